@@ -0,0 +1,170 @@
+package main
+
+import (
+	"appointment-service/internal/dto"
+	"appointment-service/internal/model"
+	"appointment-service/internal/service"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// appointmentsCommand dispatches the appointments subcommands
+func appointmentsCommand(ctx context.Context, svc service.AppointmentServicer, duration time.Duration, out *printer, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing subcommand, want list, get, create or cancel")
+	}
+
+	switch sub, params := args[0], args[1:]; sub {
+	case "list":
+		return listAppointments(ctx, svc, out, params)
+	case "get":
+		return getAppointment(ctx, svc, out, params)
+	case "create":
+		return createAppointment(ctx, svc, duration, out, params)
+	case "cancel":
+		return cancelAppointment(ctx, svc, out, params)
+	default:
+		return fmt.Errorf("unknown appointments subcommand %q, want list, get, create or cancel", sub)
+	}
+}
+
+// listAppointments prints one page of appointments, filtered like the API's
+// listing endpoints
+func listAppointments(ctx context.Context, svc service.AppointmentServicer, out *printer, args []string) error {
+	fs := newFlagSet("appointments list", "[flags]")
+	trainerID := fs.Int64("trainer", 0, "only this trainer's appointments")
+	userID := fs.Int64("user", 0, "only this user's appointments")
+	var from, to timeFlag
+	fs.Var(&from, "from", "only appointments starting at or after this time")
+	fs.Var(&to, "to", "only appointments starting before this time")
+	status := fs.String("status", "", "comma separated statuses, e.g. scheduled,completed")
+	sort := fs.String("sort", "", "asc or desc by start time")
+	limit := fs.Int("limit", model.DefaultPageSize, "page size")
+	cursor := fs.String("cursor", "", "next_cursor of the previous page")
+	var tz locationFlag
+	fs.Var(&tz, "tz", "show times in this time zone")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	request := dto.ListAppointmentsRequest{
+		TrainerId: *trainerID,
+		UserId:    *userID,
+		ListOptionsRequest: dto.ListOptionsRequest{
+			StartsAt: from.Time,
+			EndsAt:   to.Time,
+			Sort:     *sort,
+			Limit:    *limit,
+			Cursor:   *cursor,
+		},
+	}
+	if *status != "" {
+		request.Status = strings.Split(*status, ",")
+	}
+
+	query, err := dto.ToAppointmentQuery(&request)
+	if err != nil {
+		return err
+	}
+	page, err := svc.List(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	response := dto.ToListAppointmentsResponse(page, tz.Location)
+	return out.print(response, func(w io.Writer) {
+		appointmentTable(w, response.Appointments, tz.Location)
+		if response.NextCursor != "" {
+			fmt.Fprintf(w, "\nnext cursor: %s\n", response.NextCursor)
+		}
+	})
+}
+
+func getAppointment(ctx context.Context, svc service.AppointmentServicer, out *printer, args []string) error {
+	fs := newFlagSet("appointments get", "[flags] ID")
+	var tz locationFlag
+	fs.Var(&tz, "tz", "show times in this time zone")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	id, err := parseID(fs, "appointment")
+	if err != nil {
+		return err
+	}
+
+	appointment, err := svc.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	return printAppointment(out, appointment, tz.Location)
+}
+
+// createAppointment books an appointment with the same checks as the API.
+// Without -end it lasts the configured appointment duration.
+func createAppointment(ctx context.Context, svc service.AppointmentServicer, duration time.Duration, out *printer, args []string) error {
+	fs := newFlagSet("appointments create", "-trainer ID -user ID -start TIME [-end TIME]")
+	trainerID := fs.Int64("trainer", 0, "trainer ID (required)")
+	userID := fs.Int64("user", 0, "user ID (required)")
+	var start, end timeFlag
+	fs.Var(&start, "start", "start time (required)")
+	fs.Var(&end, "end", "end time, defaults to one appointment duration after the start")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *trainerID <= 0 || *userID <= 0 || start.IsZero() {
+		fs.Usage()
+		return fmt.Errorf("-trainer, -user and -start are required")
+	}
+	if end.IsZero() {
+		end.Time = start.Add(duration)
+	}
+
+	created, err := svc.Create(ctx, model.Appointment{
+		TrainerId: *trainerID,
+		UserId:    *userID,
+		StartTime: start.UTC(),
+		EndTime:   end.UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	return printAppointment(out, created, nil)
+}
+
+// cancelAppointment cancels an appointment. Without -version it cancels
+// whatever the current version is, which is what an operator usually means.
+func cancelAppointment(ctx context.Context, svc service.AppointmentServicer, out *printer, args []string) error {
+	fs := newFlagSet("appointments cancel", "[flags] ID")
+	version := fs.Int64("version", 0, "only cancel if the appointment is still at this version")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	id, err := parseID(fs, "appointment")
+	if err != nil {
+		return err
+	}
+
+	if *version == 0 {
+		current, err := svc.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		*version = current.Version
+	}
+
+	cancelled, err := svc.Cancel(ctx, id, *version)
+	if err != nil {
+		return err
+	}
+	return printAppointment(out, cancelled, nil)
+}
+
+func printAppointment(out *printer, appointment *model.Appointment, loc *time.Location) error {
+	response := dto.ToAppointmentResponse(appointment, loc)
+	return out.print(response, func(w io.Writer) {
+		appointmentTable(w, []dto.AppointmentResponse{response}, loc)
+	})
+}
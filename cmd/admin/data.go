@@ -0,0 +1,129 @@
+package main
+
+import (
+	"appointment-service/internal/dto"
+	"appointment-service/internal/model"
+	"appointment-service/internal/service"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// exportCommand streams matching appointments as CSV, NDJSON or Parquet, in
+// the same shape as the API's export endpoint. The format defaults to the
+// file's extension, and to NDJSON for stdout.
+func exportCommand(ctx context.Context, svc service.AppointmentServicer, stdout io.Writer, args []string) error {
+	fs := newFlagSet("export", "[flags]")
+	trainerID := fs.Int64("trainer", 0, "only this trainer's appointments")
+	userID := fs.Int64("user", 0, "only this user's appointments")
+	var from, to timeFlag
+	fs.Var(&from, "from", "only appointments starting at or after this time")
+	fs.Var(&to, "to", "only appointments starting before this time")
+	format := fs.String("format", "", "csv, ndjson or parquet, by default from the file extension")
+	file := fs.String("file", "-", "file to write, - for stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *format == "" {
+		*format = string(dto.ExportNDJSON)
+		if ext := strings.TrimPrefix(filepath.Ext(*file), "."); ext == "csv" || ext == "parquet" {
+			*format = ext
+		}
+	}
+	exportFormat, err := dto.ParseExportFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	w := stdout
+	if *file != "-" {
+		f, err := os.Create(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	query := model.AppointmentQuery{
+		TrainerId: *trainerID,
+		UserId:    *userID,
+		StartsAt:  from.UTC(),
+		EndsAt:    to.UTC(),
+	}
+	export := dto.NewExportWriter(w, exportFormat)
+	if err := svc.Export(ctx, query, export.Write); err != nil {
+		return err
+	}
+	return export.Close()
+}
+
+// importCommand imports a CSV or NDJSON file through the service's bulk
+// import, with the same checks, modes and report as the API's import
+// endpoint. The format defaults to CSV for .csv files and NDJSON otherwise.
+func importCommand(ctx context.Context, svc service.AppointmentServicer, out *printer, args []string) error {
+	fs := newFlagSet("import", "[flags] FILE")
+	format := fs.String("format", "", "csv or ndjson, by default from the file extension")
+	mode := fs.String("mode", string(model.ImportAllOrNothing), "all_or_nothing or partial")
+	dryRun := fs.Bool("dry-run", false, "check every row without importing anything")
+	batchSize := fs.Int("batch-size", model.DefaultImportBatchSize, "rows committed per batch in partial mode")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("want exactly one file, - for stdin")
+	}
+
+	var r io.Reader = os.Stdin
+	if fs.Arg(0) != "-" {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	if *format == "" {
+		*format = string(dto.ImportNDJSON)
+		if strings.EqualFold(filepath.Ext(fs.Arg(0)), ".csv") {
+			*format = string(dto.ImportCSV)
+		}
+	}
+
+	rows, err := dto.ParseImport(r, dto.ImportFormat(*format))
+	if err != nil {
+		return err
+	}
+	report, err := svc.Import(ctx, rows, model.ImportOptions{
+		Mode:      model.ImportMode(*mode),
+		DryRun:    *dryRun,
+		BatchSize: *batchSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	response := dto.ToImportReportResponse(report)
+	err = out.print(response, func(w io.Writer) {
+		fmt.Fprintln(w, "LINE\tSTATUS\tAPPOINTMENT\tERROR")
+		for _, row := range response.Rows {
+			var messages []string
+			for _, v := range row.Errors {
+				messages = append(messages, v.Code+": "+v.Message)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%d\t%s\n", row.Line, row.Status, row.AppointmentId, strings.Join(messages, "; "))
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if report.Rejected > 0 {
+		return fmt.Errorf("%d of %d rows rejected, %d imported", report.Rejected, len(report.Rows), report.Imported)
+	}
+	return nil
+}
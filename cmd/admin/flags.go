@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// timeFlag is an RFC 3339 time, e.g. 2025-06-02T09:00:00-07:00
+type timeFlag struct {
+	time.Time
+}
+
+func (f *timeFlag) String() string {
+	if f.IsZero() {
+		return ""
+	}
+	return f.Format(time.RFC3339)
+}
+
+func (f *timeFlag) Set(value string) error {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return fmt.Errorf("want an RFC 3339 time such as 2025-06-02T09:00:00Z")
+	}
+	f.Time = t
+	return nil
+}
+
+// locationFlag is an IANA time zone name, e.g. America/New_York
+type locationFlag struct {
+	*time.Location
+}
+
+func (f *locationFlag) String() string {
+	if f.Location == nil {
+		return ""
+	}
+	return f.Location.String()
+}
+
+func (f *locationFlag) Set(value string) error {
+	loc, err := time.LoadLocation(value)
+	if err != nil || value == "Local" {
+		return fmt.Errorf("want an IANA time zone such as America/New_York")
+	}
+	f.Location = loc
+	return nil
+}
+
+// newFlagSet returns a flag set for one subcommand that reports errors
+// rather than exiting
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: admin %s %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseID parses the single positional ID argument of a subcommand
+func parseID(fs *flag.FlagSet, what string) (int64, error) {
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 0, fmt.Errorf("want exactly one %s ID", what)
+	}
+	id, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid %s ID %q", what, fs.Arg(0))
+	}
+	return id, nil
+}
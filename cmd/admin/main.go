@@ -0,0 +1,162 @@
+// Command admin operates the appointment service from the command line. It
+// uses the same config, app and service packages as the API, so it sees the
+// same storage and applies the same business rules.
+package main
+
+import (
+	"appointment-service/internal/app"
+	"appointment-service/internal/config"
+	"appointment-service/internal/errors"
+	"appointment-service/internal/logger"
+	"appointment-service/internal/migrate"
+	repofactory "appointment-service/internal/repository/factory"
+	"appointment-service/internal/version"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	_ "time/tzdata" // trainer time zones must resolve even on hosts without zoneinfo
+
+	"github.com/gin-gonic/gin"
+)
+
+const usage = `usage: admin [-o table|json] [-v] <command> [arguments]
+
+commands:
+  appointments list|get|create|cancel   manage appointments
+  trainers week|availability            inspect a trainer's schedule
+  migrate up|down|status|force          manage the database schema
+  export                                write appointments as CSV, NDJSON or Parquet
+  import FILE                           bulk import appointments from CSV or NDJSON
+  config                                show the effective configuration
+
+Storage and business rules are configured through the same config file,
+environment variables and flags as the API.
+Run "admin <command> <subcommand> -h" for a subcommand's flags.
+
+flags:`
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "admin: %s\n", describeError(err))
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	global := flag.NewFlagSet("admin", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	format := global.String("o", "table", "output format, table or json")
+	verbose := global.Bool("v", false, "log at debug level, otherwise only warnings and errors")
+	configFlags := config.RegisterFlags(global)
+	global.Usage = func() {
+		fmt.Fprintln(stderr, usage)
+		global.PrintDefaults()
+	}
+	if err := global.Parse(args); err != nil {
+		return err
+	}
+	if global.NArg() == 0 {
+		global.Usage()
+		return fmt.Errorf("missing command")
+	}
+
+	out, err := newPrinter(*format, stdout)
+	if err != nil {
+		return err
+	}
+
+	// Logs go to stderr, so stdout only carries the command's output
+	// ----------------------------------------------------------------
+	cfg, err := config.Load(configFlags)
+	if err != nil {
+		return err
+	}
+	logLevel := new(slog.LevelVar)
+	log := initLogger(cfg, *verbose, logLevel, stderr)
+
+	command, params := global.Arg(0), global.Args()[1:]
+	switch command {
+	case "config":
+		return printConfig(out, cfg)
+
+	case "migrate":
+		migrator, closeDB, err := repofactory.NewMigrator(cfg, log)
+		if err != nil {
+			return err
+		}
+		defer closeDB()
+		return migrate.RunCommand(ctx, migrator, params, stdout)
+
+	case "appointments", "trainers", "export", "import":
+	default:
+		return fmt.Errorf("unknown command %q, run admin -h for the list", command)
+	}
+
+	// The application wires up the HTTP server too, keep its route dump
+	// off stdout
+	gin.SetMode(gin.ReleaseMode)
+	application, err := app.New(cfg, log, logLevel)
+	if err != nil {
+		return fmt.Errorf("failed to initialize application: %w", err)
+	}
+	defer application.Close()
+	svc := application.AppointmentService
+
+	switch command {
+	case "appointments":
+		return appointmentsCommand(ctx, svc, cfg.Business.AppointmentDuration, out, params)
+	case "trainers":
+		return trainersCommand(ctx, svc, out, params)
+	case "export":
+		return exportCommand(ctx, svc, stdout, params)
+	case "import":
+		return importCommand(ctx, svc, out, params)
+	default:
+		return fmt.Errorf("unknown command %q, run admin -h for the list", command)
+	}
+}
+
+func initLogger(cfg *config.Config, verbose bool, level *slog.LevelVar, output io.Writer) *slog.Logger {
+	level.Set(slog.LevelWarn)
+	if verbose {
+		level.Set(slog.LevelDebug)
+	}
+
+	info := version.GetInfo()
+	return logger.NewLogger(logger.Config{
+		Attributes: logger.Attributes{
+			ServiceName:    "appointment-admin",
+			ServiceVersion: info.Version,
+			CommitSha:      info.Commit,
+			BuildTime:      info.BuildTime,
+		},
+		Level:  level,
+		Format: logger.ParseFormat(cfg.LogFormat, logger.FormatText),
+		Output: output,
+		Redact: cfg.LogRedact,
+	})
+}
+
+// describeError adds the machine readable code and any violations of an
+// AppError to its message
+func describeError(err error) string {
+	appErr, ok := errors.IsAppError(err)
+	if !ok {
+		return err.Error()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s", appErr.ErrorCode, appErr.Error())
+	for _, v := range appErr.Violations {
+		fmt.Fprintf(&b, "\n  %s: %s", v.Field, v.Message)
+	}
+	return b.String()
+}
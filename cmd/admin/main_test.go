@@ -0,0 +1,147 @@
+package main
+
+import (
+	"appointment-service/internal/dto"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAdmin tests the admin commands against one sqlite3 database, so each
+// command sees what the previous ones did.
+//
+// It includes the following test cases:
+//
+// * Create books an appointment and rejects a conflicting one
+// * List and get print tables or the API's JSON shapes
+// * Cancel without a version cancels the current version
+// * Export writes CSV, Parquet by file extension, and NDJSON that import reads back, as a dry run or partially
+// * Import reads CSV by file extension
+// * Config hides the database password, admin token and API keys, as JSON and as text
+// * Unknown commands and output formats are rejected
+func TestAdmin(t *testing.T) {
+	t.Setenv("STORAGE_TYPE", "sqlite3")
+	t.Setenv("DB_FILE", filepath.Join(t.TempDir(), "admin.db"))
+	t.Setenv("DB_PASSWORD", "hunter2")
+
+	admin := func(args ...string) (string, error) {
+		var stdout, stderr bytes.Buffer
+		err := run(context.Background(), args, &stdout, &stderr)
+		return stdout.String(), err
+	}
+
+	t.Run("create", func(t *testing.T) {
+		out, err := admin("appointments", "create", "-trainer", "1", "-user", "2", "-start", "2025-06-02T16:00:00Z")
+		require.NoError(t, err)
+		assert.Contains(t, out, "2025-06-02T16:30:00Z")
+
+		_, err = admin("appointments", "create", "-trainer", "1", "-user", "3", "-start", "2025-06-02T16:00:00Z")
+		assert.ErrorContains(t, err, "not available")
+
+		_, err = admin("appointments", "create", "-trainer", "1")
+		assert.ErrorContains(t, err, "required")
+	})
+
+	t.Run("list and get", func(t *testing.T) {
+		out, err := admin("appointments", "list", "-trainer", "1")
+		require.NoError(t, err)
+		assert.Contains(t, out, "STATUS")
+		assert.Contains(t, out, "scheduled")
+
+		out, err = admin("-o", "json", "appointments", "get", "-tz", "America/New_York", "1")
+		require.NoError(t, err)
+		var appointment dto.AppointmentResponse
+		require.NoError(t, json.Unmarshal([]byte(out), &appointment))
+		assert.Equal(t, int64(2), appointment.UserId)
+		require.NotNil(t, appointment.LocalTimes)
+		assert.Equal(t, "2025-06-02T12:00:00-04:00", appointment.LocalStartTime)
+
+		_, err = admin("appointments", "get", "99")
+		assert.ErrorContains(t, err, "not found")
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		out, err := admin("-o", "json", "appointments", "cancel", "1")
+		require.NoError(t, err)
+		var appointment dto.AppointmentResponse
+		require.NoError(t, json.Unmarshal([]byte(out), &appointment))
+		assert.Equal(t, "cancelled", appointment.Status)
+		assert.Equal(t, int64(2), appointment.Version)
+	})
+
+	t.Run("export and import", func(t *testing.T) {
+		out, err := admin("export", "-trainer", "1")
+		require.NoError(t, err)
+		assert.Equal(t, 1, strings.Count(out, "\n"))
+
+		csvOut, err := admin("export", "-format", "csv", "-trainer", "1")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(csvOut, "id,trainer_id,user_id,start_time,end_time,status,version\n1,1,2,"))
+
+		parquetFile := filepath.Join(t.TempDir(), "appointments.parquet")
+		_, err = admin("export", "-file", parquetFile)
+		require.NoError(t, err)
+		data, err := os.ReadFile(parquetFile)
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(data, []byte("PAR1")))
+
+		file := filepath.Join(t.TempDir(), "import.ndjson")
+		lines := out +
+			`{"trainer_id":1,"user_id":4,"start_time":"2025-06-02T16:00:00Z","end_time":"2025-06-02T16:30:00Z"}` + "\n" +
+			`{"trainer_id":1,"user_id":5,"start_time":"2025-06-02T16:00:00Z","end_time":"2025-06-02T16:30:00Z"}` + "\n" +
+			"not json\n"
+		require.NoError(t, os.WriteFile(file, []byte(lines), 0o600))
+
+		out, err = admin("-o", "json", "import", "-dry-run", file)
+		assert.ErrorContains(t, err, "2 of 4 rows rejected, 0 imported")
+		var report dto.ImportReportResponse
+		require.NoError(t, json.Unmarshal([]byte(out), &report))
+		require.Len(t, report.Rows, 4)
+		assert.Equal(t, "accepted", report.Rows[0].Status)
+		assert.Equal(t, "TRAINER_UNAVAILABLE", report.Rows[2].Errors[0].Code)
+		assert.Equal(t, "INVALID_REQUEST", report.Rows[3].Errors[0].Code)
+
+		out, err = admin("-o", "json", "import", "-mode", "partial", file)
+		assert.ErrorContains(t, err, "2 of 4 rows rejected, 2 imported")
+		require.NoError(t, json.Unmarshal([]byte(out), &report))
+		assert.Equal(t, "imported", report.Rows[0].Status)
+		assert.Equal(t, "imported", report.Rows[1].Status)
+
+		csvFile := filepath.Join(t.TempDir(), "import.csv")
+		csv := "trainer_id,user_id,start_time,end_time\n1,6,2025-06-02T17:00:00Z,2025-06-02T17:30:00Z\n"
+		require.NoError(t, os.WriteFile(csvFile, []byte(csv), 0o600))
+		out, err = admin("import", csvFile)
+		require.NoError(t, err)
+		assert.Contains(t, out, "imported")
+	})
+
+	t.Run("config", func(t *testing.T) {
+		t.Setenv("ADMIN_TOKEN", "open-sesame")
+		t.Setenv("RATE_LIMIT_API_KEYS", "supersecret1,supersecret2")
+
+		for _, format := range []string{"json", "table"} {
+			out, err := admin("-o", format, "config")
+			require.NoError(t, err)
+			assert.Contains(t, out, "sqlite3", format)
+			assert.Contains(t, out, "***", format)
+			for _, secret := range []string{"hunter2", "open-sesame", "supersecret"} {
+				assert.NotContains(t, out, secret, format)
+			}
+		}
+	})
+
+	t.Run("bad usage", func(t *testing.T) {
+		_, err := admin("bogus")
+		assert.ErrorContains(t, err, "unknown command")
+
+		_, err = admin("-o", "yaml", "config")
+		assert.ErrorContains(t, err, "unknown output format")
+	})
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"appointment-service/internal/config"
+	"appointment-service/internal/dto"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// printer writes command results as an aligned table or as indented JSON.
+// JSON output uses the API's response DTOs, so scripts see the same shapes
+// as API clients.
+type printer struct {
+	json bool
+	w    io.Writer
+}
+
+func newPrinter(format string, w io.Writer) (*printer, error) {
+	switch format {
+	case "table":
+		return &printer{w: w}, nil
+	case "json":
+		return &printer{json: true, w: w}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q, use table or json", format)
+	}
+}
+
+// print writes v as JSON, or calls table to write it as tab separated
+// columns
+func (p *printer) print(v any, table func(w io.Writer)) error {
+	if p.json {
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	table(tw)
+	return tw.Flush()
+}
+
+// appointmentTable writes one row per appointment, with times in loc, or UTC
+// when loc is nil
+func appointmentTable(w io.Writer, appointments []dto.AppointmentResponse, loc *time.Location) {
+	fmt.Fprintln(w, "ID\tTRAINER\tUSER\tSTART\tEND\tSTATUS\tVERSION")
+	for _, a := range appointments {
+		fmt.Fprintf(w, "%d\t%d\t%d\t%s\t%s\t%s\t%d\n",
+			a.Id, a.TrainerId, a.UserId, formatTime(a.StartTime, loc), formatTime(a.EndTime, loc), a.Status, a.Version)
+	}
+}
+
+func formatTime(t time.Time, loc *time.Location) string {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return t.In(loc).Format(time.RFC3339)
+}
+
+// printConfig shows the effective configuration, with every secret hidden
+func printConfig(out *printer, cfg *config.Config) error {
+	masked := cfg.Masked()
+	return out.print(masked, func(w io.Writer) {
+		fmt.Fprintln(w, masked.String())
+	})
+}
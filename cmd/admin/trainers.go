@@ -0,0 +1,141 @@
+package main
+
+import (
+	"appointment-service/internal/dto"
+	"appointment-service/internal/model"
+	"appointment-service/internal/service"
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// trainersCommand dispatches the trainers subcommands
+func trainersCommand(ctx context.Context, svc service.AppointmentServicer, out *printer, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing subcommand, want week or availability")
+	}
+
+	switch sub, params := args[0], args[1:]; sub {
+	case "week":
+		return trainerWeek(ctx, svc, out, params)
+	case "availability":
+		return trainerAvailability(ctx, svc, out, params)
+	default:
+		return fmt.Errorf("unknown trainers subcommand %q, want week or availability", sub)
+	}
+}
+
+// weekResponse is a trainer's week, per day and per appointment, in the
+// trainer's time zone
+type weekResponse struct {
+	TrainerId    int64                     `json:"trainer_id"`
+	TimeZone     string                    `json:"time_zone"`
+	Days         []dto.CalendarDayResponse `json:"days"`
+	Appointments []dto.AppointmentResponse `json:"appointments"`
+}
+
+// trainerWeek shows the Monday to Sunday week containing -date, in the
+// trainer's own time zone: open slots per day, then every appointment
+func trainerWeek(ctx context.Context, svc service.AppointmentServicer, out *printer, args []string) error {
+	fs := newFlagSet("trainers week", "[flags] TRAINER_ID")
+	date := fs.String("date", time.Now().Format(time.DateOnly), "any date in the week, YYYY-MM-DD")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	trainerID, err := parseID(fs, "trainer")
+	if err != nil {
+		return err
+	}
+	day, err := time.Parse(time.DateOnly, *date)
+	if err != nil {
+		return fmt.Errorf("invalid -date %q, want YYYY-MM-DD", *date)
+	}
+
+	monday := day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	calendar, err := svc.GetCalendar(ctx, trainerID, monday, monday.AddDate(0, 0, 6), nil)
+	if err != nil {
+		return err
+	}
+
+	loc := calendar.Location
+	first := calendar.Days[0].Date
+	query := model.AppointmentQuery{
+		TrainerId: trainerID,
+		StartsAt:  first.UTC(),
+		EndsAt:    time.Date(first.Year(), first.Month(), first.Day()+7, 0, 0, 0, 0, loc).UTC(),
+	}
+	response := weekResponse{
+		TrainerId:    trainerID,
+		TimeZone:     loc.String(),
+		Days:         dto.ToCalendarResponse(calendar).Days,
+		Appointments: []dto.AppointmentResponse{},
+	}
+	err = svc.Export(ctx, query, func(a model.Appointment) error {
+		response.Appointments = append(response.Appointments, dto.ToAppointmentResponse(&a, loc))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return out.print(response, func(w io.Writer) {
+		fmt.Fprintf(w, "Trainer %d, week of %s (%s)\n\n", trainerID, first.Format(time.DateOnly), loc)
+		fmt.Fprintln(w, "DAY\tDATE\tOPEN SLOTS\tFIRST\tLAST\tSTATE")
+		for i, d := range calendar.Days {
+			state := ""
+			switch {
+			case d.Closed:
+				state = "closed"
+			case d.FullyBooked:
+				state = "fully booked"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n",
+				d.Date.Weekday().String()[:3], response.Days[i].Date, d.OpenSlots,
+				clockTime(d.FirstOpen, loc), clockTime(d.LastOpen, loc), state)
+		}
+		fmt.Fprintln(w)
+		appointmentTable(w, response.Appointments, loc)
+	})
+}
+
+// trainerAvailability lists the trainer's open slots in a window
+func trainerAvailability(ctx context.Context, svc service.AppointmentServicer, out *printer, args []string) error {
+	fs := newFlagSet("trainers availability", "-from TIME -to TIME [flags] TRAINER_ID")
+	var from, to timeFlag
+	fs.Var(&from, "from", "window start (required)")
+	fs.Var(&to, "to", "window end (required)")
+	var tz locationFlag
+	fs.Var(&tz, "tz", "show times in this time zone")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	trainerID, err := parseID(fs, "trainer")
+	if err != nil {
+		return err
+	}
+	if from.IsZero() || to.IsZero() || !to.After(from.Time) {
+		return fmt.Errorf("-from and -to are required and -to must be after -from")
+	}
+
+	slots, err := svc.GetAvailability(ctx, trainerID, from.Time, to.Time)
+	if err != nil {
+		return err
+	}
+
+	response := dto.ToAvailabilityResponse(slots, tz.Location)
+	return out.print(response, func(w io.Writer) {
+		fmt.Fprintln(w, "START\tEND")
+		for _, slot := range slots {
+			fmt.Fprintf(w, "%s\t%s\n", formatTime(slot.StartTime, tz.Location), formatTime(slot.EndTime, tz.Location))
+		}
+	})
+}
+
+// clockTime formats t as a local wall clock time, or "-" when it is zero
+func clockTime(t time.Time, loc *time.Location) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.In(loc).Format("15:04")
+}
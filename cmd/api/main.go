@@ -4,19 +4,22 @@ import (
 	"appointment-service/internal/app"
 	"appointment-service/internal/config"
 	"appointment-service/internal/logger"
+	"appointment-service/internal/tracing"
 	"appointment-service/internal/version"
 	"context"
+	"flag"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // trainer time zones must resolve even on hosts without zoneinfo
 )
 
 func main() {
 	if err := run(); err != nil {
-		fmt.Fprintf(os.Stderr, "startup error: %v\n", err)
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
 }
@@ -24,50 +27,62 @@ func main() {
 func run() error {
 	// Load configuration, get version info and initialize logger
 	// -----------------------------------------------------------
-	cfg := config.Load()
+	configFlags := config.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+	cfg, err := config.Load(configFlags)
+	if err != nil {
+		return err
+	}
 	versionInfo := version.GetInfo()
-	logger := initLogger(cfg, &versionInfo)
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(logger.ParseLogLevel(cfg.LogLevel, slog.LevelInfo))
+	logger := initLogger(cfg, &versionInfo, logLevel)
+
+	// Run a subcommand instead of the server if one is given
+	// -------------------------------------------------------
+	if flag.Arg(0) == "migrate" {
+		return runMigrate(cfg, logger, flag.Args()[1:])
+	}
+
+	// Set up tracing, flushing pending spans on exit
+	// ----------------------------------------------
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.TracesExporter, "appointment-service", versionInfo)
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("Failed to flush traces", "error", err)
+		}
+	}()
 
 	// Create application container
 	// ----------------------------
-	app, err := app.New(cfg, logger)
+	app, err := app.New(cfg, logger, logLevel)
 	if err != nil {
 		return fmt.Errorf("failed to initialize application: %w", err)
 	}
-	defer app.Close()
 
-	// Set up simple signal handling
-	// -----------------------------
-	quit := make(chan os.Signal, 1)
+	// Set up signals: a second stop signal forces shutdown, SIGHUP reloads config
+	// ---------------------------------------------------------------------------
+	quit := make(chan os.Signal, 2)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
-	// Start server in anon goroutine
-	// ------------------------------
-	go func() {
-		logger.Info("Starting server", slog.String("port", cfg.Port))
-		if err := app.Server.Run(fmt.Sprintf(":%s", cfg.Port)); err != nil {
-			logger.Error("Server failed", "error", err)
-		}
-	}()
-
-	// Wait for interrupt signal
-	// -------------------------
-	<-quit
-	logger.Info("Shutting down server...")
-
-	// Give running requests 5 seconds to complete
-	// -------------------------------------------
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	if err := app.Server.Shutdown(ctx); err != nil {
-		return fmt.Errorf("server shutdown error: %w", err)
-	}
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	app.AddWorker(app.Reloader(hup, func() (*config.Config, error) {
+		return config.Load(configFlags)
+	}))
 
-	return nil
+	// Serve until signalled, then shut down in order
+	// ----------------------------------------------
+	logger.Info("Starting server", slog.String("port", cfg.Port))
+	return app.Run(quit)
 }
 
-func initLogger(cfg *config.Config, version *version.Info) *slog.Logger {
+func initLogger(cfg *config.Config, version *version.Info, level *slog.LevelVar) *slog.Logger {
 	logConfig := logger.Config{
 		Attributes: logger.Attributes{
 			ServiceName:    "appointment-service",
@@ -75,9 +90,11 @@ func initLogger(cfg *config.Config, version *version.Info) *slog.Logger {
 			CommitSha:      version.Commit,
 			BuildTime:      version.BuildTime,
 		},
-		Level:     logger.ParseLogLevel(cfg.LogLevel, slog.LevelInfo),
+		Level:     level,
 		AddSource: cfg.LogSource,
 		Format:    logger.ParseFormat(cfg.LogFormat, logger.FormatText),
+		Redact:    cfg.LogRedact,
+		Sample:    cfg.LogSample,
 	}
 	return logger.NewLogger(logConfig)
 }
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"appointment-service/internal/config"
+	"appointment-service/internal/migrate"
+	repofactory "appointment-service/internal/repository/factory"
+	"context"
+	"log/slog"
+	"os"
+)
+
+// runMigrate runs the migrate subcommand against the configured database
+func runMigrate(cfg *config.Config, logger *slog.Logger, args []string) error {
+	migrator, closeDB, err := repofactory.NewMigrator(cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	return migrate.RunCommand(context.Background(), migrator, args, os.Stdout)
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"appointment-service/internal/dto"
+	"appointment-service/internal/errors"
+	"appointment-service/internal/logger"
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requireAdmin only lets requests bearing the admin token through, as
+// "Authorization: Bearer <token>"
+func (s *Server) requireAdmin(c *gin.Context) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.AdminToken)) != 1 {
+		c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+		handleError(c, errors.UnauthorizedError("a valid admin token is required"))
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// GetLogLevel returns the current log level
+func (s *Server) GetLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.LogLevelResponse{Level: levelName(s.logLevel.Level())})
+}
+
+// SetLogLevel changes the log level at once, until it is changed again or
+// the configured level changes on a reload
+func (s *Server) SetLogLevel(c *gin.Context) {
+
+	// Bind and parse the level
+	// ------------------------
+	var req dto.LogLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleError(c, bindingError(err))
+		return
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(req.Level)); err != nil {
+		handleError(c, errors.ValidationError("level must be debug, info, warn or error").
+			WithCode(errors.CodeFieldInvalid).
+			WithDetail("field", "level"))
+		return
+	}
+
+	// Change the level
+	// ----------------
+	old := s.logLevel.Level()
+	s.logLevel.Set(level)
+	logger.Audit(c.Request.Context(), s.log(c), "Log level changed",
+		"old", levelName(old),
+		"new", levelName(level),
+		"via", "admin endpoint",
+		"client_ip", c.ClientIP())
+
+	c.JSON(http.StatusOK, dto.LogLevelResponse{Level: levelName(level)})
+}
+
+// levelName returns the level as configured, e.g. "debug"
+func levelName(level slog.Level) string {
+	return strings.ToLower(level.String())
+}
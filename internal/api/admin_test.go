@@ -0,0 +1,103 @@
+package api
+
+import (
+	"appointment-service/internal/config"
+	"appointment-service/internal/health"
+	"appointment-service/internal/logger"
+	"appointment-service/internal/metrics"
+	"appointment-service/internal/model"
+	"appointment-service/internal/repository/memory"
+	"appointment-service/internal/service"
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAdmin tests the admin endpoints.
+//
+// It includes the following test cases:
+//
+// * Not served without an admin token configured
+// * Requests without the token, or with a wrong one, are unauthorized
+// * Reading the log level
+// * Changing the log level takes effect at once, with an audit line
+// * Unknown log levels are rejected
+func TestAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var out bytes.Buffer
+	level := new(slog.LevelVar)
+	level.Set(slog.LevelWarn)
+	log := logger.NewLogger(logger.Config{Level: level, Format: logger.FormatText, Output: &out})
+
+	newServer := func(token string) *Server {
+		svc := service.NewAppointmentService(memory.New(log), &service.StaticWorkingHours{Default: model.WorkingHours{Location: time.UTC, Start: 8 * time.Hour, End: 18 * time.Hour}}, service.DefaultRules(), log)
+		server, err := NewServer(&config.Config{AdminToken: token}, svc, metrics.New(), health.NewChecker(0), level, log)
+		require.NoError(t, err)
+		return server
+	}
+	server := newServer("s3cret")
+
+	do := func(server *Server, method, token, body string) *httptest.ResponseRecorder {
+		var reader io.Reader
+		if body != "" {
+			reader = strings.NewReader(body)
+		}
+		req := httptest.NewRequest(method, "/admin/log-level", reader)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, do(newServer(""), "GET", "", "").Code)
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		for _, token := range []string{"", "guess"} {
+			rec := do(server, "PUT", token, `{"level":"debug"}`)
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"code":"UNAUTHORIZED"`)
+			assert.Equal(t, `Bearer realm="admin"`, rec.Header().Get("WWW-Authenticate"))
+		}
+		assert.Equal(t, slog.LevelWarn, level.Level())
+	})
+
+	t.Run("get", func(t *testing.T) {
+		rec := do(server, "GET", "s3cret", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"level":"warn"}`, rec.Body.String())
+	})
+
+	t.Run("set", func(t *testing.T) {
+		out.Reset()
+		rec := do(server, "PUT", "s3cret", `{"level":"DEBUG"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"level":"debug"}`, rec.Body.String())
+		assert.Equal(t, slog.LevelDebug, level.Level())
+
+		assert.Contains(t, out.String(), `level=AUDIT msg="Log level changed"`)
+		assert.Contains(t, out.String(), `old=warn new=debug via="admin endpoint"`)
+		assert.True(t, log.Enabled(context.Background(), slog.LevelDebug))
+	})
+
+	t.Run("unknown level", func(t *testing.T) {
+		rec := do(server, "PUT", "s3cret", `{"level":"chatty"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"code":"FIELD_INVALID"`)
+		assert.Equal(t, slog.LevelDebug, level.Level())
+	})
+}
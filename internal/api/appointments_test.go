@@ -0,0 +1,157 @@
+package api
+
+import (
+	"appointment-service/internal/config"
+	"appointment-service/internal/dto"
+	"appointment-service/internal/health"
+	"appointment-service/internal/metrics"
+	"appointment-service/internal/model"
+	"appointment-service/internal/repository/memory"
+	"appointment-service/internal/service"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetAppointment tests looking up a single appointment by ID.
+//
+// It includes the following test cases:
+//
+// * A known appointment, with its version as ETag
+// * An unknown ID is not found
+// * IDs of zero and below are rejected
+func TestGetAppointment(t *testing.T) {
+	server, created := newAppointmentsServer(t)
+
+	t.Run("found", func(t *testing.T) {
+		rec := get(server, "/api/v1/appointments/"+strconv.FormatInt(created[0].Id, 10))
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `"1"`, rec.Header().Get("ETag"))
+
+		var got dto.AppointmentResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+		assert.Equal(t, created[0].Id, got.Id)
+		assert.Equal(t, int64(7), got.UserId)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		rec := get(server, "/api/v1/appointments/999")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"code":"APPOINTMENT_NOT_FOUND"`)
+	})
+
+	t.Run("invalid ID", func(t *testing.T) {
+		for _, id := range []string{"0", "-1"} {
+			rec := get(server, "/api/v1/appointments/"+id)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, id)
+			assert.Empty(t, rec.Header().Get("ETag"), id)
+		}
+	})
+}
+
+// TestListUserAppointments tests listing a client's own appointments.
+//
+// It includes the following test cases:
+//
+// * Every appointment of the user, across trainers, and no one else's
+// * A start time range
+// * A status filter
+// * Cursor paging visits every appointment once, in order
+// * User IDs of zero and below are rejected
+func TestListUserAppointments(t *testing.T) {
+	server, created := newAppointmentsServer(t)
+	ids := func(t *testing.T, query url.Values) ([]int64, string) {
+		rec := get(server, "/api/v1/appointments/users/7?"+query.Encode())
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var page dto.ListAppointmentsResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+		var ids []int64
+		for _, apt := range page.Appointments {
+			assert.Equal(t, int64(7), apt.UserId)
+			ids = append(ids, apt.Id)
+		}
+		return ids, page.NextCursor
+	}
+	// created are user 7's appointments at 9, 10 (cancelled), 11 and 12, then
+	// user 8's at 9
+	a, b, c, d := created[0].Id, created[1].Id, created[2].Id, created[3].Id
+
+	t.Run("everything", func(t *testing.T) {
+		got, cursor := ids(t, url.Values{})
+		assert.Equal(t, []int64{a, b, c, d}, got)
+		assert.Empty(t, cursor)
+	})
+
+	t.Run("time range", func(t *testing.T) {
+		got, _ := ids(t, url.Values{"starts_at": {"2030-01-07T10:00:00Z"}, "ends_at": {"2030-01-07T12:00:00Z"}})
+		assert.Equal(t, []int64{b, c}, got)
+	})
+
+	t.Run("status", func(t *testing.T) {
+		got, _ := ids(t, url.Values{"status": {"cancelled"}})
+		assert.Equal(t, []int64{b}, got)
+	})
+
+	t.Run("paging", func(t *testing.T) {
+		query := url.Values{"limit": {"3"}}
+		first, cursor := ids(t, query)
+		assert.Equal(t, []int64{a, b, c}, first)
+		require.NotEmpty(t, cursor)
+
+		query.Set("cursor", cursor)
+		second, cursor := ids(t, query)
+		assert.Equal(t, []int64{d}, second)
+		assert.Empty(t, cursor)
+	})
+
+	t.Run("invalid user", func(t *testing.T) {
+		for _, id := range []string{"0", "-1"} {
+			assert.Equal(t, http.StatusBadRequest, get(server, "/api/v1/appointments/users/"+id).Code, id)
+		}
+	})
+}
+
+// newAppointmentsServer serves appointments of users 7 and 8 from memory
+func newAppointmentsServer(t *testing.T) (*Server, []model.Appointment) {
+	gin.SetMode(gin.TestMode)
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo := memory.New(log)
+
+	at := func(hour int) model.Appointment {
+		start := time.Date(2030, 1, 7, hour, 0, 0, 0, time.UTC)
+		return model.Appointment{TrainerId: int64(hour), UserId: 7, StartTime: start, EndTime: start.Add(30 * time.Minute), Status: model.StatusScheduled}
+	}
+	cancelled := at(10)
+	cancelled.Status = model.StatusCancelled
+	someoneElse := at(9)
+	someoneElse.UserId = 8
+	var created []model.Appointment
+	for _, apt := range []model.Appointment{at(9), cancelled, at(11), at(12), someoneElse} {
+		stored, err := repo.Create(context.Background(), apt)
+		require.NoError(t, err)
+		created = append(created, *stored)
+	}
+
+	svc := service.NewAppointmentService(repo, &service.StaticWorkingHours{Default: model.WorkingHours{Location: time.UTC, Start: 8 * time.Hour, End: 18 * time.Hour}}, service.DefaultRules(), log)
+	server, err := NewServer(&config.Config{}, svc, metrics.New(), health.NewChecker(0), new(slog.LevelVar), log)
+	require.NoError(t, err)
+	return server, created
+}
+
+func get(server *Server, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+	return rec
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"appointment-service/internal/errors"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+var registerFieldNamesOnce sync.Once
+
+// registerFieldNames makes the validator report fields by the name clients
+// use (json, form or uri tag) instead of the Go struct field name
+func registerFieldNames() {
+	registerFieldNamesOnce.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			for _, tag := range []string{"json", "form", "uri"} {
+				name := strings.Split(field.Tag.Get(tag), ",")[0]
+				if name != "" && name != "-" {
+					return name
+				}
+			}
+			return field.Name
+		})
+	})
+}
+
+// bindingError converts a Gin binding failure into a validation error
+// listing every offending field
+func bindingError(err error) *errors.AppError {
+	return errors.ValidationErrors(bindingViolations(err))
+}
+
+// violationsError reports every violation of a hand validated request in one
+// aggregated error, or nil when there are none
+func violationsError(violations []errors.Violation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+	return errors.ValidationErrors(violations)
+}
+
+// bindingViolations converts a Gin binding failure into one violation per
+// offending field. Failures that can't be tied to a field, e.g. malformed
+// JSON, become a single request level violation.
+func bindingViolations(err error) []errors.Violation {
+	var validationErrs validator.ValidationErrors
+	if stderrors.As(err, &validationErrs) {
+		violations := make([]errors.Violation, len(validationErrs))
+		for i, fieldErr := range validationErrs {
+			violations[i] = fieldViolation(fieldErr)
+		}
+		return violations
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if stderrors.As(err, &typeErr) {
+		return []errors.Violation{{
+			Field:   typeErr.Field,
+			Code:    errors.CodeFieldInvalid,
+			Message: fmt.Sprintf("%s must be a %s", typeErr.Field, typeErr.Type),
+		}}
+	}
+
+	return []errors.Violation{{Code: errors.CodeInvalidRequest, Message: err.Error()}}
+}
+
+// isFieldValidationError reports whether the binding failed on field rules
+// only, i.e. the request was decoded and the bound values can be trusted
+func isFieldValidationError(err error) bool {
+	var validationErrs validator.ValidationErrors
+	return stderrors.As(err, &validationErrs)
+}
+
+// fieldViolation describes a single failed binding rule in client terms
+func fieldViolation(fieldErr validator.FieldError) errors.Violation {
+	field := fieldErr.Field()
+	violation := errors.Violation{Field: field, Code: errors.CodeFieldInvalid}
+
+	switch fieldErr.Tag() {
+	case "required":
+		violation.Code = errors.CodeFieldRequired
+		violation.Message = fmt.Sprintf("%s is required", field)
+	case "gt":
+		violation.Message = fmt.Sprintf("%s must be greater than %s", field, fieldErr.Param())
+	case "gtfield":
+		violation.Message = fmt.Sprintf("%s must be after %s", field, toSnakeCase(fieldErr.Param()))
+	default:
+		violation.Message = fmt.Sprintf("%s failed the %q rule", field, fieldErr.Tag())
+	}
+
+	return violation
+}
+
+// toSnakeCase converts a Go field name such as StartTime to start_time
+func toSnakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"appointment-service/internal/errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setETag writes the appointment version as a strong entity tag, e.g. "3"
+func setETag(c *gin.Context, version int64) {
+	c.Header("ETag", strconv.Quote(strconv.FormatInt(version, 10)))
+}
+
+// versionFromIfMatch extracts the expected version from the If-Match header.
+// Writes are required to be conditional, so a missing header is rejected with
+// 428 and anything that is not a single strong entity tag with a version
+// is rejected as a validation error.
+func versionFromIfMatch(c *gin.Context) (int64, error) {
+	ifMatch := strings.TrimSpace(c.GetHeader("If-Match"))
+	if ifMatch == "" {
+		return 0, errors.PreconditionRequiredError("If-Match header with the appointment's ETag is required").
+			WithDetail("header", "If-Match")
+	}
+
+	// Weak tags never match under the strong comparison If-Match requires
+	if strings.HasPrefix(ifMatch, "W/") {
+		return 0, errors.PreconditionFailedError("If-Match requires a strong ETag").
+			WithCode(errors.CodeInvalidETag)
+	}
+
+	unquoted, err := strconv.Unquote(ifMatch)
+	if err != nil {
+		return 0, errors.ValidationError(fmt.Sprintf("malformed If-Match header %q", ifMatch)).
+			WithCode(errors.CodeInvalidETag).
+			WithDetail("header", "If-Match")
+	}
+
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, errors.ValidationError(fmt.Sprintf("If-Match header %q is not an appointment ETag", ifMatch)).
+			WithCode(errors.CodeInvalidETag).
+			WithDetail("header", "If-Match")
+	}
+
+	return version, nil
+}
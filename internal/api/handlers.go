@@ -3,13 +3,17 @@ package api
 import (
 	"appointment-service/internal/dto"
 	"appointment-service/internal/errors"
+	"appointment-service/internal/model"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-// ListAppointments is a handler to list all appointments for a given trainer
+// ListAppointments is a handler to list appointments for a given trainer
+// Supports time range, status and user filters, sort order and cursor pagination
 // Attach this function to Server struct for ease of setting up routes
 func (s *Server) ListAppointments(c *gin.Context) {
 
@@ -17,13 +21,32 @@ func (s *Server) ListAppointments(c *gin.Context) {
 	// -----------------------------------------------
 	var req dto.ListAppointmentsRequest
 	if err := c.ShouldBindUri(&req); err != nil {
-		handleError(c, errors.ValidationError(err.Error()))
+		handleError(c, bindingError(err))
+		return
+	}
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleError(c, bindingError(err))
+		return
+	}
+
+	// Convert the request to a query
+	// ------------------------------
+	query, err := dto.ToAppointmentQuery(&req)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	loc, err := requestLocation(c)
+	if err != nil {
+		handleError(c, err)
 		return
 	}
 
 	// List the appointments
 	// ----------------------
-	appointments, err := s.appointmentService.List(c.Request.Context(), req.TrainerId)
+	page, err := s.appointmentService.List(c.Request.Context(), query)
 	if err != nil {
 		handleError(c, err)
 		return
@@ -31,10 +54,83 @@ func (s *Server) ListAppointments(c *gin.Context) {
 
 	// Convert appointments to AppointmentResponse DTOs
 	// ------------------------------------------------
-	response := dto.ToListAppointmentsResponse(appointments)
+	response := dto.ToListAppointmentsResponse(page, loc)
 	c.JSON(http.StatusOK, response)
 }
 
+// ListUserAppointments is a handler to list a user's (client's) own appointments
+// Supports the same time range, status, sort and pagination options as ListAppointments
+func (s *Server) ListUserAppointments(c *gin.Context) {
+
+	// Bind parameters to ListUserAppointmentsRequest DTO
+	// ---------------------------------------------------
+	var req dto.ListUserAppointmentsRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		handleError(c, bindingError(err))
+		return
+	}
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleError(c, bindingError(err))
+		return
+	}
+
+	// Convert the request to a query
+	// ------------------------------
+	query, err := dto.ToUserAppointmentQuery(&req)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	loc, err := requestLocation(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	// List the user's appointments
+	// -----------------------------
+	page, err := s.appointmentService.ListClientAppointments(c.Request.Context(), req.UserId, query)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	response := dto.ToListAppointmentsResponse(page, loc)
+	c.JSON(http.StatusOK, response)
+}
+
+// GetAppointment is a handler to look up a single appointment by ID
+// The appointment's version is returned as an ETag for use with If-Match
+func (s *Server) GetAppointment(c *gin.Context) {
+
+	// Bind the appointment ID
+	// -----------------------
+	var uri dto.AppointmentIdRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		handleError(c, bindingError(err))
+		return
+	}
+
+	loc, err := requestLocation(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	// Get the appointment
+	// -------------------
+	appointment, err := s.appointmentService.Get(c.Request.Context(), uri.Id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	setETag(c, appointment.Version)
+	c.JSON(http.StatusOK, dto.ToAppointmentResponse(appointment, loc))
+}
+
 // CreateAppointment is a handler to create a new appointment
 // Attach this function to Server struct for ease of setting up routes
 func (s *Server) CreateAppointment(c *gin.Context) {
@@ -43,7 +139,13 @@ func (s *Server) CreateAppointment(c *gin.Context) {
 	// -----------------------------------------------
 	var req dto.CreateAppointmentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleError(c, errors.ValidationError(err.Error()))
+		handleError(c, s.appointmentBindingError(c, err, dto.ToAppointmentModel(&req)))
+		return
+	}
+
+	loc, err := requestLocation(c)
+	if err != nil {
+		handleError(c, err)
 		return
 	}
 
@@ -51,6 +153,15 @@ func (s *Server) CreateAppointment(c *gin.Context) {
 	//
 	appointment := dto.ToAppointmentModel(&req)
 
+	// Cap the user's valid booking attempts
+	// --------------------------------------
+	// The user is whoever the body names, so only bookings that pass
+	// validation count, or junk requests could spend a user's attempts.
+	// Invalid ones go on to be rejected, and counted, by Create.
+	if s.appointmentService.Validate(c.Request.Context(), appointment) == nil && !s.allowBooking(c, req.UserId) {
+		return
+	}
+
 	// Create the appointment
 	// -----------------------
 	createdAppointment, err := s.appointmentService.Create(c.Request.Context(), appointment)
@@ -61,10 +172,131 @@ func (s *Server) CreateAppointment(c *gin.Context) {
 
 	// Convert the created appointment to a response DTO
 	// -------------------------------------------------
-	response := dto.ToAppointmentResponse(createdAppointment)
+	response := dto.ToAppointmentResponse(createdAppointment, loc)
+	setETag(c, createdAppointment.Version)
 	c.JSON(http.StatusCreated, response)
 }
 
+// RescheduleAppointment is a handler to move an appointment to a new time
+// Requires an If-Match header carrying the appointment's current ETag
+func (s *Server) RescheduleAppointment(c *gin.Context) {
+
+	// Bind the appointment ID, version and new times
+	// -----------------------------------------------
+	var uri dto.AppointmentIdRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		handleError(c, bindingError(err))
+		return
+	}
+
+	version, err := versionFromIfMatch(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	loc, err := requestLocation(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	var req dto.RescheduleAppointmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleError(c, bindingError(err))
+		return
+	}
+
+	// Reschedule the appointment
+	// --------------------------
+	updated, err := s.appointmentService.Reschedule(c.Request.Context(), uri.Id, req.StartTime, req.EndTime, version)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	setETag(c, updated.Version)
+	c.JSON(http.StatusOK, dto.ToAppointmentResponse(updated, loc))
+}
+
+// CancelAppointment is a handler to cancel an appointment
+// Requires an If-Match header carrying the appointment's current ETag
+func (s *Server) CancelAppointment(c *gin.Context) {
+
+	// Bind the appointment ID and version
+	// -----------------------------------
+	var uri dto.AppointmentIdRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		handleError(c, bindingError(err))
+		return
+	}
+
+	version, err := versionFromIfMatch(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	loc, err := requestLocation(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	// Cancel the appointment
+	// ----------------------
+	cancelled, err := s.appointmentService.Cancel(c.Request.Context(), uri.Id, version)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	setETag(c, cancelled.Version)
+	c.JSON(http.StatusOK, dto.ToAppointmentResponse(cancelled, loc))
+}
+
+// UpdateAppointmentStatus is a handler to move an appointment to a new status
+// Requires an If-Match header carrying the appointment's current ETag
+func (s *Server) UpdateAppointmentStatus(c *gin.Context) {
+
+	// Bind the appointment ID, version and new status
+	// -----------------------------------------------
+	var uri dto.AppointmentIdRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		handleError(c, bindingError(err))
+		return
+	}
+
+	version, err := versionFromIfMatch(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	loc, err := requestLocation(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	var req dto.UpdateStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleError(c, bindingError(err))
+		return
+	}
+
+	// Update the status
+	// -----------------
+	updated, err := s.appointmentService.UpdateStatus(c.Request.Context(), uri.Id, model.AppointmentStatus(req.Status), version)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	setETag(c, updated.Version)
+	c.JSON(http.StatusOK, dto.ToAppointmentResponse(updated, loc))
+}
+
 // GetAvailability is a handler to get available slots for a given trainer
 func (s *Server) GetAvailability(c *gin.Context) {
 
@@ -74,17 +306,17 @@ func (s *Server) GetAvailability(c *gin.Context) {
 	// so we have to validate explicitly.
 	var req dto.GetAvailabilityRequest
 	if err := c.ShouldBindUri(&req); err != nil {
-		s.logger.Error("URI binding failed", "error", err)
-		handleError(c, errors.ValidationError(err.Error()))
+		s.log(c).ErrorContext(c.Request.Context(), "URI binding failed", "error", err)
+		handleError(c, bindingError(err))
 		return
 	}
 
 	if err := c.ShouldBindQuery(&req); err != nil {
-		s.logger.Error("Query binding failed",
+		s.log(c).ErrorContext(c.Request.Context(), "Query binding failed",
 			"error", err,
 			"raw_starts_at", c.Query("starts_at"),
 			"raw_ends_at", c.Query("ends_at"))
-		handleError(c, errors.ValidationError(err.Error()))
+		handleError(c, bindingError(err))
 		return
 	}
 
@@ -93,7 +325,7 @@ func (s *Server) GetAvailability(c *gin.Context) {
 	req.StartsAt = req.StartsAt.UTC()
 	req.EndsAt = req.EndsAt.UTC()
 
-	s.logger.Info("Request times in UTC",
+	s.log(c).InfoContext(c.Request.Context(), "Request times in UTC",
 		"starts_at", req.StartsAt.Format(time.RFC3339),
 		"ends_at", req.EndsAt.Format(time.RFC3339))
 
@@ -104,6 +336,12 @@ func (s *Server) GetAvailability(c *gin.Context) {
 		return
 	}
 
+	loc, err := requestLocation(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
 	// Get available slots
 	// -------------------
 	available, err := s.appointmentService.GetAvailability(
@@ -117,48 +355,283 @@ func (s *Server) GetAvailability(c *gin.Context) {
 		return
 	}
 
-	// Convert to response DTOs, ensuring UTC
-	// --------------------------------------
-	response := make([]dto.AvailabilityResponse, len(available))
-	for i, slot := range available {
-		response[i] = dto.AvailabilityResponse{
-			StartTime: slot.StartTime.UTC(),
-			EndTime:   slot.EndTime.UTC(),
-		}
-	}
-
-	c.JSON(http.StatusOK, response)
+	// Convert to response DTOs in UTC, with local times if requested
+	// --------------------------------------------------------------
+	c.JSON(http.StatusOK, dto.ToAvailabilityResponse(available, loc))
 }
 
 func validateAvailabilityRequest(req *dto.GetAvailabilityRequest) error {
+	var violations []errors.Violation
 
 	if req.TrainerId <= 0 {
-		return errors.ValidationError("trainer_id must be greater than 0")
+		violations = append(violations, errors.Violation{
+			Field:   "trainer_id",
+			Code:    errors.CodeInvalidRequest,
+			Message: "trainer_id must be greater than 0",
+		})
 	}
 
 	if req.StartsAt.IsZero() {
-		return errors.ValidationError("starts_at is required and must be a valid timestamp")
+		violations = append(violations, errors.Violation{
+			Field:   "starts_at",
+			Code:    errors.CodeInvalidRequest,
+			Message: "starts_at is required and must be a valid timestamp",
+		})
 	}
 
 	if req.EndsAt.IsZero() {
-		return errors.ValidationError("ends_at is required and must be a valid timestamp")
+		violations = append(violations, errors.Violation{
+			Field:   "ends_at",
+			Code:    errors.CodeInvalidRequest,
+			Message: "ends_at is required and must be a valid timestamp",
+		})
+	} else if req.EndsAt.Before(req.StartsAt) {
+		violations = append(violations, errors.Violation{
+			Field:   "ends_at",
+			Code:    errors.CodeInvalidTimeRange,
+			Message: "ends_at must be after starts_at",
+		})
+	}
+
+	return violationsError(violations)
+}
+
+// GetCalendar is a handler to get a trainer's availability summarised per day
+// Days are local dates in the tz / Accept-Timezone zone, or the trainer's own
+func (s *Server) GetCalendar(c *gin.Context) {
+
+	// Bind the trainer from the URL and the date range from the query
+	// ----------------------------------------------------------------
+	var req dto.GetCalendarRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		handleError(c, bindingError(err))
+		return
+	}
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleError(c, bindingError(err))
+		return
+	}
+
+	// Validate the request
+	// --------------------
+	if err := validateCalendarRequest(&req); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	loc, err := requestLocation(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	// Summarise availability per day
+	// ------------------------------
+	calendar, err := s.appointmentService.GetCalendar(c.Request.Context(), req.TrainerId, req.From, req.To, loc)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.ToCalendarResponse(calendar))
+}
+
+func validateCalendarRequest(req *dto.GetCalendarRequest) error {
+	var violations []errors.Violation
+
+	if req.TrainerId <= 0 {
+		violations = append(violations, errors.Violation{
+			Field:   "trainer_id",
+			Code:    errors.CodeInvalidRequest,
+			Message: "trainer_id must be greater than 0",
+		})
+	}
+
+	if req.From.IsZero() {
+		violations = append(violations, errors.Violation{
+			Field:   "from",
+			Code:    errors.CodeInvalidRequest,
+			Message: "from is required and must be a date, e.g. 2025-06-01",
+		})
+	}
+
+	if req.To.IsZero() {
+		violations = append(violations, errors.Violation{
+			Field:   "to",
+			Code:    errors.CodeInvalidRequest,
+			Message: "to is required and must be a date, e.g. 2025-06-30",
+		})
+	}
+
+	return violationsError(violations)
+}
+
+// GetUtilization is a handler to report how booked trainers are, per day,
+// week or month in each trainer's local zone
+func (s *Server) GetUtilization(c *gin.Context) {
+
+	// Bind the trainer, period and date range from the query
+	// --------------------------------------------------------
+	var req dto.UtilizationRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleError(c, bindingError(err))
+		return
+	}
+
+	// Validate the request
+	// --------------------
+	if err := validateUtilizationRequest(&req); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	// Compute the report
+	// ------------------
+	report, err := s.appointmentService.Utilization(c.Request.Context(), dto.ToUtilizationQuery(&req))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.ToUtilizationResponse(report))
+}
+
+func validateUtilizationRequest(req *dto.UtilizationRequest) error {
+	var violations []errors.Violation
+
+	if req.From.IsZero() {
+		violations = append(violations, errors.Violation{
+			Field:   "from",
+			Code:    errors.CodeInvalidRequest,
+			Message: "from is required and must be a date, e.g. 2025-06-01",
+		})
+	}
+
+	if req.To.IsZero() {
+		violations = append(violations, errors.Violation{
+			Field:   "to",
+			Code:    errors.CodeInvalidRequest,
+			Message: "to is required and must be a date, e.g. 2025-06-30",
+		})
+	}
+
+	return violationsError(violations)
+}
+
+// appointmentBindingError reports binding failures together with the
+// appointment's business rule violations, so every problem with a create
+// request comes back in one response. The rules only run when the body was
+// decoded and both times are present.
+func (s *Server) appointmentBindingError(c *gin.Context, err error, apt model.Appointment) error {
+	violations := bindingViolations(err)
+	if isFieldValidationError(err) && !apt.StartTime.IsZero() && !apt.EndTime.IsZero() {
+		if ruleErr := s.appointmentService.Validate(c.Request.Context(), apt); ruleErr != nil {
+			if _, ok := errors.IsAppError(ruleErr); !ok {
+				return ruleErr
+			}
+			violations = append(violations, errors.ViolationsOf(ruleErr)...)
+		}
+	}
+	return errors.ValidationErrors(violations)
+}
+
+// ImportAppointments is a handler to bulk import appointments from a CSV or
+// NDJSON body. Responds 200 with a per-row report, also when rows are rejected.
+func (s *Server) ImportAppointments(c *gin.Context) {
+
+	// Bind the import options from the query
+	// ---------------------------------------
+	var req dto.ImportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleError(c, bindingError(err))
+		return
 	}
 
-	if req.EndsAt.Before(req.StartsAt) {
-		return errors.ValidationError("ends_at must be after starts_at")
+	// Parse the body in the requested format
+	// ---------------------------------------
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, model.MaxImportBytes)
+	rows, err := dto.ParseImport(body, importFormat(c, req.Format))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	// Import the rows
+	// ---------------
+	report, err := s.appointmentService.Import(c.Request.Context(), rows, req.ToImportOptions())
+	if err != nil {
+		handleError(c, err)
+		return
 	}
 
-	return nil
+	c.JSON(http.StatusOK, dto.ToImportReportResponse(report))
 }
 
-// handleError is a helper function to handle different types of errors
-func handleError(c *gin.Context, err error) {
-	// If this is an application error, return the error message and status code from within
-	if appErr, ok := errors.IsAppError(err); ok {
-		c.JSON(appErr.Code, gin.H{"error": appErr.Message})
+// importFormat picks the import format from the format query parameter, or
+// else the Content-Type
+func importFormat(c *gin.Context, format string) dto.ImportFormat {
+	if format != "" {
+		return dto.ImportFormat(strings.ToLower(format))
+	}
+	switch c.ContentType() {
+	case "text/csv":
+		return dto.ImportCSV
+	case "application/x-ndjson", "application/jsonl":
+		return dto.ImportNDJSON
+	}
+	return ""
+}
+
+// ExportAppointments is a handler to stream matching appointments as CSV,
+// NDJSON or Parquet. Rows are written as they are read from storage, so the
+// response starts at once and memory use stays flat however many rows match.
+func (s *Server) ExportAppointments(c *gin.Context) {
+
+	// Bind the format and filters from the query
+	// -------------------------------------------
+	var req dto.ExportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleError(c, bindingError(err))
+		return
+	}
+
+	format, err := dto.ParseExportFormat(req.Format)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	query, err := dto.ToExportQuery(&req)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	// Stream the appointments into the response
+	// ------------------------------------------
+	header := c.Writer.Header()
+	header.Set("Content-Type", format.ContentType())
+	header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="appointments.%s"`, format))
+
+	w := dto.NewExportWriter(c.Writer, format)
+	err = s.appointmentService.Export(c.Request.Context(), query, w.Write)
+	if err == nil {
+		err = w.Close()
+	}
+	if err == nil {
 		return
 	}
 
-	// For any other error, return 500
-	c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	// Until the first byte is sent the failure can still be a problem
+	// response. After that the connection is cut, so clients see a failed
+	// download rather than a complete looking, truncated file.
+	if !c.Writer.Written() {
+		header.Del("Content-Type")
+		header.Del("Content-Disposition")
+		handleError(c, err)
+		return
+	}
+	s.log(c).ErrorContext(c.Request.Context(), "Export failed mid-stream", "error", err)
+	panic(http.ErrAbortHandler)
 }
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"appointment-service/internal/dto"
+	"appointment-service/internal/errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestValidateRequests tests validation of the availability, calendar and
+// utilization query parameters.
+//
+// It includes the following test cases:
+//
+// * A valid request passes
+// * Every missing parameter is reported at once
+// * A single problem keeps its own code and field
+func TestValidateRequests(t *testing.T) {
+	start := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	fields := func(err error) []string {
+		var fields []string
+		for _, v := range errors.ViolationsOf(err) {
+			fields = append(fields, v.Field)
+		}
+		return fields
+	}
+
+	t.Run("availability", func(t *testing.T) {
+		assert.NoError(t, validateAvailabilityRequest(&dto.GetAvailabilityRequest{TrainerId: 1, StartsAt: start, EndsAt: start.Add(time.Hour)}))
+
+		err := validateAvailabilityRequest(&dto.GetAvailabilityRequest{})
+		appErr, ok := errors.IsAppError(err)
+		assert.True(t, ok)
+		assert.Equal(t, errors.CodeValidationFailed, appErr.ErrorCode)
+		assert.Equal(t, []string{"trainer_id", "starts_at", "ends_at"}, fields(err))
+
+		err = validateAvailabilityRequest(&dto.GetAvailabilityRequest{TrainerId: 1, StartsAt: start, EndsAt: start.Add(-time.Hour)})
+		appErr, ok = errors.IsAppError(err)
+		assert.True(t, ok)
+		assert.Equal(t, errors.CodeInvalidTimeRange, appErr.ErrorCode)
+		assert.Equal(t, "ends_at", appErr.Details["field"])
+	})
+
+	t.Run("calendar", func(t *testing.T) {
+		assert.NoError(t, validateCalendarRequest(&dto.GetCalendarRequest{TrainerId: 1, From: start, To: start}))
+
+		err := validateCalendarRequest(&dto.GetCalendarRequest{TrainerId: 1})
+		appErr, ok := errors.IsAppError(err)
+		assert.True(t, ok)
+		assert.Equal(t, errors.CodeValidationFailed, appErr.ErrorCode)
+		assert.Equal(t, []string{"from", "to"}, fields(err))
+	})
+
+	t.Run("utilization", func(t *testing.T) {
+		assert.NoError(t, validateUtilizationRequest(&dto.UtilizationRequest{From: start, To: start}))
+
+		err := validateUtilizationRequest(&dto.UtilizationRequest{})
+		appErr, ok := errors.IsAppError(err)
+		assert.True(t, ok)
+		assert.Equal(t, errors.CodeValidationFailed, appErr.ErrorCode)
+		assert.Equal(t, []string{"from", "to"}, fields(err))
+	})
+}
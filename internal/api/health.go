@@ -0,0 +1,46 @@
+package api
+
+import (
+	"appointment-service/internal/dto"
+	"appointment-service/internal/version"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Healthz is the liveness probe: the process is up and serving HTTP.
+// Dependencies are left to Readyz, so a database outage doesn't get every
+// instance restarted.
+func (s *Server) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.HealthResponse{Status: dto.HealthOK})
+}
+
+// Readyz is the readiness probe: every health check passes and the server
+// is not shutting down. Failing checks are logged with their error and
+// reported as 503.
+func (s *Server) Readyz(c *gin.Context) {
+
+	// Run the checks
+	// --------------
+	report := s.health.Ready(c.Request.Context())
+	for _, result := range report.Results {
+		if result.Err != nil {
+			s.log(c).WarnContext(c.Request.Context(), "Readiness check failed",
+				"check", result.Name,
+				"error", result.Err)
+		}
+	}
+
+	// Report the outcome
+	// ------------------
+	status := http.StatusOK
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, dto.ToReadinessResponse(report))
+}
+
+// Version returns the build information of the running binary
+func (s *Server) Version(c *gin.Context) {
+	c.JSON(http.StatusOK, version.GetInfo())
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"appointment-service/internal/config"
+	"appointment-service/internal/health"
+	"appointment-service/internal/metrics"
+	"appointment-service/internal/model"
+	"appointment-service/internal/repository/memory"
+	"appointment-service/internal/service"
+	"context"
+	stderrors "errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProbes tests the liveness, readiness and version endpoints.
+//
+// It includes the following test cases:
+//
+// * Liveness and version always answer
+// * Readiness passes while every check passes
+// * Readiness fails, without leaking the error, when a check fails
+// * Readiness fails as soon as shutdown starts, while requests are still served
+func TestProbes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	svc := service.NewAppointmentService(memory.New(logger), &service.StaticWorkingHours{Default: model.WorkingHours{Location: time.UTC, Start: 8 * time.Hour, End: 18 * time.Hour}}, service.DefaultRules(), logger)
+
+	var down error
+	checker := health.NewChecker(0)
+	checker.Add("repository", func(context.Context) error { return down })
+
+	cfg := &config.Config{ShutdownDelay: 50 * time.Millisecond}
+	server, err := NewServer(cfg, svc, metrics.New(), checker, new(slog.LevelVar), logger)
+	require.NoError(t, err)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec
+	}
+
+	t.Run("liveness and version", func(t *testing.T) {
+		assert.JSONEq(t, `{"status":"ok"}`, get("/healthz").Body.String())
+		assert.JSONEq(t, `{"version":"unknown","commit":"unknown","buildTime":"unknown"}`, get("/version").Body.String())
+	})
+
+	t.Run("ready", func(t *testing.T) {
+		rec := get("/readyz")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"repository":{"status":"ok"`)
+	})
+
+	t.Run("check failing", func(t *testing.T) {
+		down = stderrors.New("dial tcp 10.0.0.7:5432: connection refused")
+		defer func() { down = nil }()
+
+		rec := get("/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"repository":{"status":"failing"`)
+		assert.NotContains(t, rec.Body.String(), "10.0.0.7")
+	})
+
+	t.Run("shutting down", func(t *testing.T) {
+		done := make(chan error)
+		go func() { done <- server.Shutdown(context.Background()) }()
+
+		require.Eventually(t, checker.Draining, time.Second, time.Millisecond)
+		rec := get("/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.JSONEq(t, `{"status":"draining"}`, rec.Body.String())
+		assert.Equal(t, http.StatusOK, get("/healthz").Code)
+
+		require.NoError(t, <-done)
+	})
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"appointment-service/internal/dto"
+	"appointment-service/internal/errors"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const problemContentType = "application/problem+json"
+
+// handleError renders any error as an RFC 7807 problem response.
+// Application errors carry their own status, code and details; anything else
+// is reported as a generic 500 without leaking internals.
+func handleError(c *gin.Context, err error) {
+	appErr, ok := errors.IsAppError(err)
+	if !ok {
+		appErr = errors.InternalError("internal server error", err)
+	}
+
+	problem := dto.ProblemResponse{
+		Type:     appErr.ErrorCode.TypeURI(),
+		Title:    http.StatusText(appErr.Code),
+		Status:   appErr.Code,
+		Detail:   appErr.Message,
+		Instance: c.Request.URL.Path,
+		Code:     string(appErr.ErrorCode),
+		Details:  appErr.Details,
+	}
+	for _, v := range appErr.Violations {
+		problem.Errors = append(problem.Errors, dto.ProblemViolation{
+			Field:   v.Field,
+			Code:    string(v.Code),
+			Message: v.Message,
+		})
+	}
+
+	body, marshalErr := json.Marshal(problem)
+	if marshalErr != nil {
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.Abort()
+	c.Data(appErr.Code, problemContentType, body)
+}
+
+// handleNoRoute renders unknown routes as a problem response
+func handleNoRoute(c *gin.Context) {
+	handleError(c, errors.NotFoundError("no route for "+c.Request.Method+" "+c.Request.URL.Path).
+		WithCode(errors.CodeRouteNotFound))
+}
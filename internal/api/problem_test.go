@@ -0,0 +1,78 @@
+package api
+
+import (
+	"appointment-service/internal/dto"
+	"appointment-service/internal/errors"
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHandleError tests that errors are rendered as RFC 7807 problem responses.
+//
+// It includes the following test cases:
+//
+// * Application error with a specific code and details
+// * Application error wrapped by fmt.Errorf style wrapping
+// * Unknown error, which must not leak its message
+func TestHandleError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantDetail string
+	}{
+		{
+			name: "application error with code and details",
+			err: errors.ConflictError("trainer 1 is not available").
+				WithCode(errors.CodeTrainerUnavailable).
+				WithDetail("conflicting_appointment_ids", []int64{7}),
+			wantStatus: http.StatusConflict,
+			wantCode:   "TRAINER_UNAVAILABLE",
+			wantDetail: "trainer 1 is not available",
+		},
+		{
+			name:       "wrapped application error",
+			err:        stderrors.Join(errors.AppointmentNotFoundError(3)),
+			wantStatus: http.StatusNotFound,
+			wantCode:   "APPOINTMENT_NOT_FOUND",
+			wantDetail: "appointment 3 not found",
+		},
+		{
+			name:       "unknown error",
+			err:        stderrors.New("connection refused to 10.0.0.1"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   "INTERNAL_ERROR",
+			wantDetail: "internal server error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/appointments/3", nil)
+
+			handleError(c, tt.err)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+
+			var problem dto.ProblemResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+			assert.Equal(t, tt.wantStatus, problem.Status)
+			assert.Equal(t, tt.wantCode, problem.Code)
+			assert.Equal(t, tt.wantDetail, problem.Detail)
+			assert.Equal(t, "urn:appointment-service:problem:"+tt.wantCode, problem.Type)
+			assert.Equal(t, "/api/v1/appointments/3", problem.Instance)
+		})
+	}
+}
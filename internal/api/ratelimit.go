@@ -0,0 +1,128 @@
+package api
+
+import (
+	"appointment-service/internal/config"
+	"appointment-service/internal/errors"
+	"appointment-service/internal/ratelimit"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyHeader carries a client's API key, which tells clients apart when
+// rate limits are keyed by API key
+const apiKeyHeader = "X-API-Key"
+
+// rateLimits are the limiters of every route, and of booking attempts per
+// user
+type rateLimits struct {
+	// byAPIKey keys clients by apiKeyHeader rather than IP address, when
+	// their key is one of apiKeys
+	byAPIKey bool
+	apiKeys  atomic.Pointer[map[string]bool]
+
+	// routes has a limiter for every route served, by method and path
+	// template, e.g. "POST /api/v1/appointments", without a limit unless
+	// configured
+	routes map[string]*ratelimit.Limiter
+
+	// bookings caps booking attempts, keyed by the user booking
+	bookings *ratelimit.Limiter
+}
+
+func newRateLimits(key string, routes gin.RoutesInfo) *rateLimits {
+	limits := &rateLimits{
+		byAPIKey: strings.EqualFold(key, "api_key"),
+		routes:   make(map[string]*ratelimit.Limiter, len(routes)),
+		bookings: ratelimit.NewLimiter(ratelimit.Limit{}),
+	}
+	for _, route := range routes {
+		limits.routes[route.Method+" "+route.Path] = ratelimit.NewLimiter(ratelimit.Limit{})
+	}
+	return limits
+}
+
+// SetRateLimits applies the rate limits of cfg, e.g. on a config reload.
+// Clients keep the tokens they have, up to the new limits. Nothing is applied
+// when a limit is for a route the server does not serve.
+func (s *Server) SetRateLimits(cfg config.RateLimitConfig) error {
+	for route := range cfg.Routes {
+		if _, ok := s.limits.routes[route]; !ok {
+			return fmt.Errorf("rate limit for unknown route %q", route)
+		}
+	}
+	for route, limiter := range s.limits.routes {
+		limiter.SetLimit(cfg.Routes[route])
+	}
+	s.limits.bookings.SetLimit(cfg.BookingsPerUser)
+
+	apiKeys := make(map[string]bool, len(cfg.APIKeys))
+	for _, key := range cfg.APIKeys {
+		apiKeys[key] = true
+	}
+	s.limits.apiKeys.Store(&apiKeys)
+	return nil
+}
+
+// rateLimit refuses requests beyond their route's limit for the client
+func (s *Server) rateLimit(c *gin.Context) {
+	limiter, ok := s.limits.routes[c.Request.Method+" "+c.FullPath()]
+	if !ok {
+		return // <-- no route, answered with 404
+	}
+	allow(c, limiter, s.clientKey(c), errors.RateLimitedError("too many requests, slow down"))
+}
+
+// allowBooking refuses the user's booking attempts beyond their limit,
+// whichever client they come from. The user is whoever the body names, so it
+// is only called once the booking is valid.
+func (s *Server) allowBooking(c *gin.Context, userID int64) bool {
+	return allow(c, s.limits.bookings, "user:"+strconv.FormatInt(userID, 10),
+		errors.RateLimitedError(fmt.Sprintf("user %d made too many booking attempts", userID)).
+			WithDetail("user_id", userID))
+}
+
+// clientKey tells clients apart, by their API key when configured so and the
+// key is a known one, and by IP address otherwise. Unknown keys must not count
+// as clients of their own, or sending a new key with every request would
+// evade the limits.
+func (s *Server) clientKey(c *gin.Context) string {
+	if key := c.GetHeader(apiKeyHeader); s.limits.byAPIKey && (*s.limits.apiKeys.Load())[key] {
+		return "key:" + key
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// allow takes a token for key from limiter and reports the limit in the
+// RateLimit-* headers. A request refused is answered with refused, as 429 Too
+// Many Requests with a Retry-After header, and false is returned.
+func allow(c *gin.Context, limiter *ratelimit.Limiter, key string, refused *errors.AppError) bool {
+	decision := limiter.Allow(key)
+	if decision.Limit.Unlimited() {
+		return true
+	}
+
+	header := c.Writer.Header()
+	header.Set("RateLimit-Limit", strconv.Itoa(decision.Limit.Requests))
+	header.Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+	header.Set("RateLimit-Reset", strconv.Itoa(seconds(decision.Reset)))
+	header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", decision.Limit.Requests, seconds(decision.Limit.Period)))
+	if decision.Allowed {
+		return true
+	}
+
+	retryAfter := max(seconds(decision.RetryAfter), 1)
+	header.Set("Retry-After", strconv.Itoa(retryAfter))
+	handleError(c, refused.WithDetail("retry_after_seconds", retryAfter))
+	return false
+}
+
+// seconds rounds d up to whole seconds, as the headers take them
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"appointment-service/internal/config"
+	"appointment-service/internal/health"
+	"appointment-service/internal/metrics"
+	"appointment-service/internal/model"
+	"appointment-service/internal/ratelimit"
+	"appointment-service/internal/repository/memory"
+	"appointment-service/internal/service"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRateLimit tests limiting requests per route and client.
+//
+// It includes the following test cases:
+//
+// * Requests within the limit carry RateLimit headers, routes without one don't
+// * Requests beyond the limit get 429 with Retry-After
+// * Clients are told apart by IP address, forwarded only by trusted proxies
+// * Clients are told apart by known API keys when configured so, and unknown keys by IP address
+// * Booking attempts are capped per user, whichever client they come from
+// * Malformed and invalid booking attempts don't count against the user they name
+// * Limits change on reload, and limits for unknown routes are rejected
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const availability = "GET /api/v1/appointments/trainers/:trainer_id/availability"
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	newServer := func(t *testing.T, key string) *Server {
+		hours := &service.StaticWorkingHours{Default: model.WorkingHours{Location: time.UTC, Start: 8 * time.Hour, End: 18 * time.Hour}}
+		svc := service.NewAppointmentService(memory.New(log), hours, service.DefaultRules(), log)
+		cfg := &config.Config{
+			TrustedProxies: []string{"10.0.0.1"},
+			RateLimit: config.RateLimitConfig{
+				Key:             key,
+				APIKeys:         []string{"alpha", "beta"},
+				Routes:          map[string]ratelimit.Limit{availability: {Requests: 2, Period: time.Minute}},
+				BookingsPerUser: ratelimit.Limit{Requests: 1, Period: time.Minute},
+			},
+		}
+		server, err := NewServer(cfg, svc, metrics.New(), health.NewChecker(0), new(slog.LevelVar), log)
+		require.NoError(t, err)
+		return server
+	}
+
+	do := func(server *Server, req *http.Request, remoteAddr string) *httptest.ResponseRecorder {
+		req.RemoteAddr = remoteAddr + ":41000"
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, req)
+		return rec
+	}
+	getAvailability := func(server *Server, remoteAddr string, headers ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/appointments/trainers/1/availability?starts_at=2030-01-07T08:00:00Z&ends_at=2030-01-07T10:00:00Z", nil)
+		for i := 0; i < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		return do(server, req, remoteAddr)
+	}
+	book := func(server *Server, remoteAddr string, userID int64) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"trainer_id":1,"user_id":%d,"start_time":"2030-01-07T09:00:00Z","end_time":"2030-01-07T09:30:00Z"}`, userID)
+		return do(server, httptest.NewRequest("POST", "/api/v1/appointments", strings.NewReader(body)), remoteAddr)
+	}
+
+	t.Run("within the limit", func(t *testing.T) {
+		server := newServer(t, "ip")
+		rec := getAvailability(server, "192.0.2.1")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "30", rec.Header().Get("RateLimit-Reset"))
+		assert.Equal(t, "2;w=60", rec.Header().Get("RateLimit-Policy"))
+
+		rec = do(server, httptest.NewRequest("GET", "/api/v1/appointments/1", nil), "192.0.2.1")
+		assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+	})
+
+	t.Run("beyond the limit", func(t *testing.T) {
+		server := newServer(t, "ip")
+		getAvailability(server, "192.0.2.1")
+		getAvailability(server, "192.0.2.1")
+
+		rec := getAvailability(server, "192.0.2.1")
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "30", rec.Header().Get("Retry-After"))
+		assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+		assert.Contains(t, rec.Body.String(), `"code":"RATE_LIMITED"`)
+		assert.Contains(t, rec.Body.String(), `"retry_after_seconds":30`)
+	})
+
+	t.Run("by IP address", func(t *testing.T) {
+		server := newServer(t, "ip")
+		getAvailability(server, "192.0.2.1")
+		getAvailability(server, "192.0.2.1")
+
+		assert.Equal(t, http.StatusOK, getAvailability(server, "192.0.2.2").Code)
+		assert.Equal(t, http.StatusTooManyRequests, getAvailability(server, "192.0.2.1", "X-Forwarded-For", "198.51.100.7").Code,
+			"forwarded by an untrusted proxy")
+		assert.Equal(t, http.StatusOK, getAvailability(server, "10.0.0.1", "X-Forwarded-For", "198.51.100.7").Code,
+			"forwarded by a trusted proxy")
+	})
+
+	t.Run("by API key", func(t *testing.T) {
+		server := newServer(t, "api_key")
+		getAvailability(server, "192.0.2.1", "X-API-Key", "alpha")
+		getAvailability(server, "192.0.2.2", "X-API-Key", "alpha")
+
+		assert.Equal(t, http.StatusTooManyRequests, getAvailability(server, "192.0.2.3", "X-API-Key", "alpha").Code)
+		assert.Equal(t, http.StatusOK, getAvailability(server, "192.0.2.1", "X-API-Key", "beta").Code)
+		assert.Equal(t, http.StatusOK, getAvailability(server, "192.0.2.1").Code, "no key, by IP address")
+		assert.Equal(t, http.StatusOK, getAvailability(server, "192.0.2.1", "X-API-Key", "gamma").Code,
+			"unknown key, by IP address")
+		assert.Equal(t, http.StatusTooManyRequests, getAvailability(server, "192.0.2.1", "X-API-Key", "delta").Code,
+			"another unknown key, still by IP address")
+	})
+
+	t.Run("bookings per user", func(t *testing.T) {
+		server := newServer(t, "ip")
+		assert.Equal(t, http.StatusCreated, book(server, "192.0.2.1", 7).Code)
+
+		rec := book(server, "192.0.2.2", 7)
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+		assert.Contains(t, rec.Body.String(), "user 7 made too many booking attempts")
+
+		assert.Equal(t, http.StatusConflict, book(server, "192.0.2.3", 8).Code, "another user, checked for availability")
+	})
+
+	t.Run("invalid bookings don't count", func(t *testing.T) {
+		server := newServer(t, "ip")
+		malformed := httptest.NewRequest("POST", "/api/v1/appointments", strings.NewReader(`{"user_id":7,`))
+		assert.Equal(t, http.StatusBadRequest, do(server, malformed, "192.0.2.1").Code)
+		outsideHours := httptest.NewRequest("POST", "/api/v1/appointments", strings.NewReader(
+			`{"trainer_id":1,"user_id":7,"start_time":"2030-01-07T06:00:00Z","end_time":"2030-01-07T06:30:00Z"}`))
+		assert.Equal(t, http.StatusBadRequest, do(server, outsideHours, "192.0.2.1").Code)
+
+		assert.Equal(t, http.StatusCreated, book(server, "192.0.2.1", 7).Code)
+	})
+
+	t.Run("reload", func(t *testing.T) {
+		server := newServer(t, "ip")
+		getAvailability(server, "192.0.2.1")
+		getAvailability(server, "192.0.2.1")
+
+		require.NoError(t, server.SetRateLimits(config.RateLimitConfig{}))
+		assert.Equal(t, http.StatusOK, getAvailability(server, "192.0.2.1").Code)
+		assert.Equal(t, http.StatusCreated, book(server, "192.0.2.1", 7).Code)
+		assert.Equal(t, http.StatusConflict, book(server, "192.0.2.1", 7).Code)
+
+		err := server.SetRateLimits(config.RateLimitConfig{Routes: map[string]ratelimit.Limit{
+			availability:                 {Requests: 1, Period: time.Second},
+			"GET /api/v1/appointments/x": {Requests: 1, Period: time.Second},
+		}})
+		assert.ErrorContains(t, err, `unknown route "GET /api/v1/appointments/x"`)
+		assert.Empty(t, getAvailability(server, "192.0.2.1").Header().Get("RateLimit-Limit"), "nothing applied")
+	})
+}
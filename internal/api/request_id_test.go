@@ -0,0 +1,80 @@
+package api
+
+import (
+	"appointment-service/internal/config"
+	"appointment-service/internal/health"
+	"appointment-service/internal/metrics"
+	"appointment-service/internal/model"
+	"appointment-service/internal/repository/memory"
+	"appointment-service/internal/service"
+	"bytes"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRequestID tests request ID handling and request scoped logging.
+//
+// It includes the following test cases:
+//
+// * A client's X-Request-ID is echoed back
+// * A missing X-Request-ID is generated
+// * An X-Request-ID with control characters is replaced
+// * Records of the handler, the service and the request log carry the request's ID
+// * The request log has the query parameters one by one
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var out bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&out, nil))
+	hours := &service.StaticWorkingHours{Default: model.WorkingHours{Location: time.UTC, Start: 8 * time.Hour, End: 18 * time.Hour}}
+	svc := service.NewAppointmentService(memory.New(logger), hours, service.DefaultRules(), logger)
+	server, err := NewServer(&config.Config{}, svc, metrics.New(), health.NewChecker(0), new(slog.LevelVar), logger)
+	require.NoError(t, err)
+
+	get := func(requestID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/appointments/trainers/1/availability?starts_at=2030-01-07T08:00:00Z&ends_at=2030-01-07T10:00:00Z", nil)
+		if requestID != "" {
+			req.Header.Set("X-Request-ID", requestID)
+		}
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("client ID", func(t *testing.T) {
+		out.Reset()
+		rec := get("checkout-42")
+		require.Equal(t, 200, rec.Code)
+		assert.Equal(t, "checkout-42", rec.Header().Get("X-Request-ID"))
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		require.Len(t, lines, 3)
+		assert.Contains(t, lines[0], `msg="Request times in UTC"`)
+		assert.Contains(t, lines[1], `msg="Slot calculation"`)
+		assert.Contains(t, lines[2], `msg="request completed"`)
+		assert.Contains(t, lines[2], "query.ends_at=2030-01-07T10:00:00Z query.starts_at=2030-01-07T08:00:00Z")
+		for _, line := range lines {
+			assert.Contains(t, line, "request_id=checkout-42")
+		}
+	})
+
+	t.Run("generated ID", func(t *testing.T) {
+		first, second := get("").Header().Get("X-Request-ID"), get("").Header().Get("X-Request-ID")
+		assert.Len(t, first, 36)
+		assert.NotEqual(t, first, second)
+	})
+
+	t.Run("unsafe ID", func(t *testing.T) {
+		out.Reset()
+		id := get("a\x7fb").Header().Get("X-Request-ID")
+		assert.Len(t, id, 36)
+		assert.NotContains(t, out.String(), "a\x7fb")
+	})
+}
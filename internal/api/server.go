@@ -2,14 +2,22 @@ package api
 
 import (
 	"appointment-service/internal/config"
+	"appointment-service/internal/errors"
+	"appointment-service/internal/health"
+	"appointment-service/internal/logger"
+	"appointment-service/internal/metrics"
 	"appointment-service/internal/middleware"
 	"appointment-service/internal/service"
 
 	"context"
+	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 type Server struct {
@@ -17,51 +25,140 @@ type Server struct {
 	router             *gin.Engine
 	cfg                *config.Config
 	appointmentService service.AppointmentServicer
+	metrics            *metrics.Metrics
+	health             *health.Checker
+	logLevel           *slog.LevelVar
+	limits             *rateLimits
 	logger             *slog.Logger
 }
 
-// NewServer creates a new instance of the server
-func NewServer(cfg *config.Config, appointmentService service.AppointmentServicer, logger *slog.Logger) (*Server, error) {
+// NewServer creates a new instance of the server. logLevel is the level of
+// logger, changed through the admin endpoints.
+func NewServer(cfg *config.Config, appointmentService service.AppointmentServicer, m *metrics.Metrics, checker *health.Checker, logLevel *slog.LevelVar, logger *slog.Logger) (*Server, error) {
 
 	r := gin.New()
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
 
 	server := &Server{
 		httpServer:         &http.Server{},
 		router:             r,
 		cfg:                cfg,
 		appointmentService: appointmentService,
+		metrics:            m,
+		health:             checker,
+		logLevel:           logLevel,
 		logger:             logger,
 	}
 
+	registerFieldNames()
 	server.setupMiddleware()
 	server.setupRoutes()
 
+	server.limits = newRateLimits(cfg.RateLimit.Key, r.Routes())
+	if err := server.SetRateLimits(cfg.RateLimit); err != nil {
+		return nil, err
+	}
+
 	return server, nil
 }
 
 // setupMiddleware configures the server's middleware
 func (s *Server) setupMiddleware() {
+	s.router.Use(otelgin.Middleware("appointment-service", otelgin.WithGinFilter(func(c *gin.Context) bool {
+		return !probeRoutes[c.FullPath()]
+	}))) // <-- first, so the request's span is in the context of everything after
+	s.router.Use(middleware.RequestID(s.logger))
 	s.router.Use(middleware.GinLogger(s.logger))
-	s.router.Use(gin.Recovery()) // <-- panic to 500 conversion
+	s.router.Use(middleware.Metrics(s.metrics))
+	s.router.Use(gin.CustomRecovery(func(c *gin.Context, recovered any) {
+		if recovered == http.ErrAbortHandler {
+			panic(recovered) // <-- let net/http cut the connection, e.g. a failed stream
+		}
+		handleError(c, errors.InternalError("internal server error", fmt.Errorf("panic: %v", recovered)))
+	})) // <-- panic to 500 problem conversion
+	s.router.Use(s.rateLimit) // <-- after logging and metrics, so refused requests show up in both
+}
+
+// probeRoutes are polled by orchestrators and scrapers, and not traced
+var probeRoutes = map[string]bool{
+	"/metrics": true,
+	"/healthz": true,
+	"/readyz":  true,
 }
 
 // setupRoutes configures the server's routes
 func (s *Server) setupRoutes() {
 
+	s.router.NoRoute(handleNoRoute)
+	s.router.GET("/metrics", gin.WrapH(s.metrics.Handler()))
+	s.router.GET("/healthz", s.Healthz)
+	s.router.GET("/readyz", s.Readyz)
+	s.router.GET("/version", s.Version)
+
 	v1 := s.router.Group("/api/v1")
 	{
 		v1.GET("/appointments/trainers/:trainer_id", s.ListAppointments)
+		v1.GET("/appointments/users/:user_id", s.ListUserAppointments)
+		v1.GET("/appointments/:id", s.GetAppointment)
 		v1.POST("/appointments", s.CreateAppointment)
+		v1.POST("/appointments/import", s.ImportAppointments)
+		v1.GET("/appointments/export", s.ExportAppointments)
+		v1.PUT("/appointments/:id/reschedule", s.RescheduleAppointment)
+		v1.PUT("/appointments/:id/cancel", s.CancelAppointment)
+		v1.PUT("/appointments/:id/status", s.UpdateAppointmentStatus)
 		v1.GET("/appointments/trainers/:trainer_id/availability", s.GetAvailability)
+		v1.GET("/appointments/trainers/:trainer_id/calendar", s.GetCalendar)
+		v1.GET("/reports/utilization", s.GetUtilization)
+	}
+
+	// Admin endpoints are only served with a token to guard them
+	if s.cfg.AdminToken != "" {
+		admin := s.router.Group("/admin", s.requireAdmin)
+		{
+			admin.GET("/log-level", s.GetLogLevel)
+			admin.PUT("/log-level", s.SetLogLevel)
+		}
 	}
 }
 
-func (s *Server) Run(addr string) error {
-	s.httpServer.Addr = addr
+// log returns the logger of the request c handles
+func (s *Server) log(c *gin.Context) *slog.Logger {
+	return logger.FromContext(c.Request.Context(), s.logger)
+}
+
+// Start listens on addr and serves in the background. Failing to listen,
+// e.g. on a port already in use, is returned; failing to serve later is
+// reported to fail.
+func (s *Server) Start(addr string, fail func(error)) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
 	s.httpServer.Handler = s.router
-	return s.httpServer.ListenAndServe()
+
+	go func() {
+		if err := s.httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			fail(err)
+		}
+	}()
+	return nil
 }
 
+// Shutdown fails readiness first, then keeps serving for cfg.ShutdownDelay
+// so load balancers notice and stop routing here, and finally stops
+// accepting connections and waits for in-flight requests to complete.
 func (s *Server) Shutdown(ctx context.Context) error {
+	s.health.Drain()
+
+	if s.cfg.ShutdownDelay > 0 {
+		s.logger.Info("Draining before shutdown", "delay", s.cfg.ShutdownDelay)
+		select {
+		case <-time.After(s.cfg.ShutdownDelay):
+		case <-ctx.Done():
+		}
+	}
+
 	return s.httpServer.Shutdown(ctx)
 }
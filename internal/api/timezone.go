@@ -0,0 +1,54 @@
+package api
+
+import (
+	"appointment-service/internal/errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// acceptTimezoneHeader names the request header clients can use instead of
+// the tz query parameter to ask for local times
+const acceptTimezoneHeader = "Accept-Timezone"
+
+// requestLocation returns the IANA time zone the caller wants times rendered
+// in, taken from the tz query parameter or else the Accept-Timezone header.
+// It returns nil when neither is set, in which case responses carry UTC only.
+// Stored times are unaffected, the zone only changes how they are presented.
+func requestLocation(c *gin.Context) (*time.Location, error) {
+	// Responses differ per header value, so caches must key on it
+	c.Header("Vary", acceptTimezoneHeader)
+
+	name, source := strings.TrimSpace(c.Query("tz")), "tz"
+	if name == "" {
+		name, source = strings.TrimSpace(c.GetHeader(acceptTimezoneHeader)), acceptTimezoneHeader
+	}
+	if name == "" {
+		return nil, nil
+	}
+
+	// LoadLocation treats "" and "Local" specially, neither is a client's zone
+	if name == "Local" {
+		return nil, unknownTimezoneError(name, source)
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, unknownTimezoneError(name, source)
+	}
+
+	return loc, nil
+}
+
+// unknownTimezoneError reports a time zone that is not an IANA zone name,
+// naming the query parameter or header it came from
+func unknownTimezoneError(name, source string) error {
+	err := errors.ValidationError(fmt.Sprintf("unknown time zone %q, expected an IANA name such as America/New_York", name)).
+		WithCode(errors.CodeInvalidTimeZone)
+	if source == acceptTimezoneHeader {
+		return err.WithDetail("header", source)
+	}
+	return err.WithDetail("field", source)
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"appointment-service/internal/dto"
+	"appointment-service/internal/errors"
+	"appointment-service/internal/model"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	_ "time/tzdata" // zone lookups must not depend on the host's zoneinfo
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRequestLocation tests how the caller's time zone is picked up.
+//
+// It includes the following test cases:
+//
+// * Neither tz nor Accept-Timezone, which means UTC only
+// * tz query parameter
+// * Accept-Timezone header
+// * tz query parameter wins over the header
+// * Unknown zone in the query parameter
+// * Unknown zone in the header
+// * "Local", which would otherwise resolve to the server's zone
+func TestRequestLocation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		query      string
+		header     string
+		wantZone   string
+		wantErr    bool
+		wantDetail map[string]any
+	}{
+		{
+			name: "no zone requested",
+		},
+		{
+			name:     "query parameter",
+			query:    "?tz=Asia/Kolkata",
+			wantZone: "Asia/Kolkata",
+		},
+		{
+			name:     "header",
+			header:   "America/New_York",
+			wantZone: "America/New_York",
+		},
+		{
+			name:     "query parameter wins",
+			query:    "?tz=Europe/Berlin",
+			header:   "America/New_York",
+			wantZone: "Europe/Berlin",
+		},
+		{
+			name:       "unknown zone in query parameter",
+			query:      "?tz=Mars/Olympus",
+			wantErr:    true,
+			wantDetail: map[string]any{"field": "tz"},
+		},
+		{
+			name:       "unknown zone in header",
+			header:     "PST8PDT-ish",
+			wantErr:    true,
+			wantDetail: map[string]any{"header": "Accept-Timezone"},
+		},
+		{
+			name:       "server local zone",
+			query:      "?tz=Local",
+			wantErr:    true,
+			wantDetail: map[string]any{"field": "tz"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/"+tt.query, nil)
+			if tt.header != "" {
+				c.Request.Header.Set("Accept-Timezone", tt.header)
+			}
+
+			loc, err := requestLocation(c)
+
+			assert.Equal(t, "Accept-Timezone", w.Header().Get("Vary"))
+			if tt.wantErr {
+				appErr, ok := errors.IsAppError(err)
+				require.True(t, ok)
+				assert.Equal(t, errors.CodeInvalidTimeZone, appErr.ErrorCode)
+				assert.Equal(t, tt.wantDetail, appErr.Details)
+				return
+			}
+
+			require.NoError(t, err)
+			if tt.wantZone == "" {
+				assert.Nil(t, loc)
+				return
+			}
+			assert.Equal(t, tt.wantZone, loc.String())
+		})
+	}
+}
+
+// TestLocalTimesRendering tests that responses keep their UTC fields and only
+// gain local fields when a zone was requested, including across midnight.
+func TestLocalTimesRendering(t *testing.T) {
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	require.NoError(t, err)
+
+	// 19:00 UTC is 00:30 the next day in Kolkata
+	slot := model.TimeSlot{
+		StartTime: time.Date(2025, 6, 1, 19, 0, 0, 0, time.UTC),
+		EndTime:   time.Date(2025, 6, 1, 19, 30, 0, 0, time.UTC),
+	}
+
+	t.Run("without zone", func(t *testing.T) {
+		body, err := json.Marshal(dto.ToAvailabilityResponse([]model.TimeSlot{slot}, nil))
+		require.NoError(t, err)
+		assert.JSONEq(t, `[{"start_time":"2025-06-01T19:00:00Z","end_time":"2025-06-01T19:30:00Z"}]`, string(body))
+	})
+
+	t.Run("with zone", func(t *testing.T) {
+		body, err := json.Marshal(dto.ToAvailabilityResponse([]model.TimeSlot{slot}, kolkata))
+		require.NoError(t, err)
+		assert.JSONEq(t, `[{
+			"start_time": "2025-06-01T19:00:00Z",
+			"end_time": "2025-06-01T19:30:00Z",
+			"local_start_time": "2025-06-02T00:30:00+05:30",
+			"local_end_time": "2025-06-02T01:00:00+05:30",
+			"local_date": "2025-06-02",
+			"time_zone": "Asia/Kolkata"
+		}]`, string(body))
+	})
+
+	t.Run("appointment with zone", func(t *testing.T) {
+		apt := model.Appointment{
+			Id: 1, TrainerId: 2, UserId: 3,
+			StartTime: slot.StartTime, EndTime: slot.EndTime,
+			Status: model.StatusScheduled, Version: 1,
+		}
+		body, err := json.Marshal(dto.ToAppointmentResponse(&apt, kolkata))
+		require.NoError(t, err)
+
+		var got map[string]any
+		require.NoError(t, json.Unmarshal(body, &got))
+		assert.Equal(t, "2025-06-01T19:00:00Z", got["start_time"])
+		assert.Equal(t, "2025-06-02T00:30:00+05:30", got["local_start_time"])
+		assert.Equal(t, "2025-06-02", got["local_date"])
+		assert.Equal(t, "Asia/Kolkata", got["time_zone"])
+	})
+}
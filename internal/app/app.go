@@ -3,53 +3,162 @@ package app
 import (
 	"appointment-service/internal/api"
 	"appointment-service/internal/config"
+	"appointment-service/internal/health"
+	"appointment-service/internal/metrics"
+	"appointment-service/internal/migrate"
 	"appointment-service/internal/repository"
 	repofactory "appointment-service/internal/repository/factory"
 	"appointment-service/internal/service"
 	servicefactory "appointment-service/internal/service/factory"
+	"appointment-service/internal/tracing"
+	"context"
+	"database/sql"
+	"fmt"
 	"log/slog"
+	"os"
+
+	"go.opentelemetry.io/otel"
 )
 
 // Application contains all dependencies
 type Application struct {
 	Config             *config.Config
 	Logger             *slog.Logger
+	LogLevel           *slog.LevelVar
+	Metrics            *metrics.Metrics
+	Health             *health.Checker
 	Repository         repository.AppointmentRepository
 	AppointmentService service.AppointmentServicer
 	Server             *api.Server
+
+	workers *workerGroup
+	reloads reloads
 }
 
-// New creates a new application instance with all dependencies wired up
-func New(cfg *config.Config, logger *slog.Logger) (*Application, error) {
+// New creates a new application instance with all dependencies wired up.
+// logLevel is the level of logger, changed by reloads and the admin
+// endpoints.
+func New(cfg *config.Config, logger *slog.Logger, logLevel *slog.LevelVar) (*Application, error) {
 
-	// Create repository
-	// ------------------
+	m := metrics.New()
+
+	// Create repository, recording its latency and tracing its calls
+	// ---------------------------------------------------------------
 	repo, err := repofactory.NewRepository(cfg, logger)
 	if err != nil {
 		return nil, err
 	}
 
+	checker, err := newChecker(cfg, repo, logger)
+	if err != nil {
+		repo.Close()
+		return nil, err
+	}
+
+	instrumented, err := metrics.InstrumentRepository(repo, string(cfg.StorageType), m)
+	if err != nil {
+		repo.Close()
+		return nil, err
+	}
+	repo = tracing.InstrumentRepository(instrumented, string(cfg.StorageType), otel.GetTracerProvider())
+
 	// Create service, injecting the repository
 	// ----------------------------------------
-	appointmentService := servicefactory.NewAppointmentService(repo, logger)
+	rawService, err := servicefactory.NewAppointmentService(cfg, repo, logger)
+	if err != nil {
+		repo.Close()
+		return nil, err
+	}
+	appointmentService := metrics.InstrumentService(rawService, m)
+	appointmentService = tracing.InstrumentService(appointmentService, otel.GetTracerProvider())
 
 	// Create server
 	// -------------
-	server, err := api.NewServer(cfg, appointmentService, logger)
+	server, err := api.NewServer(cfg, appointmentService, m, checker, logLevel, logger)
 	if err != nil {
+		repo.Close()
 		return nil, err
 	}
 
+	workers := &workerGroup{}
+	checker.Add("workers", workers.check)
+
 	return &Application{
 		Config:             cfg,
 		Logger:             logger,
+		LogLevel:           logLevel,
+		Metrics:            m,
+		Health:             checker,
 		Repository:         repo,
 		AppointmentService: appointmentService,
 		Server:             server,
+		workers:            workers,
+		reloads:            reloads{applied: cfg, service: rawService},
 	}, nil
 }
 
-// Close cleans up application resources
+// newChecker registers the readiness checks: the repository answers and, for
+// SQL backends, the schema is migrated. They use repo before it is
+// instrumented, so frequent probes neither skew the metrics nor flood tracing.
+func newChecker(cfg *config.Config, repo repository.AppointmentRepository, logger *slog.Logger) (*health.Checker, error) {
+	checker := health.NewChecker(health.DefaultTimeout)
+	checker.Add("repository", repo.Ping)
+
+	if db, ok := repo.(interface{ DB() *sql.DB }); ok {
+		dialect, err := migrate.DialectFor(cfg.StorageType)
+		if err != nil {
+			return nil, err
+		}
+		migrator, err := migrate.New(db.DB(), dialect, logger.With("component", "migrate"))
+		if err != nil {
+			return nil, err
+		}
+		checker.Add("migrations", health.Migrations(migrator))
+	}
+	return checker, nil
+}
+
+// AddWorker registers a background worker, started by Run before the server
+// and stopped after it. Call it before Run.
+func (app *Application) AddWorker(w Worker) {
+	app.workers.add(w)
+}
+
+// Run serves until the first signal on signals or a component failure, then
+// shuts down: the server drains its requests, the workers stop, and the
+// repository is closed. A second signal cuts the draining short. Failing to
+// start, e.g. on a port already in use, is returned at once.
+func (app *Application) Run(signals <-chan os.Signal) error {
+	return app.lifecycle().Run(signals)
+}
+
+// lifecycle orders the components: the repository outlives the workers,
+// which outlive the server
+func (app *Application) lifecycle() *Lifecycle {
+	lc := NewLifecycle(app.Logger)
+	lc.Add(Component{
+		Name: "repository",
+		Stop: func(context.Context) error { return app.Repository.Close() },
+	})
+	lc.Add(Component{
+		Name:        "workers",
+		Start:       app.workers.start,
+		Stop:        app.workers.stop,
+		StopTimeout: app.Config.ShutdownTimeout,
+	})
+	lc.Add(Component{
+		Name: "http server",
+		Start: func(fail func(error)) error {
+			return app.Server.Start(fmt.Sprintf(":%s", app.Config.Port), fail)
+		},
+		Stop:        app.Server.Shutdown,
+		StopTimeout: app.Config.ShutdownDelay + app.Config.ShutdownTimeout,
+	})
+	return lc
+}
+
+// Close cleans up application resources, for applications that are never
+// Run, such as the admin CLI's
 func (app *Application) Close() error {
 	return app.Repository.Close()
 }
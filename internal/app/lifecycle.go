@@ -0,0 +1,130 @@
+package app
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// Component is a part of the application started and stopped with it, e.g.
+// the HTTP server or the repository
+type Component struct {
+	Name string
+
+	// Start starts the component without blocking, and returns once it
+	// runs. Failures after that are reported to fail, which stops the
+	// application. Nil for components that are running when added.
+	Start func(fail func(error)) error
+
+	// Stop stops the component, giving up when ctx is done
+	Stop func(ctx context.Context) error
+
+	// StopTimeout bounds Stop, no bound when zero
+	StopTimeout time.Duration
+}
+
+// Lifecycle starts components in the order added and stops them in reverse,
+// so each component outlives those depending on it.
+type Lifecycle struct {
+	logger     *slog.Logger
+	components []Component
+	failed     chan error
+}
+
+// NewLifecycle creates a Lifecycle without components
+func NewLifecycle(logger *slog.Logger) *Lifecycle {
+	return &Lifecycle{logger: logger, failed: make(chan error, 1)}
+}
+
+// Add appends c, to be started after and stopped before those added so far
+func (l *Lifecycle) Add(c Component) {
+	l.components = append(l.components, c)
+}
+
+// Run starts every component, waits for a signal or a component failure,
+// then stops every component. Stopping waits for each component's
+// StopTimeout, unless a second signal arrives, which cuts every remaining
+// stop short.
+//
+// When a component fails to start, those already started are stopped and
+// the start error is returned. Otherwise the error is that of a failed
+// component, if any, joined with those of stopping.
+func (l *Lifecycle) Run(signals <-chan os.Signal) error {
+
+	// Start the components in order
+	// -----------------------------
+	for i, c := range l.components {
+		if c.Start == nil {
+			continue
+		}
+		if err := c.Start(l.fail); err != nil {
+			err = fmt.Errorf("starting %s: %w", c.Name, err)
+			l.logger.Error("Startup failed", "component", c.Name, "error", err)
+			return stderrors.Join(err, l.stop(i, signals))
+		}
+		l.logger.Info("Started", "component", c.Name)
+	}
+
+	// Wait for a signal or a failure
+	// ------------------------------
+	var err error
+	select {
+	case sig := <-signals:
+		l.logger.Info("Shutting down, signal again to force", "signal", sig.String())
+	case err = <-l.failed:
+		l.logger.Error("Shutting down after a failure", "error", err)
+	}
+
+	// Stop the components in reverse
+	// ------------------------------
+	return stderrors.Join(err, l.stop(len(l.components), signals))
+}
+
+// fail reports a running component's failure. Only the first one is kept,
+// as it is what brings the application down.
+func (l *Lifecycle) fail(err error) {
+	select {
+	case l.failed <- err:
+	default:
+	}
+}
+
+// stop stops the first n components in reverse order
+func (l *Lifecycle) stop(n int, signals <-chan os.Signal) error {
+	forced, force := context.WithCancel(context.Background())
+	defer force()
+	go func() {
+		select {
+		case sig := <-signals:
+			l.logger.Warn("Forcing shutdown", "signal", sig.String())
+			force()
+		case <-forced.Done():
+		}
+	}()
+
+	var errs []error
+	for i := n - 1; i >= 0; i-- {
+		c := l.components[i]
+		if c.Stop == nil {
+			continue
+		}
+
+		ctx, cancel := forced, context.CancelFunc(func() {})
+		if c.StopTimeout > 0 {
+			ctx, cancel = context.WithTimeout(forced, c.StopTimeout)
+		}
+		err := c.Stop(ctx)
+		cancel()
+
+		if err != nil {
+			l.logger.Error("Stop failed", "component", c.Name, "error", err)
+			errs = append(errs, fmt.Errorf("stopping %s: %w", c.Name, err))
+			continue
+		}
+		l.logger.Info("Stopped", "component", c.Name)
+	}
+	return stderrors.Join(errs...)
+}
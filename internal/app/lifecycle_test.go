@@ -0,0 +1,255 @@
+package app
+
+import (
+	"appointment-service/internal/api"
+	"appointment-service/internal/config"
+	"appointment-service/internal/model"
+	"appointment-service/internal/service"
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLifecycle tests the ordering of components.
+//
+// It includes the following test cases:
+//
+// * Components start in order and stop in reverse
+// * A start failure stops the components already started, and only those
+// * A failure after start stops every component
+func TestLifecycle(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var events []string
+	component := func(name string, startErr error) Component {
+		return Component{
+			Name: name,
+			Start: func(func(error)) error {
+				events = append(events, "start "+name)
+				return startErr
+			},
+			Stop: func(context.Context) error {
+				events = append(events, "stop "+name)
+				return nil
+			},
+		}
+	}
+
+	t.Run("order", func(t *testing.T) {
+		events = nil
+		lc := NewLifecycle(logger)
+		lc.Add(component("a", nil))
+		lc.Add(component("b", nil))
+
+		signals := make(chan os.Signal, 1)
+		signals <- syscall.SIGTERM
+		require.NoError(t, lc.Run(signals))
+		assert.Equal(t, []string{"start a", "start b", "stop b", "stop a"}, events)
+	})
+
+	t.Run("start failure", func(t *testing.T) {
+		events = nil
+		lc := NewLifecycle(logger)
+		lc.Add(component("a", nil))
+		lc.Add(component("b", stderrors.New("port taken")))
+		lc.Add(component("c", nil))
+
+		err := lc.Run(nil)
+		assert.ErrorContains(t, err, "starting b: port taken")
+		assert.Equal(t, []string{"start a", "start b", "stop a"}, events)
+	})
+
+	t.Run("failure after start", func(t *testing.T) {
+		events = nil
+		lc := NewLifecycle(logger)
+		lc.Add(component("a", nil))
+		lc.Add(Component{
+			Name: "b",
+			Start: func(fail func(error)) error {
+				go fail(stderrors.New("connection lost"))
+				return nil
+			},
+		})
+
+		assert.EqualError(t, lc.Run(nil), "connection lost")
+		assert.Equal(t, []string{"start a", "stop a"}, events)
+	})
+}
+
+// TestApplicationRun tests serving and shutting down a whole application.
+//
+// It includes the following test cases:
+//
+// * A request in flight at shutdown completes before the workers stop
+// * A second signal cuts a stuck request's draining short
+// * A port already in use fails startup, stopping the workers
+// * A failing worker stops the application
+func TestApplicationRun(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("request in flight", func(t *testing.T) {
+		app, slow := newTestApplication(t)
+
+		var drainedFirst atomic.Bool
+		app.AddWorker(Worker{Name: "reminders", Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			drainedFirst.Store(slow.finished.Load())
+			return nil
+		}})
+
+		signals, done := run(app)
+		waitServing(t, app)
+
+		response := make(chan int)
+		go func() { response <- getAvailability(app) }()
+		<-slow.entered
+
+		signals <- syscall.SIGTERM
+		require.Eventually(t, app.Health.Draining, time.Second, time.Millisecond)
+		assert.Never(t, func() bool { return len(done) > 0 }, 50*time.Millisecond, 5*time.Millisecond)
+
+		close(slow.release)
+		assert.Equal(t, http.StatusOK, <-response)
+		require.NoError(t, <-done)
+		assert.True(t, drainedFirst.Load())
+
+		_, err := http.Get(url(app, "/healthz"))
+		assert.Error(t, err)
+	})
+
+	t.Run("second signal", func(t *testing.T) {
+		app, slow := newTestApplication(t)
+		defer close(slow.release)
+
+		signals, done := run(app)
+		waitServing(t, app)
+		go getAvailability(app)
+		<-slow.entered
+
+		signals <- syscall.SIGTERM
+		require.Eventually(t, app.Health.Draining, time.Second, time.Millisecond)
+		signals <- syscall.SIGINT
+
+		select {
+		case err := <-done:
+			assert.ErrorContains(t, err, "stopping http server")
+			assert.ErrorIs(t, err, context.Canceled)
+		case <-time.After(time.Second):
+			t.Fatal("Run did not return on the second signal")
+		}
+	})
+
+	t.Run("port in use", func(t *testing.T) {
+		app, _ := newTestApplication(t)
+		taken, err := net.Listen("tcp", ":"+app.Config.Port)
+		require.NoError(t, err)
+		defer taken.Close()
+
+		var stopped atomic.Bool
+		app.AddWorker(Worker{Name: "reminders", Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			stopped.Store(true)
+			return nil
+		}})
+
+		_, done := run(app)
+		err = <-done
+		assert.ErrorContains(t, err, "starting http server")
+		assert.ErrorContains(t, err, "address already in use")
+		assert.True(t, stopped.Load())
+	})
+
+	t.Run("failing worker", func(t *testing.T) {
+		app, _ := newTestApplication(t)
+		app.AddWorker(Worker{Name: "reminders", Run: func(context.Context) error {
+			return stderrors.New("queue unreachable")
+		}})
+
+		_, done := run(app)
+		assert.EqualError(t, <-done, "worker reminders failed: queue unreachable")
+		assert.Error(t, app.workers.check(context.Background()))
+	})
+}
+
+// slowService holds availability requests until released
+type slowService struct {
+	service.AppointmentServicer
+	entered  chan struct{}
+	release  chan struct{}
+	finished atomic.Bool
+}
+
+func (s *slowService) GetAvailability(ctx context.Context, trainerID int64, startsAt, endsAt time.Time) ([]model.TimeSlot, error) {
+	s.entered <- struct{}{}
+	<-s.release
+	defer s.finished.Store(true)
+	return s.AppointmentServicer.GetAvailability(ctx, trainerID, startsAt, endsAt)
+}
+
+// newTestApplication creates an in-memory application on a free port, whose
+// server uses a slowService
+func newTestApplication(t *testing.T) (*Application, *slowService) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	free, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	_, port, err := net.SplitHostPort(free.Addr().String())
+	require.NoError(t, err)
+	require.NoError(t, free.Close())
+
+	cfg := config.Default()
+	cfg.Port = port
+	app, err := New(cfg, logger, new(slog.LevelVar))
+	require.NoError(t, err)
+
+	slow := &slowService{AppointmentServicer: app.AppointmentService, entered: make(chan struct{}), release: make(chan struct{})}
+	app.Server, err = api.NewServer(cfg, slow, app.Metrics, app.Health, app.LogLevel, logger)
+	require.NoError(t, err)
+	return app, slow
+}
+
+// run runs app in the background, returning its signal channel and the
+// channel Run's error is sent to
+func run(app *Application) (chan os.Signal, chan error) {
+	signals := make(chan os.Signal, 2)
+	done := make(chan error, 1)
+	go func() { done <- app.Run(signals) }()
+	return signals, done
+}
+
+func url(app *Application, path string) string {
+	return fmt.Sprintf("http://127.0.0.1:%s%s", app.Config.Port, path)
+}
+
+func waitServing(t *testing.T, app *Application) {
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(url(app, "/healthz"))
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, time.Second, 5*time.Millisecond)
+}
+
+func getAvailability(app *Application) int {
+	resp, err := http.Get(url(app, "/api/v1/appointments/trainers/1/availability?starts_at=2030-01-07T08:00:00Z&ends_at=2030-01-07T10:00:00Z"))
+	if err != nil {
+		return 0
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
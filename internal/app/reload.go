@@ -0,0 +1,101 @@
+package app
+
+import (
+	"appointment-service/internal/config"
+	"appointment-service/internal/logger"
+	"appointment-service/internal/service"
+	servicefactory "appointment-service/internal/service/factory"
+	"context"
+	"os"
+	"strings"
+	"sync"
+)
+
+// reloads tracks the configuration applied by the last reload
+type reloads struct {
+	mu      sync.Mutex
+	applied *config.Config
+
+	// service is the appointment service before instrumentation, whose
+	// business rules are replaced on reload
+	service *service.AppointmentService
+}
+
+// Reload applies the reloadable settings of next, e.g. the config file as
+// edited since startup: the log level, the business rules and the rate
+// limits. Requests are served throughout, in-flight ones finish under the
+// old rules.
+//
+// Every changed setting gets an audit log line. Settings that need a restart,
+// such as the port or storage, are logged and otherwise ignored. Nothing is
+// applied when the new business rules or rate limits are unusable.
+func (app *Application) Reload(ctx context.Context, next *config.Config) error {
+	app.reloads.mu.Lock()
+	defer app.reloads.mu.Unlock()
+
+	// Build the new rules, and apply the rate limits, before anything else
+	// --------------------------------------------------------------------
+	reloaded := app.reloads.applied.Reload(next)
+	hours, err := servicefactory.WorkingHours(reloaded)
+	if err != nil {
+		return err
+	}
+	if err := app.Server.SetRateLimits(reloaded.RateLimit); err != nil {
+		return err
+	}
+
+	// Apply the rules and the log level
+	// ---------------------------------
+	changes := config.Diff(app.reloads.applied, next)
+	for _, change := range changes {
+		if !change.Reloadable {
+			app.Logger.WarnContext(ctx, "Config change needs a restart",
+				"setting", change.Key,
+				"old", change.Old,
+				"new", change.New)
+			continue
+		}
+
+		if change.Key == "log.level" {
+			// The admin endpoint may have changed it since the last reload
+			change.Old = strings.ToLower(app.LogLevel.Level().String())
+			app.LogLevel.Set(logger.ParseLogLevel(reloaded.LogLevel, app.LogLevel.Level()))
+		}
+		logger.Audit(ctx, app.Logger, "Config changed",
+			"setting", change.Key,
+			"old", change.Old,
+			"new", change.New,
+			"via", "reload")
+	}
+	app.reloads.service.SetPolicy(hours, servicefactory.Rules(reloaded))
+	app.reloads.applied = reloaded
+
+	app.Logger.InfoContext(ctx, "Config reloaded", "file", next.File, "changes", len(changes))
+	return nil
+}
+
+// Reloader returns a worker that reloads the configuration from load on
+// every signal, e.g. SIGHUP. A configuration that fails to load or apply is
+// logged and changes nothing.
+func (app *Application) Reloader(signals <-chan os.Signal, load func() (*config.Config, error)) Worker {
+	return Worker{
+		Name: "config reloader",
+		Run: func(ctx context.Context) error {
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case sig := <-signals:
+					app.Logger.InfoContext(ctx, "Reloading config", "signal", sig.String())
+					next, err := load()
+					if err == nil {
+						err = app.Reload(ctx, next)
+					}
+					if err != nil {
+						app.Logger.ErrorContext(ctx, "Config reload failed, keeping the current config", "error", err)
+					}
+				}
+			}
+		},
+	}
+}
@@ -0,0 +1,126 @@
+package app
+
+import (
+	"appointment-service/internal/config"
+	"appointment-service/internal/logger"
+	"appointment-service/internal/ratelimit"
+	"bytes"
+	"context"
+	stderrors "errors"
+	"log/slog"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReload tests applying a changed configuration while running.
+//
+// It includes the following test cases:
+//
+// * Business rules, rate limits and the log level take effect, with an audit line each
+// * Settings that need a restart are logged and left as they are
+// * Unusable business rules or rate limits change nothing
+// * A signal reloads through the Reloader worker, and load failures are logged
+func TestReload(t *testing.T) {
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var out bytes.Buffer
+	output := writerFunc(func(p []byte) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return out.Write(p)
+	})
+	logs := func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return out.String()
+	}
+
+	level := new(slog.LevelVar)
+	level.Set(slog.LevelWarn)
+	app, err := New(config.Default(), logger.NewLogger(logger.Config{Level: level, Output: output}), level)
+	require.NoError(t, err)
+	defer app.Close()
+
+	// Monday 2030-01-07, 9am to 10am Los Angeles time
+	monday := func() int {
+		slots, err := app.AppointmentService.GetAvailability(ctx, 1,
+			time.Date(2030, 1, 7, 17, 0, 0, 0, time.UTC),
+			time.Date(2030, 1, 7, 18, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		return len(slots)
+	}
+	require.Equal(t, 2, monday())
+
+	t.Run("reloadable", func(t *testing.T) {
+		next := config.Default()
+		next.LogLevel = "debug"
+		next.Business.Weekdays = []time.Weekday{time.Tuesday}
+		next.RateLimit.BookingsPerUser = ratelimit.Limit{Requests: 1, Period: time.Hour}
+		next.Port = "9090"
+
+		require.NoError(t, app.Reload(ctx, next))
+		assert.Equal(t, 0, monday())
+		assert.Equal(t, slog.LevelDebug, level.Level())
+		assert.Equal(t, "8080", app.Config.Port)
+
+		assert.Contains(t, logs(), `level=AUDIT msg="Config changed"`)
+		assert.Contains(t, logs(), `setting=log.level old=warn new=debug via=reload`)
+		assert.Contains(t, logs(), `setting=business.days old="" new=tue via=reload`)
+		assert.Contains(t, logs(), `setting=rate_limit.bookings_per_user old=5/m new=1/h via=reload`)
+		assert.Contains(t, logs(), `level=WARN msg="Config change needs a restart"`)
+		assert.Contains(t, logs(), `setting=app.port old=8080 new=9090`)
+	})
+
+	t.Run("unusable", func(t *testing.T) {
+		next := config.Default()
+		next.TrainerTimezones = "1=Mars/Olympus_Mons"
+
+		assert.ErrorContains(t, app.Reload(ctx, next), "invalid TRAINER_TIMEZONES")
+		assert.Equal(t, 0, monday())
+
+		next = config.Default()
+		next.RateLimit.Routes = map[string]ratelimit.Limit{"GET /api/v2/appointments": {Requests: 1, Period: time.Second}}
+		assert.ErrorContains(t, app.Reload(ctx, next), "rate limit for unknown route")
+		assert.Equal(t, 0, monday())
+		assert.Equal(t, slog.LevelDebug, level.Level())
+	})
+
+	t.Run("signal", func(t *testing.T) {
+		signals := make(chan os.Signal, 1)
+		var loadErr error
+		reloader := app.Reloader(signals, func() (*config.Config, error) {
+			return config.Default(), loadErr
+		})
+
+		workerCtx, stop := context.WithCancel(ctx)
+		done := make(chan error)
+		go func() { done <- reloader.Run(workerCtx) }()
+		defer func() {
+			stop()
+			assert.NoError(t, <-done)
+		}()
+
+		loadErr = stderrors.New("config file: yaml: line 3: did not find expected key")
+		signals <- syscall.SIGHUP
+		require.Eventually(t, func() bool {
+			return bytes.Contains([]byte(logs()), []byte("Config reload failed, keeping the current config"))
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, 0, monday())
+
+		loadErr = nil
+		signals <- syscall.SIGHUP
+		require.Eventually(t, func() bool { return monday() == 2 }, time.Second, time.Millisecond)
+		assert.Equal(t, slog.LevelInfo, level.Level())
+	})
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
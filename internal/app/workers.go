@@ -0,0 +1,82 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Worker is a background job running for the application's lifetime. Run
+// must return once ctx is cancelled; returning before that, with or without
+// an error, is a failure and stops the application.
+type Worker struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// workerGroup runs the application's workers as one component
+type workerGroup struct {
+	workers []Worker
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	stopped atomic.Int32
+}
+
+func (g *workerGroup) add(w Worker) {
+	g.workers = append(g.workers, w)
+}
+
+func (g *workerGroup) start(fail func(error)) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	g.cancel = cancel
+
+	for _, w := range g.workers {
+		g.wg.Add(1)
+		go func(w Worker) {
+			defer g.wg.Done()
+			err := w.Run(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+
+			g.stopped.Add(1)
+			if err == nil {
+				err = fmt.Errorf("worker %s stopped unexpectedly", w.Name)
+			} else {
+				err = fmt.Errorf("worker %s failed: %w", w.Name, err)
+			}
+			fail(err)
+		}(w)
+	}
+	return nil
+}
+
+// stop cancels the workers and waits for them to return
+func (g *workerGroup) stop(ctx context.Context) error {
+	if g.cancel == nil {
+		return nil
+	}
+	g.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("workers did not stop: %w", ctx.Err())
+	}
+}
+
+// check is the readiness check of the workers: all of them still run
+func (g *workerGroup) check(context.Context) error {
+	if n := g.stopped.Load(); n > 0 {
+		return fmt.Errorf("%d of %d workers stopped", n, len(g.workers))
+	}
+	return nil
+}
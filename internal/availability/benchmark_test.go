@@ -0,0 +1,72 @@
+package availability
+
+import (
+	"appointment-service/internal/model"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// BenchmarkOpenSlots compares the interval engine with the baseline, the
+// per-slot × per-booking check availability used before, for a quarter of
+// round-the-clock working hours (about 4,400 slots) and up to 25,000
+// bookings. The baseline grows with slots × bookings, the engine with
+// slots + bookings log bookings.
+//
+//	go test -bench OpenSlots -benchmem ./internal/availability/
+func BenchmarkOpenSlots(b *testing.B) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		b.Fatal(err)
+	}
+	hours := model.WorkingHours{Location: loc, Start: 0, End: 24 * time.Hour}
+	window := Interval{
+		Start: time.Date(2025, 4, 1, 0, 0, 0, 0, loc),
+		End:   time.Date(2025, 7, 1, 0, 0, 0, 0, loc),
+	}
+
+	for _, bookings := range []int{100, 1_000, 10_000, 25_000} {
+		rng := rand.New(rand.NewSource(int64(bookings)))
+		schedule := Schedule{Hours: hours, Bookings: make([]Interval, bookings)}
+		span := window.End.Sub(window.Start)
+		for i := range schedule.Bookings {
+			start := window.Start.Add(time.Duration(rng.Int63n(int64(span)))).Truncate(5 * time.Minute)
+			schedule.Bookings[i] = Interval{Start: start, End: start.Add(5 * time.Minute)}
+		}
+
+		b.Run(fmt.Sprintf("engine/bookings=%d", bookings), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				schedule.OpenSlots(window, slotLength)
+			}
+		})
+
+		b.Run(fmt.Sprintf("baseline/bookings=%d", bookings), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				baselineOpenSlots(schedule, window, slotLength)
+			}
+		})
+	}
+}
+
+// baselineOpenSlots checks every working slot against every blocked
+// interval, which is how GetAvailability computed availability before this
+// package. It is the reference the engine is tested and benchmarked against.
+func baselineOpenSlots(s Schedule, window Interval, length time.Duration) []model.TimeSlot {
+	blocked := append(append(append([]Interval{}, s.Bookings...), s.Holds...), s.TimeOff...)
+
+	var open []model.TimeSlot
+	for _, slot := range WorkingSlots(s.Hours, window, length) {
+		isOpen := true
+		for _, b := range blocked {
+			if slot.StartTime.Before(b.End) && slot.EndTime.After(b.Start) {
+				isOpen = false
+				break
+			}
+		}
+		if isOpen {
+			open = append(open, slot)
+		}
+	}
+	return open
+}
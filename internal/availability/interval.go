@@ -0,0 +1,111 @@
+// Package availability computes when a trainer is free.
+//
+// Everything is expressed as half-open [Start, End) intervals of absolute
+// time. Working hours become a sorted set of working intervals, one per local
+// working day, bookings, holds and time-off are merged into a sorted set of
+// blocked intervals, and free time is the sweep-line difference of the two.
+// Slots are then sliced out of the free intervals on the trainer's local wall
+// clock boundaries.
+//
+// Every step is a linear merge over sorted input, so computing availability
+// costs O((slots + blocked) log blocked) rather than checking every slot
+// against every booking.
+package availability
+
+import (
+	"appointment-service/internal/model"
+	"slices"
+	"time"
+)
+
+// Interval is a half-open span of absolute time, [Start, End). An interval
+// ending at 10:00 does not overlap one starting at 10:00.
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// IsEmpty reports whether the interval contains no time at all
+func (i Interval) IsEmpty() bool {
+	return !i.Start.Before(i.End)
+}
+
+// Overlaps reports whether the two intervals share any time. Empty intervals
+// overlap nothing.
+func (i Interval) Overlaps(o Interval) bool {
+	return !i.IsEmpty() && !o.IsEmpty() &&
+		i.Start.Before(o.End) && o.Start.Before(i.End)
+}
+
+// Grow returns the interval widened by d on both sides
+func (i Interval) Grow(d time.Duration) Interval {
+	return Interval{Start: i.Start.Add(-d), End: i.End.Add(d)}
+}
+
+// FromAppointments returns the time span of each appointment
+func FromAppointments(appointments []model.Appointment) []Interval {
+	intervals := make([]Interval, len(appointments))
+	for i, apt := range appointments {
+		intervals[i] = Interval{Start: apt.StartTime, End: apt.EndTime}
+	}
+	return intervals
+}
+
+// Normalize returns the intervals sorted by start, with empty intervals
+// dropped and overlapping or touching intervals merged. The input is not
+// modified.
+func Normalize(intervals []Interval) []Interval {
+	sorted := make([]Interval, 0, len(intervals))
+	for _, i := range intervals {
+		if !i.IsEmpty() {
+			sorted = append(sorted, i)
+		}
+	}
+	slices.SortFunc(sorted, func(a, b Interval) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	merged := sorted[:0]
+	for _, i := range sorted {
+		if last := len(merged) - 1; last >= 0 && !i.Start.After(merged[last].End) {
+			if i.End.After(merged[last].End) {
+				merged[last].End = i.End
+			}
+			continue
+		}
+		merged = append(merged, i)
+	}
+
+	return merged
+}
+
+// Subtract returns the parts of from that are not covered by remove. Both
+// inputs must be normalized, and so is the result.
+func Subtract(from, remove []Interval) []Interval {
+	var result []Interval
+
+	next := 0
+	for _, f := range from {
+		// Skip removals that end before this interval, they end before every
+		// later interval too
+		for next < len(remove) && !remove[next].End.After(f.Start) {
+			next++
+		}
+
+		start := f.Start
+		for k := next; k < len(remove) && remove[k].Start.Before(f.End); k++ {
+			if remove[k].Start.After(start) {
+				result = append(result, Interval{Start: start, End: remove[k].Start})
+			}
+			if remove[k].End.After(start) {
+				start = remove[k].End
+			}
+		}
+
+		if start.Before(f.End) {
+			result = append(result, Interval{Start: start, End: f.End})
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,143 @@
+package availability
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// at returns 2025-06-02 at the given hour and minute UTC
+func at(hour, minute int) time.Time {
+	return time.Date(2025, 6, 2, hour, minute, 0, 0, time.UTC)
+}
+
+// span returns the interval between two times of day on 2025-06-02 UTC
+func span(startHour, startMinute, endHour, endMinute int) Interval {
+	return Interval{Start: at(startHour, startMinute), End: at(endHour, endMinute)}
+}
+
+// TestIntervalOverlaps tests half-open overlap semantics.
+//
+// It includes the following test cases:
+//
+// * Back-to-back intervals do not overlap
+// * Partially and fully overlapping intervals overlap
+// * Empty intervals overlap nothing
+func TestIntervalOverlaps(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Interval
+		want bool
+	}{
+		{"back to back", span(9, 0, 10, 0), span(10, 0, 10, 30), false},
+		{"back to back reversed", span(10, 0, 10, 30), span(9, 0, 10, 0), false},
+		{"partial", span(9, 0, 10, 0), span(9, 30, 10, 30), true},
+		{"contained", span(9, 0, 11, 0), span(9, 30, 10, 0), true},
+		{"identical", span(9, 0, 9, 30), span(9, 0, 9, 30), true},
+		{"empty", span(9, 30, 9, 30), span(9, 0, 10, 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.a.Overlaps(tt.b))
+		})
+	}
+}
+
+// TestNormalize tests sorting and merging of intervals.
+//
+// It includes the following test cases:
+//
+// * Unsorted, overlapping and touching intervals are merged
+// * Empty and inverted intervals are dropped
+// * Disjoint intervals are kept as they are
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []Interval
+		want  []Interval
+	}{
+		{
+			name:  "merge overlapping and touching",
+			input: []Interval{span(11, 0, 12, 0), span(9, 0, 10, 0), span(9, 30, 10, 30), span(10, 30, 11, 0)},
+			want:  []Interval{span(9, 0, 12, 0)},
+		},
+		{
+			name:  "drop empty and inverted",
+			input: []Interval{span(9, 0, 9, 0), span(10, 0, 9, 0), span(12, 0, 12, 30)},
+			want:  []Interval{span(12, 0, 12, 30)},
+		},
+		{
+			name:  "disjoint",
+			input: []Interval{span(13, 0, 14, 0), span(9, 0, 10, 0)},
+			want:  []Interval{span(9, 0, 10, 0), span(13, 0, 14, 0)},
+		},
+		{
+			name:  "nothing",
+			input: nil,
+			want:  []Interval{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Normalize(tt.input))
+		})
+	}
+}
+
+// TestSubtract tests removing blocked time from working time.
+//
+// It includes the following test cases:
+//
+// * Removal in the middle splits an interval
+// * Removals touching the edges trim an interval
+// * One removal spanning several intervals
+// * Removals entirely before, after and between intervals
+// * Removal covering everything
+func TestSubtract(t *testing.T) {
+	tests := []struct {
+		name   string
+		from   []Interval
+		remove []Interval
+		want   []Interval
+	}{
+		{
+			name:   "split",
+			from:   []Interval{span(8, 0, 12, 0)},
+			remove: []Interval{span(9, 0, 9, 30)},
+			want:   []Interval{span(8, 0, 9, 0), span(9, 30, 12, 0)},
+		},
+		{
+			name:   "trim edges",
+			from:   []Interval{span(8, 0, 12, 0)},
+			remove: []Interval{span(7, 0, 8, 30), span(11, 30, 13, 0)},
+			want:   []Interval{span(8, 30, 11, 30)},
+		},
+		{
+			name:   "one removal spanning several intervals",
+			from:   []Interval{span(8, 0, 9, 0), span(10, 0, 11, 0), span(12, 0, 13, 0)},
+			remove: []Interval{span(8, 30, 12, 30)},
+			want:   []Interval{span(8, 0, 8, 30), span(12, 30, 13, 0)},
+		},
+		{
+			name:   "removals around intervals",
+			from:   []Interval{span(8, 0, 9, 0), span(10, 0, 11, 0)},
+			remove: []Interval{span(6, 0, 7, 0), span(9, 0, 10, 0), span(11, 0, 12, 0)},
+			want:   []Interval{span(8, 0, 9, 0), span(10, 0, 11, 0)},
+		},
+		{
+			name:   "remove everything",
+			from:   []Interval{span(8, 0, 9, 0), span(10, 0, 11, 0)},
+			remove: []Interval{span(0, 0, 23, 0)},
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Subtract(tt.from, tt.remove))
+		})
+	}
+}
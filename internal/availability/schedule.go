@@ -0,0 +1,107 @@
+package availability
+
+import (
+	"appointment-service/internal/model"
+	"time"
+)
+
+// Schedule holds everything that decides when a trainer can be booked: their
+// working hours, and the bookings, holds and time-off that block parts of it.
+// Blocked intervals may be unsorted and may overlap each other.
+type Schedule struct {
+	Hours    model.WorkingHours
+	Bookings []Interval
+	Holds    []Interval
+	TimeOff  []Interval
+}
+
+// Working returns the working time within the window as normalized
+// intervals, one per working day at most. It is built from each local
+// date's working hours, so it costs one step per day in the window rather
+// than one per slot.
+func (s Schedule) Working(window Interval) []Interval {
+	var working []Interval
+
+	loc := s.Hours.Location
+	first := window.Start.In(loc)
+	last := window.End.In(loc)
+	// Start a day early, working hours of the previous local date may reach
+	// into the window
+	day := time.Date(first.Year(), first.Month(), first.Day()-1, 0, 0, 0, 0, loc)
+	for !day.After(last) {
+		year, month, date := day.Date()
+		if s.Hours.IsWorkingDay(day.Weekday()) {
+			start, end := s.Hours.Span(year, month, date)
+			span := Interval{Start: maxTime(start, window.Start), End: minTime(end, window.End)}
+			if !span.IsEmpty() {
+				working = append(working, Interval{Start: span.Start.UTC(), End: span.End.UTC()})
+			}
+		}
+		day = time.Date(year, month, date+1, 0, 0, 0, 0, loc)
+	}
+
+	return Normalize(working)
+}
+
+// Blocked returns the bookings, holds and time-off as normalized intervals
+func (s Schedule) Blocked() []Interval {
+	blocked := make([]Interval, 0, len(s.Bookings)+len(s.Holds)+len(s.TimeOff))
+	blocked = append(blocked, s.Bookings...)
+	blocked = append(blocked, s.Holds...)
+	blocked = append(blocked, s.TimeOff...)
+	return Normalize(blocked)
+}
+
+// Free returns the working time within the window that is not blocked
+func (s Schedule) Free(window Interval) []Interval {
+	return Subtract(s.Working(window), s.Blocked())
+}
+
+// OpenSlots slices the free time within the window into slots of the given
+// length, aligned like WorkingSlots. Only the free time is walked slot by
+// slot, once.
+func (s Schedule) OpenSlots(window Interval, length time.Duration) []model.TimeSlot {
+	var slots []model.TimeSlot
+
+	for _, free := range s.Free(window) {
+		WalkWorkingSlots(s.Hours, free, length, func(slot Interval) {
+			slots = append(slots, model.TimeSlot{
+				StartTime: slot.Start,
+				EndTime:   slot.End,
+			})
+		})
+	}
+
+	return slots
+}
+
+// WalkSlots calls fn, in order, with every working slot in the window and
+// whether it is open, i.e. not overlapped by anything blocked. This suits
+// summaries that need closed and booked slots as well as open ones.
+func (s Schedule) WalkSlots(window Interval, length time.Duration, fn func(slot Interval, open bool)) {
+	blocked := s.Blocked()
+
+	// Slots and blocked intervals are both in ascending order, so the blocked
+	// index only ever moves forward
+	next := 0
+	WalkWorkingSlots(s.Hours, window, length, func(slot Interval) {
+		for next < len(blocked) && !blocked[next].End.After(slot.Start) {
+			next++
+		}
+		fn(slot, next == len(blocked) || !blocked[next].Overlaps(slot))
+	})
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
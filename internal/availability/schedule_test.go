@@ -0,0 +1,205 @@
+package availability
+
+import (
+	"appointment-service/internal/model"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestScheduleOpenSlots tests that the interval engine finds exactly the
+// working slots that no booking, hold or time-off overlaps.
+//
+// It includes the following test cases:
+//
+// * Back-to-back bookings leave the slots around them open
+// * Bookings off the slot grid block every slot they touch
+// * Holds and time-off block slots like bookings do
+// * Randomized schedules across zones, compared with a naive check
+// * WalkSlots reports the same open slots as OpenSlots
+func TestScheduleOpenSlots(t *testing.T) {
+	la, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	hours := model.WorkingHours{Location: la, Start: 8 * time.Hour, End: 17 * time.Hour}
+	day := Interval{
+		Start: time.Date(2025, 6, 2, 0, 0, 0, 0, la),
+		End:   time.Date(2025, 6, 3, 0, 0, 0, 0, la),
+	}
+	local := func(hour, minute int) time.Time {
+		return time.Date(2025, 6, 2, hour, minute, 0, 0, la).UTC()
+	}
+
+	t.Run("back to back bookings", func(t *testing.T) {
+		schedule := Schedule{
+			Hours: hours,
+			Bookings: []Interval{
+				{Start: local(9, 0), End: local(9, 30)},
+				{Start: local(9, 30), End: local(10, 0)},
+			},
+		}
+
+		slots := schedule.OpenSlots(day, slotLength)
+		assert.Len(t, slots, 16)
+		assert.Equal(t, local(8, 30), slots[1].StartTime)
+		assert.Equal(t, local(10, 0), slots[2].StartTime)
+	})
+
+	t.Run("bookings off the grid", func(t *testing.T) {
+		schedule := Schedule{
+			Hours: hours,
+			Bookings: []Interval{
+				{Start: local(8, 10), End: local(8, 20)},
+				{Start: local(12, 0), End: local(17, 0)},
+			},
+		}
+
+		slots := schedule.OpenSlots(day, slotLength)
+		require.Len(t, slots, 7)
+		assert.Equal(t, local(8, 30), slots[0].StartTime)
+		assert.Equal(t, local(11, 30), slots[6].StartTime)
+	})
+
+	t.Run("holds and time off", func(t *testing.T) {
+		schedule := Schedule{
+			Hours:    hours,
+			Bookings: []Interval{{Start: local(9, 0), End: local(9, 30)}},
+			Holds:    []Interval{{Start: local(8, 10), End: local(8, 20)}},
+			TimeOff:  []Interval{{Start: local(12, 0), End: local(17, 0)}},
+		}
+
+		slots := schedule.OpenSlots(day, slotLength)
+		require.Len(t, slots, 6)
+		assert.Equal(t, local(8, 30), slots[0].StartTime)
+		assert.Equal(t, local(9, 30), slots[1].StartTime)
+		assert.Equal(t, local(11, 30), slots[5].StartTime)
+	})
+
+	t.Run("randomized schedules", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(34))
+
+		for _, zone := range []string{"America/Los_Angeles", "Asia/Kathmandu", "Australia/Lord_Howe", "Europe/London"} {
+			loc, err := time.LoadLocation(zone)
+			require.NoError(t, err)
+
+			for i := 0; i < 50; i++ {
+				h := sweepHours[rng.Intn(len(sweepHours))]
+				from := time.Date(2024, time.Month(1+rng.Intn(12)), 1+rng.Intn(28), rng.Intn(24), rng.Intn(60), 0, 0, loc)
+				window := Interval{Start: from, End: from.Add(time.Duration(1+rng.Intn(10)) * 24 * time.Hour)}
+
+				schedule := Schedule{
+					Hours:    model.WorkingHours{Location: loc, Start: h.start, End: h.end},
+					Bookings: randomIntervals(rng, window, 60),
+					Holds:    randomIntervals(rng, window, 5),
+					TimeOff:  randomIntervals(rng, window, 2),
+				}
+
+				want := baselineOpenSlots(schedule, window, slotLength)
+				got := schedule.OpenSlots(window, slotLength)
+				if !assert.Equal(t, want, got, "%s window %s", zone, window.Start) {
+					return
+				}
+
+				var walked []model.TimeSlot
+				schedule.WalkSlots(window, slotLength, func(slot Interval, open bool) {
+					if open {
+						walked = append(walked, model.TimeSlot{StartTime: slot.Start, EndTime: slot.End})
+					}
+				})
+				if !assert.Equal(t, want, walked, "%s window %s", zone, window.Start) {
+					return
+				}
+			}
+		}
+	})
+}
+
+// randomIntervals returns n blocked intervals of 5 minutes to 3 hours that
+// start within the window, often on a slot boundary and sometimes not
+func randomIntervals(rng *rand.Rand, window Interval, n int) []Interval {
+	intervals := make([]Interval, n)
+	span := window.End.Sub(window.Start)
+	for i := range intervals {
+		start := window.Start.Add(time.Duration(rng.Int63n(int64(span))))
+		if rng.Intn(2) == 0 {
+			start = start.Truncate(slotLength)
+		}
+		intervals[i] = Interval{Start: start, End: start.Add(time.Duration(5+rng.Intn(180)) * time.Minute)}
+	}
+	return intervals
+}
+
+// TestScheduleWorking tests that working time is built per local date from
+// the working hours.
+//
+// It includes the following test cases:
+//
+// * One interval per working day, clipped to the window
+// * Working hours starting in a DST gap begin where the gap ends
+// * Working hours ending in a DST overlap end at its later occurrence
+// * Every working slot lies within the working time
+func TestScheduleWorking(t *testing.T) {
+	la, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2025, month, day, hour, minute, 0, 0, la)
+	}
+
+	t.Run("one interval per working day", func(t *testing.T) {
+		schedule := Schedule{Hours: model.WorkingHours{
+			Location: la,
+			Start:    8 * time.Hour,
+			End:      17 * time.Hour,
+			Weekdays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday},
+		}}
+
+		// Sunday noon to Wednesday noon
+		working := schedule.Working(Interval{Start: at(6, 1, 12, 0), End: at(6, 4, 12, 0)})
+		require.Len(t, working, 3)
+		assert.Equal(t, Interval{Start: at(6, 2, 8, 0).UTC(), End: at(6, 2, 17, 0).UTC()}, working[0])
+		assert.Equal(t, Interval{Start: at(6, 4, 8, 0).UTC(), End: at(6, 4, 12, 0).UTC()}, working[2])
+	})
+
+	t.Run("start in a DST gap", func(t *testing.T) {
+		schedule := Schedule{Hours: model.WorkingHours{Location: la, Start: 150 * time.Minute, End: 5 * time.Hour}}
+
+		working := schedule.Working(Interval{Start: at(3, 9, 0, 0), End: at(3, 10, 0, 0)})
+		require.Len(t, working, 1)
+		assert.Equal(t, at(3, 9, 3, 0).UTC(), working[0].Start)
+		assert.Equal(t, 2*time.Hour, working[0].End.Sub(working[0].Start))
+	})
+
+	t.Run("end in a DST overlap", func(t *testing.T) {
+		schedule := Schedule{Hours: model.WorkingHours{Location: la, Start: 0, End: 90 * time.Minute}}
+
+		working := schedule.Working(Interval{Start: at(11, 2, 0, 0), End: at(11, 3, 0, 0)})
+		require.Len(t, working, 1)
+		assert.Equal(t, 150*time.Minute, working[0].End.Sub(working[0].Start))
+	})
+
+	t.Run("randomized working hours", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(31))
+
+		for _, zone := range []string{"America/Los_Angeles", "Asia/Kathmandu", "Australia/Lord_Howe", "America/St_Johns"} {
+			loc, err := time.LoadLocation(zone)
+			require.NoError(t, err)
+
+			for i := 0; i < 50; i++ {
+				h := sweepHours[rng.Intn(len(sweepHours))]
+				from := time.Date(2024, time.Month(1+rng.Intn(12)), 1+rng.Intn(28), rng.Intn(24), rng.Intn(60), 0, 0, loc)
+				window := Interval{Start: from, End: from.Add(time.Duration(1+rng.Intn(10)) * 24 * time.Hour)}
+				schedule := Schedule{Hours: model.WorkingHours{Location: loc, Start: h.start, End: h.end}}
+
+				working := schedule.Working(window)
+				for _, slot := range WorkingSlots(schedule.Hours, window, slotLength) {
+					slot := Interval{Start: slot.StartTime, End: slot.EndTime}
+					if !assert.Empty(t, Subtract([]Interval{slot}, working), "%s slot %s", zone, slot.Start) {
+						return
+					}
+				}
+			}
+		}
+	})
+}
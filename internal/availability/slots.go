@@ -0,0 +1,46 @@
+package availability
+
+import (
+	"appointment-service/internal/model"
+	"time"
+)
+
+// WorkingSlots returns every slot of the given length within the window that
+// lies within working hours, aligned to the trainer's local wall clock (e.g.
+// :00 and :30 local time, even for zones offset by :30 or :45). Bookings are
+// not taken into account, see Schedule for that.
+//
+// Slots are walked in absolute time, so a DST overlap yields the repeated
+// local slots twice and a DST gap yields none of the skipped ones. Whenever
+// a UTC offset change breaks the alignment, the walk re-aligns to the next
+// local slot boundary.
+func WorkingSlots(hours model.WorkingHours, window Interval, length time.Duration) []model.TimeSlot {
+	var slots []model.TimeSlot
+
+	WalkWorkingSlots(hours, window, length, func(slot Interval) {
+		slots = append(slots, model.TimeSlot{
+			StartTime: slot.Start,
+			EndTime:   slot.End,
+		})
+	})
+
+	return slots
+}
+
+// WalkWorkingSlots calls fn, in order, with the UTC span of every slot
+// WorkingSlots would return, without collecting them
+func WalkWorkingSlots(hours model.WorkingHours, window Interval, length time.Duration, fn func(slot Interval)) {
+	slotStart := hours.NextAligned(window.Start, length)
+	for !slotStart.Add(length).After(window.End) {
+		if !hours.IsAligned(slotStart, length) {
+			slotStart = hours.NextAligned(slotStart, length)
+			continue
+		}
+
+		slotEnd := slotStart.Add(length)
+		if hours.Contains(slotStart, slotEnd) {
+			fn(Interval{Start: slotStart.UTC(), End: slotEnd.UTC()})
+		}
+		slotStart = slotEnd
+	}
+}
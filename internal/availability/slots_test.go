@@ -0,0 +1,294 @@
+package availability
+
+import (
+	"appointment-service/internal/model"
+	"math/rand"
+	"testing"
+	"time"
+	_ "time/tzdata" // property tests must not depend on the host's zoneinfo
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slotLength is the appointment length used throughout the tests
+const slotLength = 30 * time.Minute
+
+// sweepZones covers whole hour, :30 and :45 offsets, 30 minute DST shifts,
+// southern hemisphere DST and zones that dropped or changed DST rules.
+var sweepZones = []string{
+	"UTC",
+	"America/Los_Angeles",
+	"America/New_York",
+	"America/St_Johns",
+	"America/Santiago",
+	"America/Sao_Paulo",
+	"Europe/London",
+	"Europe/Berlin",
+	"Africa/Casablanca",
+	"Asia/Tehran",
+	"Asia/Kolkata",
+	"Asia/Kathmandu",
+	"Australia/Adelaide",
+	"Australia/Eucla",
+	"Australia/Lord_Howe",
+	"Pacific/Chatham",
+	"Pacific/Apia",
+}
+
+// sweepHours are working hours chosen so that DST transitions, which mostly
+// happen at night, actually fall inside the working day
+var sweepHours = []struct{ start, end time.Duration }{
+	{0, 24 * time.Hour},
+	{0, 6 * time.Hour},
+	{1 * time.Hour, 4 * time.Hour},
+	{8 * time.Hour, 17 * time.Hour},
+	{22 * time.Hour, 24 * time.Hour},
+	{90 * time.Minute, 150 * time.Minute},
+}
+
+// TestWorkingSlotsKnownDays tests slot generation on hand-checked days.
+//
+// It includes the following test cases:
+//
+// * Spring forward in Los Angeles, where 2:00 and 2:30 do not exist
+// * Fall back in Los Angeles, where 1:00 and 1:30 happen twice
+// * Kathmandu (+5:45), where local :00/:30 is UTC :15/:45
+// * Newfoundland (-2:30 in summer), where local :00/:30 is UTC :30/:00
+// * Default Pacific business hours on a regular day
+func TestWorkingSlotsKnownDays(t *testing.T) {
+	tests := []struct {
+		name      string
+		zone      string
+		start     time.Duration
+		end       time.Duration
+		day       time.Time
+		wantSlots int
+		wantFirst string
+	}{
+		{
+			name:      "spring forward skips the missing hour",
+			zone:      "America/Los_Angeles",
+			start:     0,
+			end:       6 * time.Hour,
+			day:       time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC),
+			wantSlots: 10,
+			wantFirst: "2024-03-10T08:00:00Z",
+		},
+		{
+			name:      "fall back repeats the doubled hour",
+			zone:      "America/Los_Angeles",
+			start:     0,
+			end:       6 * time.Hour,
+			day:       time.Date(2024, 11, 3, 0, 0, 0, 0, time.UTC),
+			wantSlots: 14,
+			wantFirst: "2024-11-03T07:00:00Z",
+		},
+		{
+			name:      "quarter hour offset",
+			zone:      "Asia/Kathmandu",
+			start:     8 * time.Hour,
+			end:       17 * time.Hour,
+			day:       time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC),
+			wantSlots: 18,
+			wantFirst: "2024-06-03T02:15:00Z",
+		},
+		{
+			name:      "half hour offset",
+			zone:      "America/St_Johns",
+			start:     8 * time.Hour,
+			end:       17 * time.Hour,
+			day:       time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC),
+			wantSlots: 18,
+			wantFirst: "2024-06-03T10:30:00Z",
+		},
+		{
+			name:      "default business hours",
+			zone:      "America/Los_Angeles",
+			start:     8 * time.Hour,
+			end:       17 * time.Hour,
+			day:       time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC),
+			wantSlots: 18,
+			wantFirst: "2025-06-02T15:00:00Z",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc, err := time.LoadLocation(tt.zone)
+			require.NoError(t, err)
+			hours := model.WorkingHours{Location: loc, Start: tt.start, End: tt.end}
+
+			// Cover the whole local day, whichever UTC day it falls on
+			from := time.Date(tt.day.Year(), tt.day.Month(), tt.day.Day(), 0, 0, 0, 0, loc)
+			to := from.Add(26 * time.Hour)
+			slots := WorkingSlots(hours, Interval{Start: from, End: to}, slotLength)
+
+			var sameDay []model.TimeSlot
+			for _, slot := range slots {
+				if slot.StartTime.In(loc).Day() == tt.day.Day() {
+					sameDay = append(sameDay, slot)
+				}
+			}
+			assert.Len(t, sameDay, tt.wantSlots)
+			if assert.NotEmpty(t, sameDay) {
+				assert.Equal(t, tt.wantFirst, sameDay[0].StartTime.Format(time.RFC3339))
+			}
+		})
+	}
+}
+
+// TestWorkingSlotsDSTSweep is a property-based sweep of slot generation.
+//
+// For many IANA zones it finds every UTC offset change between 2010 and 2035
+// and generates slots for windows around each transition, plus random
+// windows, random working hours and random (unaligned) window bounds.
+// Every result is checked against these properties:
+//
+// * Each slot starts on a :00 or :30 boundary of the trainer's local wall clock
+// * Each slot is exactly 30 minutes long and within the requested window
+// * Slots are strictly increasing and never overlap
+// * The result equals a brute-force scan of every minute in the window
+//
+// The brute-force scan uses its own wall clock arithmetic rather than
+// model.WorkingHours.
+//
+// Note: the random source is seeded, so failures are reproducible.
+func TestWorkingSlotsDSTSweep(t *testing.T) {
+	rng := rand.New(rand.NewSource(20241103))
+	sweepStart := time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)
+	sweepEnd := time.Date(2035, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	for _, zone := range sweepZones {
+		t.Run(zone, func(t *testing.T) {
+			loc, err := time.LoadLocation(zone)
+			require.NoError(t, err)
+
+			var windows [][2]time.Time
+
+			// Windows straddling every offset change
+			for _, transition := range offsetTransitions(loc, sweepStart, sweepEnd) {
+				from := transition.Add(-36 * time.Hour).Add(time.Duration(rng.Intn(3600)) * time.Second)
+				windows = append(windows, [2]time.Time{from, from.Add(72 * time.Hour)})
+			}
+
+			// Random windows anywhere in the sweep, including zones without DST
+			for i := 0; i < 40; i++ {
+				span := sweepEnd.Sub(sweepStart)
+				from := sweepStart.Add(time.Duration(rng.Int63n(int64(span)))).Truncate(time.Second)
+				windows = append(windows, [2]time.Time{from, from.Add(time.Duration(1+rng.Intn(72)) * time.Hour)})
+			}
+
+			for _, window := range windows {
+				h := sweepHours[rng.Intn(len(sweepHours))]
+				hours := model.WorkingHours{Location: loc, Start: h.start, End: h.end}
+				if rng.Intn(4) == 0 {
+					hours.Weekdays = []time.Weekday{time.Monday, time.Wednesday, time.Saturday}
+				}
+
+				slots := WorkingSlots(hours, Interval{Start: window[0], End: window[1]}, slotLength)
+				if !checkSlotProperties(t, hours, window[0], window[1], slots) {
+					t.Fatalf("properties violated for %s hours %v-%v window %s to %s",
+						zone, hours.Start, hours.End,
+						window[0].Format(time.RFC3339), window[1].Format(time.RFC3339))
+				}
+			}
+		})
+	}
+}
+
+// checkSlotProperties asserts the slot generation properties, returning false
+// if any of them does not hold
+func checkSlotProperties(t *testing.T, hours model.WorkingHours, from, to time.Time, slots []model.TimeSlot) bool {
+	t.Helper()
+	ok := true
+
+	for i, slot := range slots {
+		local := slot.StartTime.In(hours.Location)
+		ok = assert.Zero(t, local.Minute()%30, "slot %s not aligned locally", local) && ok
+		ok = assert.Zero(t, local.Second(), "slot %s not aligned locally", local) && ok
+		ok = assert.Equal(t, slotLength, slot.EndTime.Sub(slot.StartTime)) && ok
+		ok = assert.False(t, slot.StartTime.Before(from) || slot.EndTime.After(to), "slot %s outside window", local) && ok
+		if i > 0 {
+			ok = assert.False(t, slot.StartTime.Before(slots[i-1].EndTime), "slot %s overlaps previous", local) && ok
+		}
+	}
+
+	want := bruteForceSlotStarts(hours, from, to, slotLength)
+	var got []time.Time
+	for _, slot := range slots {
+		got = append(got, slot.StartTime)
+	}
+	ok = assert.Equal(t, want, got) && ok
+
+	return ok
+}
+
+// bruteForceSlotStarts checks every minute of the window independently of
+// model.WorkingHours: a minute is a slot start if it is :00 or :30 on the local
+// wall clock, falls on a working day and both its start and end wall clock
+// times, measured from the local midnight it starts after, are within hours
+func bruteForceSlotStarts(hours model.WorkingHours, from, to time.Time, length time.Duration) []time.Time {
+	var starts []time.Time
+
+	first := from.Truncate(time.Minute)
+	if first.Before(from) {
+		first = first.Add(time.Minute)
+	}
+
+	for start := first; !start.Add(length).After(to); start = start.Add(time.Minute) {
+		localStart := start.In(hours.Location)
+		if localStart.Second() != 0 || localStart.Minute()%30 != 0 {
+			continue
+		}
+		if !hours.IsWorkingDay(localStart.Weekday()) {
+			continue
+		}
+
+		localEnd := start.Add(length).In(hours.Location)
+		startMinutes := localStart.Hour()*60 + localStart.Minute()
+		endMinutes := civilDaysBetween(localStart, localEnd)*24*60 + localEnd.Hour()*60 + localEnd.Minute()
+
+		if startMinutes >= int(hours.Start.Minutes()) && endMinutes <= int(hours.End.Minutes()) {
+			starts = append(starts, start.UTC())
+		}
+	}
+
+	return starts
+}
+
+// civilDaysBetween counts calendar days between the local dates of a and b
+func civilDaysBetween(a, b time.Time) int {
+	ad := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	bd := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(bd.Sub(ad).Hours() / 24)
+}
+
+// offsetTransitions finds every instant in [from, to) at which the zone's UTC
+// offset changes, scanning in 6 hour steps and bisecting to the second
+func offsetTransitions(loc *time.Location, from, to time.Time) []time.Time {
+	var transitions []time.Time
+
+	_, prevOffset := from.In(loc).Zone()
+	for t := from; t.Before(to); t = t.Add(6 * time.Hour) {
+		next := t.Add(6 * time.Hour)
+		_, nextOffset := next.In(loc).Zone()
+		if nextOffset == prevOffset {
+			continue
+		}
+
+		lo, hi := t, next
+		for hi.Sub(lo) > time.Second {
+			mid := lo.Add(hi.Sub(lo) / 2)
+			if _, offset := mid.In(loc).Zone(); offset == prevOffset {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		transitions = append(transitions, hi)
+		prevOffset = nextOffset
+	}
+
+	return transitions
+}
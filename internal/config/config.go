@@ -1,9 +1,15 @@
+// Package config loads the service's configuration. Every setting has a
+// default, and can be set in a YAML or TOML config file, by an environment
+// variable and, for the common ones, by a command line flag. Later sources
+// win: flags > environment > file > defaults.
 package config
 
 import (
+	"appointment-service/internal/ratelimit"
 	"fmt"
 	"os"
-	"strconv"
+	"strings"
+	"time"
 )
 
 type Environment string
@@ -31,6 +37,51 @@ type Config struct {
 	SqlLite3DbFile string
 	Port           string
 	DB             DBConfig
+	Business       BusinessConfig
+	RateLimit      RateLimitConfig
+
+	// LogRedact lists the key patterns of log attributes whose values are
+	// redacted, e.g. "*password*" or "query.email"; see path.Match
+	LogRedact []string
+
+	// LogSample logs one in every n records of a message, e.g.
+	// {"Slot calculation": 100}. Other messages are all logged.
+	LogSample map[string]int
+
+	// File is the config file the configuration was read from, if any
+	File string
+
+	// AutoMigrate applies pending schema migrations when the service starts.
+	// Turn it off to run them separately with the migrate subcommand.
+	AutoMigrate bool
+
+	// TrainerTimezones assigns trainers their own IANA time zone, e.g.
+	// "12=Asia/Kolkata,13=America/St_Johns". Trainers not listed work in
+	// the default business time zone.
+	TrainerTimezones string
+
+	// TrustedProxies are the addresses or CIDR ranges of the proxies whose
+	// X-Forwarded-For header gives the client's IP address. Requests from
+	// anywhere else are taken to come from their own address.
+	TrustedProxies []string
+
+	// AdminToken enables the admin endpoints, e.g. to change the log level,
+	// for requests bearing it. They are not served when it is empty.
+	AdminToken string
+
+	// TracesExporter is where trace spans go: "otlp" (endpoint and headers
+	// from the standard OTEL_EXPORTER_OTLP_* variables), "stdout", or "none"
+	// to switch tracing off.
+	TracesExporter string
+
+	// ShutdownDelay is how long readiness fails before the server stops
+	// accepting connections on shutdown. Set it to cover the load
+	// balancer's probe interval, so no request hits a closed port.
+	ShutdownDelay time.Duration
+
+	// ShutdownTimeout is how long in-flight requests, and then background
+	// workers, get to finish on shutdown
+	ShutdownTimeout time.Duration
 }
 
 type DBConfig struct {
@@ -39,44 +90,146 @@ type DBConfig struct {
 	Name     string
 	User     string
 	Password string
+	SSLMode  string
+}
+
+// BusinessConfig holds the booking rules: when trainers work and how
+// appointments are cut
+type BusinessConfig struct {
+	// TimeZone is the IANA zone the business hours are kept in
+	TimeZone string
+
+	// OpensAt and ClosesAt are wall clock times as offsets from local
+	// midnight, e.g. 8h and 17h for 8am to 5pm
+	OpensAt  time.Duration
+	ClosesAt time.Duration
+
+	// Weekdays are the working days, every day when empty
+	Weekdays []time.Weekday
+
+	// AppointmentDuration is the length of every appointment, and so of
+	// every availability slot
+	AppointmentDuration time.Duration
+
+	// Buffer is the minimum gap kept between two appointments of a trainer
+	Buffer time.Duration
+
+	// MaxAvailabilityWindow caps how wide a window availability may be
+	// asked for, no cap when zero
+	MaxAvailabilityWindow time.Duration
+}
+
+// RateLimitConfig limits how often each client may call
+type RateLimitConfig struct {
+	// Key tells clients apart: "ip" by their IP address, or "api_key" by
+	// their X-API-Key header when it is one of APIKeys, and by IP address
+	// otherwise
+	Key string
+
+	// APIKeys are the keys clients are told apart by when Key is api_key. A
+	// request with any other key is keyed by its IP address, so that making
+	// up keys does not get a client fresh limits.
+	APIKeys []string
+
+	// Routes limits the requests to routes, by method and path template,
+	// e.g. "POST /api/v1/appointments". Routes not listed are not limited.
+	Routes map[string]ratelimit.Limit
+
+	// BookingsPerUser limits the attempts of each user to book an
+	// appointment, whichever client they come from
+	BookingsPerUser ratelimit.Limit
 }
 
-func Load() *Config {
+// Default returns the configuration used where nothing else is set
+func Default() *Config {
 	return &Config{
-		Environment:    Environment(envOrDefault("APP_ENV", "development")),
-		Port:           envOrDefault("APP_PORT", "8080"),
-		LogLevel:       envOrDefault("LOG_LEVEL", "info"),
-		LogSource:      envAsBool("LOG_SOURCE", true),
-		LogFormat:      envOrDefault("LOG_FORMAT", "text"),
-		StorageType:    StorageType(envOrDefault("STORAGE_TYPE", "memory")),
-		SqlLite3DbFile: envOrDefault("DB_FILE", ""),
+		Environment: Development,
+		Port:        "8080",
+		LogLevel:    "info",
+		LogSource:   true,
+		LogFormat:   "text",
+		LogRedact: []string{
+			"*password*", "*secret*", "*token*", "authorization", "cookie",
+			"email", "phone", "notes", "user_agent",
+		},
+		StorageType:     Memory,
+		AutoMigrate:     true,
+		TracesExporter:  "none",
+		ShutdownTimeout: 5 * time.Second,
 		DB: DBConfig{
-			Host:     envOrDefault("DB_HOST", ""),
-			Port:     envOrDefault("DB_PORT", ""),
-			Name:     envOrDefault("DB_NAME", ""),
-			User:     envOrDefault("DB_USER", ""),
-			Password: envOrDefault("DB_PASSWORD", ""),
+			SSLMode: "require",
+		},
+		Business: BusinessConfig{
+			TimeZone:              "America/Los_Angeles",
+			OpensAt:               8 * time.Hour,
+			ClosesAt:              17 * time.Hour,
+			AppointmentDuration:   30 * time.Minute,
+			MaxAvailabilityWindow: 31 * 24 * time.Hour,
+		},
+		RateLimit: RateLimitConfig{
+			Key: "ip",
+			Routes: map[string]ratelimit.Limit{
+				"GET /api/v1/appointments/trainers/:trainer_id/availability": {Requests: 10, Period: time.Second},
+				"GET /api/v1/appointments/trainers/:trainer_id/calendar":     {Requests: 10, Period: time.Second},
+				"POST /api/v1/appointments":                                  {Requests: 60, Period: time.Minute},
+				"POST /api/v1/appointments/import":                           {Requests: 10, Period: time.Minute},
+			},
+			BookingsPerUser: ratelimit.Limit{Requests: 5, Period: time.Minute},
 		},
 	}
 }
 
-func envOrDefault(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// Load builds the configuration from the defaults, the config file named by
+// the -config flag or CONFIG_FILE, the environment and the flags, each
+// overriding the ones before. flags may be nil for commands without any.
+//
+// Every unparsable value and every problem found by Validate is reported at
+// once, as Problems.
+func Load(flags *Flags) (*Config, error) {
+	cfg := Default()
+	var problems Problems
+
+	// Apply the config file
+	// ---------------------
+	cfg.File = os.Getenv("CONFIG_FILE")
+	if path := flags.configFile(); path != "" {
+		cfg.File = path
+	}
+	if cfg.File != "" {
+		values, err := readFile(cfg.File)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range sortedKeys(values) {
+			s, ok := settingsByKey[key]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s: unknown setting %q", cfg.File, key))
+				continue
+			}
+			problems = problems.check(s.set(cfg, values[key]), "%s: %s", cfg.File, key)
+		}
 	}
-	return defaultValue
-}
 
-func envAsBool(key string, defaultValue bool) bool {
-	val := os.Getenv(key)
-	if val == "" {
-		return defaultValue
+	// Apply the environment, then the flags
+	// -------------------------------------
+	for _, s := range settings {
+		if value := os.Getenv(s.env); value != "" {
+			problems = problems.check(s.set(cfg, value), "%s", s.env)
+		}
 	}
-	parsed, err := strconv.ParseBool(val)
-	if err != nil {
-		return defaultValue
+	for _, f := range flags.visited() {
+		problems = problems.check(settingsByFlag[f.Name].set(cfg, f.Value.String()), "-%s", f.Name)
 	}
-	return parsed
+
+	// Check the result
+	// ----------------
+	if err := cfg.Validate(); err != nil {
+		problems = append(problems, err.(Problems)...)
+	}
+	if len(problems) > 0 {
+		return nil, problems
+	}
+	return cfg, nil
 }
 
 func (c *Config) String() string {
@@ -85,10 +238,13 @@ func (c *Config) String() string {
 			"Configuration\n"+
 			"-------------------------------------------------------------\n"+
 			"Config{\n"+
+			"  File: %s\n"+
 			"  Environment: %s\n"+
 			"  LogLevel: %s\n"+
 			"  LogSource: %t\n"+
 			"  LogFormat: %s\n"+
+			"  LogRedact: %s\n"+
+			"  LogSample: %s\n"+
 			"  StorageType: %s\n"+
 			"  SqlLite3DbFile: %s\n"+
 			"  Port: %s\n"+
@@ -98,13 +254,39 @@ func (c *Config) String() string {
 			"    Name: %s\n"+
 			"    User: %s\n"+
 			"    Password: ***\n"+ // Hide password
+			"    SSLMode: %s\n"+
+			"  }\n"+
+			"  Business: {\n"+
+			"    TimeZone: %s\n"+
+			"    OpensAt: %s\n"+
+			"    ClosesAt: %s\n"+
+			"    Weekdays: %s\n"+
+			"    AppointmentDuration: %s\n"+
+			"    Buffer: %s\n"+
+			"    MaxAvailabilityWindow: %s\n"+
+			"  }\n"+
+			"  RateLimit: {\n"+
+			"    Key: %s\n"+
+			"    APIKeys: %s\n"+
+			"    Routes: %s\n"+
+			"    BookingsPerUser: %s\n"+
 			"  }\n"+
+			"  TrustedProxies: %s\n"+
+			"  AutoMigrate: %t\n"+
+			"  TrainerTimezones: %s\n"+
+			"  AdminToken: %s\n"+
+			"  TracesExporter: %s\n"+
+			"  ShutdownDelay: %s\n"+
+			"  ShutdownTimeout: %s\n"+
 			"}\n"+
 			"=============================================================",
+		c.File,
 		c.Environment,
 		c.LogLevel,
 		c.LogSource,
 		c.LogFormat,
+		strings.Join(c.LogRedact, ","),
+		formatRates(c.LogSample),
 		c.StorageType,
 		c.SqlLite3DbFile,
 		c.Port,
@@ -112,5 +294,58 @@ func (c *Config) String() string {
 		c.DB.Port,
 		c.DB.Name,
 		c.DB.User,
+		c.DB.SSLMode,
+		c.Business.TimeZone,
+		formatClock(c.Business.OpensAt),
+		formatClock(c.Business.ClosesAt),
+		formatWeekdays(c.Business.Weekdays),
+		c.Business.AppointmentDuration,
+		c.Business.Buffer,
+		c.Business.MaxAvailabilityWindow,
+		c.RateLimit.Key,
+		mask(strings.Join(c.RateLimit.APIKeys, ",")),
+		formatRouteLimits(c.RateLimit.Routes),
+		c.RateLimit.BookingsPerUser,
+		strings.Join(c.TrustedProxies, ","),
+		c.AutoMigrate,
+		c.TrainerTimezones,
+		mask(c.AdminToken),
+		c.TracesExporter,
+		c.ShutdownDelay,
+		c.ShutdownTimeout,
 	)
 }
+
+// Masked returns a copy of c with every secret setting that is set, such as
+// the database password or the rate limit API keys, replaced by "***", for
+// showing the configuration
+func (c *Config) Masked() *Config {
+	masked := *c
+	for _, s := range settings {
+		if secrets[s.key] && s.get(c) != "" {
+			// "***" parses as any text or list setting
+			_ = s.set(&masked, mask(s.get(c)))
+		}
+	}
+	return &masked
+}
+
+// mask hides a secret, showing only whether it is set
+func mask(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return "***"
+}
+
+// formatWeekdays lists weekdays the way they are configured, e.g. "mon,tue"
+func formatWeekdays(days []time.Weekday) string {
+	if len(days) == 0 {
+		return "every day"
+	}
+	names := make([]string, len(days))
+	for i, day := range days {
+		names[i] = strings.ToLower(day.String()[:3])
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,291 @@
+package config
+
+import (
+	"appointment-service/internal/ratelimit"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoad tests where settings come from.
+//
+// It includes the following test cases:
+//
+// * Defaults when nothing is set
+// * YAML and TOML files, including nested tables and lists
+// * Environment overriding the file, and flags overriding the environment
+// * Flags left out not overriding anything
+// * Unknown file keys and unparsable values, reported with their source
+// * Unknown file formats and missing files
+func TestLoad(t *testing.T) {
+	load := func(t *testing.T, args ...string) (*Config, error) {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags := RegisterFlags(fs)
+		require.NoError(t, fs.Parse(args))
+		return Load(flags)
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		clearEnv(t)
+		cfg, err := Load(nil)
+		require.NoError(t, err)
+		assert.Equal(t, Default(), cfg)
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		clearEnv(t)
+		path := writeFile(t, "config.yaml", `
+app:
+  port: 9090
+log:
+  level: debug
+  source: false
+  redact: [email, "query.*"]
+  sample: ["Slot calculation=100", "Listed appointments = 10"]
+business:
+  opens_at: "09:00"
+  closes_at: "18:30"
+  days: [mon, tue, wednesday]
+  appointment_duration: 45m
+  buffer: 15m
+`)
+		cfg, err := load(t, "-config", path)
+		require.NoError(t, err)
+		assert.Equal(t, path, cfg.File)
+		assert.Equal(t, "9090", cfg.Port)
+		assert.Equal(t, "debug", cfg.LogLevel)
+		assert.False(t, cfg.LogSource)
+		assert.Equal(t, []string{"email", "query.*"}, cfg.LogRedact)
+		assert.Equal(t, map[string]int{"Slot calculation": 100, "Listed appointments": 10}, cfg.LogSample)
+		assert.Equal(t, 9*time.Hour, cfg.Business.OpensAt)
+		assert.Equal(t, 18*time.Hour+30*time.Minute, cfg.Business.ClosesAt)
+		assert.Equal(t, []time.Weekday{time.Monday, time.Tuesday, time.Wednesday}, cfg.Business.Weekdays)
+		assert.Equal(t, 45*time.Minute, cfg.Business.AppointmentDuration)
+		assert.Equal(t, 15*time.Minute, cfg.Business.Buffer)
+	})
+
+	t.Run("toml", func(t *testing.T) {
+		clearEnv(t)
+		t.Setenv("CONFIG_FILE", writeFile(t, "config.toml", `
+[storage]
+type = "sqlite3"
+db_file = "/var/lib/appointments.db"
+auto_migrate = false
+
+[business]
+timezone = "Europe/Paris"
+days = ["mon", "fri"]
+
+[rate_limit]
+key = "api_key"
+api_keys = ["k-alpha", "k-beta"]
+routes = ["post /api/v1/appointments = 30/m", "GET /api/v1/appointments/:id=5/10s"]
+bookings_per_user = "3/m"
+`))
+		cfg, err := Load(nil)
+		require.NoError(t, err)
+		assert.Equal(t, SqlLite3, cfg.StorageType)
+		assert.Equal(t, "/var/lib/appointments.db", cfg.SqlLite3DbFile)
+		assert.False(t, cfg.AutoMigrate)
+		assert.Equal(t, "Europe/Paris", cfg.Business.TimeZone)
+		assert.Equal(t, []time.Weekday{time.Monday, time.Friday}, cfg.Business.Weekdays)
+		assert.Equal(t, RateLimitConfig{
+			Key:     "api_key",
+			APIKeys: []string{"k-alpha", "k-beta"},
+			Routes: map[string]ratelimit.Limit{
+				"POST /api/v1/appointments":    {Requests: 30, Period: time.Minute},
+				"GET /api/v1/appointments/:id": {Requests: 5, Period: 10 * time.Second},
+			},
+			BookingsPerUser: ratelimit.Limit{Requests: 3, Period: time.Minute},
+		}, cfg.RateLimit)
+	})
+
+	t.Run("precedence", func(t *testing.T) {
+		clearEnv(t)
+		path := writeFile(t, "config.yaml", "app:\n  port: 9090\nlog:\n  level: debug\n  format: json\n")
+		t.Setenv("APP_PORT", "9191")
+		t.Setenv("LOG_LEVEL", "warn")
+
+		cfg, err := load(t, "-config", path, "-port", "9292")
+		require.NoError(t, err)
+		assert.Equal(t, "9292", cfg.Port)
+		assert.Equal(t, "warn", cfg.LogLevel)
+		assert.Equal(t, "json", cfg.LogFormat)
+	})
+
+	t.Run("flags left out", func(t *testing.T) {
+		clearEnv(t)
+		t.Setenv("STORAGE_TYPE", "sqlite3")
+		t.Setenv("DB_FILE", "/tmp/appointments.db")
+
+		cfg, err := load(t, "-port", "9292")
+		require.NoError(t, err)
+		assert.Equal(t, SqlLite3, cfg.StorageType)
+		assert.Equal(t, "/tmp/appointments.db", cfg.SqlLite3DbFile)
+	})
+
+	t.Run("bad values", func(t *testing.T) {
+		clearEnv(t)
+		path := writeFile(t, "config.yaml", "log:\n  colour: red\nbusiness:\n  buffer: soon\n")
+		t.Setenv("LOG_SOURCE", "maybe")
+		t.Setenv("LOG_SAMPLE", "Slot calculation")
+		t.Setenv("RATE_LIMIT_ROUTES", "availability=10/s")
+
+		_, err := load(t, "-config", path, "-port", "http")
+		var problems Problems
+		require.ErrorAs(t, err, &problems)
+		assert.Equal(t, Problems{
+			path + `: business.buffer: "soon" is not a duration, e.g. 30m`,
+			path + `: unknown setting "log.colour"`,
+			`LOG_SOURCE: "maybe" is not a boolean`,
+			`LOG_SAMPLE: "Slot calculation" is not a name and rate, e.g. Slot calculation=100`,
+			`RATE_LIMIT_ROUTES: "availability=10/s" is not a route and rate limit, e.g. POST /api/v1/appointments=60/m`,
+			`app.port (APP_PORT) is "http", must be a port number between 1 and 65535`,
+		}, problems)
+	})
+
+	t.Run("bad files", func(t *testing.T) {
+		clearEnv(t)
+		_, err := load(t, "-config", writeFile(t, "config.json", "{}"))
+		assert.ErrorContains(t, err, `unknown format ".json"`)
+
+		_, err = load(t, "-config", filepath.Join(t.TempDir(), "missing.yaml"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+// TestValidate tests that every problem is reported at once.
+//
+// It includes the following test cases:
+//
+// * The defaults, which are valid
+// * Bad service settings: port, log level, format and redaction, exporter, proxies, rate limit key
+// * SQLite storage without a database file
+// * Postgres storage without connection settings
+// * Business hours that close before they open, a negative buffer and bad trainer time zones
+// * Rate limits by API key without any keys
+func TestValidate(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		assert.NoError(t, Default().Validate())
+	})
+
+	t.Run("service", func(t *testing.T) {
+		cfg := Default()
+		cfg.Port = "70000"
+		cfg.LogLevel = "verbose"
+		cfg.LogFormat = "xml"
+		cfg.LogRedact = []string{"[email"}
+		cfg.TracesExporter = "zipkin"
+		cfg.TrustedProxies = []string{"10.0.0.0/8", "proxy.internal"}
+		cfg.RateLimit.Key = "cookie"
+
+		err := cfg.Validate()
+		assert.Equal(t, Problems{
+			`app.port (APP_PORT) is "70000", must be a port number between 1 and 65535`,
+			`log.level (LOG_LEVEL) is "verbose", must be one of debug, info, warn, error`,
+			`log.format (LOG_FORMAT) is "xml", must be one of text, json`,
+			`log.redact (LOG_REDACT) has "[email", must be a pattern such as *password*`,
+			`tracing.exporter (OTEL_TRACES_EXPORTER) is "zipkin", must be one of none, otlp, stdout`,
+			`app.trusted_proxies (TRUSTED_PROXIES) has "proxy.internal", must be an IP address or CIDR range`,
+			`rate_limit.key (RATE_LIMIT_KEY) is "cookie", must be one of ip, api_key`,
+		}, err)
+	})
+
+	t.Run("sqlite3", func(t *testing.T) {
+		cfg := Default()
+		cfg.StorageType = SqlLite3
+
+		assert.Equal(t, Problems{"storage.db_file (DB_FILE) is required for sqlite3 storage"}, cfg.Validate())
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		cfg := Default()
+		cfg.StorageType = Postgres
+		cfg.DB.Host = "db.internal"
+		cfg.DB.SSLMode = "sometimes"
+
+		assert.Equal(t, Problems{
+			"db.name (DB_NAME) is required for postgres storage",
+			"db.user (DB_USER) is required for postgres storage",
+			`db.port (DB_PORT) is "", must be a port number between 1 and 65535`,
+			`db.sslmode (DB_SSLMODE) is "sometimes", must be one of disable, allow, prefer, require, verify-ca, verify-full`,
+		}, cfg.Validate())
+	})
+
+	t.Run("business", func(t *testing.T) {
+		cfg := Default()
+		cfg.Business.TimeZone = "Mars/Olympus_Mons"
+		cfg.Business.OpensAt = 18 * time.Hour
+		cfg.Business.Buffer = -time.Minute
+		cfg.TrainerTimezones = "12=Asia/Kolkata,13"
+
+		assert.Equal(t, Problems{
+			`business.timezone (BUSINESS_TIMEZONE) is "Mars/Olympus_Mons", must be an IANA time zone, e.g. Europe/Paris`,
+			`business.trainer_timezones (TRAINER_TIMEZONES) is "12=Asia/Kolkata,13", trainer time zone "13" must look like <trainer_id>=<IANA zone>`,
+			"business.closes_at (BUSINESS_CLOSES_AT) is 17:00, must be after business.opens_at 18:00",
+			"business.buffer (APPOINTMENT_BUFFER) is -1m0s, must not be negative",
+		}, cfg.Validate())
+	})
+
+	t.Run("rate limits", func(t *testing.T) {
+		cfg := Default()
+		cfg.RateLimit.Key = "api_key"
+
+		assert.Equal(t, Problems{
+			"rate_limit.api_keys (RATE_LIMIT_API_KEYS) is required for rate limits by api_key",
+		}, cfg.Validate())
+	})
+}
+
+// TestDiff tests comparing configurations for a reload.
+//
+// It includes the following test cases:
+//
+// * Changed settings, in a fixed order and marked reloadable or not
+// * Secrets, which are masked
+// * Reload, which only takes the reloadable settings
+func TestDiff(t *testing.T) {
+	old := Default()
+	next := Default()
+	next.Port = "9090"
+	next.LogLevel = "debug"
+	next.Business.Weekdays = []time.Weekday{time.Monday, time.Tuesday}
+	next.Business.Buffer = 10 * time.Minute
+	next.DB.Password = "hunter2"
+	next.RateLimit.APIKeys = []string{"k-alpha"}
+
+	assert.Equal(t, []Change{
+		{Key: "app.port", Old: "8080", New: "9090"},
+		{Key: "log.level", Old: "info", New: "debug", Reloadable: true},
+		{Key: "db.password", Old: "", New: "***"},
+		{Key: "business.days", Old: "", New: "mon,tue", Reloadable: true},
+		{Key: "business.buffer", Old: "0s", New: "10m0s", Reloadable: true},
+		{Key: "rate_limit.api_keys", Old: "", New: "***", Reloadable: true},
+	}, Diff(old, next))
+
+	reloaded := old.Reload(next)
+	assert.Equal(t, "8080", reloaded.Port)
+	assert.Empty(t, reloaded.DB.Password)
+	assert.Equal(t, "debug", reloaded.LogLevel)
+	assert.Equal(t, next.Business, reloaded.Business)
+	assert.Equal(t, "info", old.LogLevel, "the original is left as it is")
+}
+
+// clearEnv unsets every setting's environment variable for the test
+func clearEnv(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	for _, s := range settings {
+		t.Setenv(s.env, "")
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
@@ -0,0 +1,44 @@
+package config
+
+import "flag"
+
+// Flags are the configuration flags registered on a command's flag set
+type Flags struct {
+	fs   *flag.FlagSet
+	file *string
+}
+
+// RegisterFlags adds -config, naming the config file, and the flags of the
+// common settings to fs. Pass the result to Load once fs is parsed.
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{fs: fs}
+	f.file = fs.String("config", "", "config file, YAML or TOML (env CONFIG_FILE)")
+	for _, s := range settings {
+		if s.flag != "" {
+			fs.String(s.flag, "", "sets "+s.key+" (env "+s.env+")")
+		}
+	}
+	return f
+}
+
+func (f *Flags) configFile() string {
+	if f == nil {
+		return ""
+	}
+	return *f.file
+}
+
+// visited returns the setting flags given on the command line. Flags left
+// out do not override the other sources, even with an empty default.
+func (f *Flags) visited() []*flag.Flag {
+	if f == nil {
+		return nil
+	}
+	var visited []*flag.Flag
+	f.fs.Visit(func(fl *flag.Flag) {
+		if _, ok := settingsByFlag[fl.Name]; ok {
+			visited = append(visited, fl)
+		}
+	})
+	return visited
+}
@@ -0,0 +1,41 @@
+package config
+
+// Change is a setting whose value differs between two configurations
+type Change struct {
+	Key string
+	Old string
+	New string
+
+	// Reloadable changes take effect on a reload, the others need a restart
+	Reloadable bool
+}
+
+// Diff returns the settings that differ from old to new, in a fixed order.
+// Secrets are masked.
+func Diff(old, new *Config) []Change {
+	var changes []Change
+	for _, s := range settings {
+		before, after := s.get(old), s.get(new)
+		if before == after {
+			continue
+		}
+		if secrets[s.key] {
+			before, after = mask(before), mask(after)
+		}
+		changes = append(changes, Change{Key: s.key, Old: before, New: after, Reloadable: s.reloadable})
+	}
+	return changes
+}
+
+// Reload returns a copy of c with the reloadable settings of next, leaving
+// those that need a restart as they are
+func (c *Config) Reload(next *Config) *Config {
+	reloaded := *c
+	for _, s := range settings {
+		if s.reloadable {
+			// Values come from a loaded config, so they parse
+			_ = s.set(&reloaded, s.get(next))
+		}
+	}
+	return &reloaded
+}
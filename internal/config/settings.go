@@ -0,0 +1,288 @@
+package config
+
+import (
+	"appointment-service/internal/ratelimit"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setting is one configurable value, known by its key in the config file,
+// its environment variable and, optionally, its flag. Reloadable settings
+// take effect on a reload, the others need a restart.
+type setting struct {
+	key        string
+	env        string
+	flag       string
+	reloadable bool
+	field
+}
+
+// field parses a setting's value into a Config, and formats it back
+type field struct {
+	set func(cfg *Config, value string) error
+	get func(cfg *Config) string
+}
+
+// settings lists every setting. Keys are dotted paths into the config file,
+// e.g. "log.level" is level under the log table.
+var settings = []setting{
+	{"app.env", "APP_ENV", "env", false, text(func(c *Config) *string { return (*string)(&c.Environment) })},
+	{"app.port", "APP_PORT", "port", false, text(func(c *Config) *string { return &c.Port })},
+	{"app.trusted_proxies", "TRUSTED_PROXIES", "", false, list(func(c *Config) *[]string { return &c.TrustedProxies })},
+	{"log.level", "LOG_LEVEL", "log-level", true, text(func(c *Config) *string { return &c.LogLevel })},
+	{"log.source", "LOG_SOURCE", "", false, boolean(func(c *Config) *bool { return &c.LogSource })},
+	{"log.format", "LOG_FORMAT", "log-format", false, text(func(c *Config) *string { return &c.LogFormat })},
+	{"log.redact", "LOG_REDACT", "", false, list(func(c *Config) *[]string { return &c.LogRedact })},
+	{"log.sample", "LOG_SAMPLE", "", false, rates(func(c *Config) *map[string]int { return &c.LogSample })},
+	{"storage.type", "STORAGE_TYPE", "storage", false, text(func(c *Config) *string { return (*string)(&c.StorageType) })},
+	{"storage.db_file", "DB_FILE", "db-file", false, text(func(c *Config) *string { return &c.SqlLite3DbFile })},
+	{"storage.auto_migrate", "DB_AUTO_MIGRATE", "", false, boolean(func(c *Config) *bool { return &c.AutoMigrate })},
+	{"db.host", "DB_HOST", "", false, text(func(c *Config) *string { return &c.DB.Host })},
+	{"db.port", "DB_PORT", "", false, text(func(c *Config) *string { return &c.DB.Port })},
+	{"db.name", "DB_NAME", "", false, text(func(c *Config) *string { return &c.DB.Name })},
+	{"db.user", "DB_USER", "", false, text(func(c *Config) *string { return &c.DB.User })},
+	{"db.password", "DB_PASSWORD", "", false, text(func(c *Config) *string { return &c.DB.Password })},
+	{"db.sslmode", "DB_SSLMODE", "", false, text(func(c *Config) *string { return &c.DB.SSLMode })},
+	{"admin.token", "ADMIN_TOKEN", "", false, text(func(c *Config) *string { return &c.AdminToken })},
+	{"tracing.exporter", "OTEL_TRACES_EXPORTER", "", false, text(func(c *Config) *string { return &c.TracesExporter })},
+	{"shutdown.delay", "SHUTDOWN_DELAY", "", false, duration(func(c *Config) *time.Duration { return &c.ShutdownDelay })},
+	{"shutdown.timeout", "SHUTDOWN_TIMEOUT", "", false, duration(func(c *Config) *time.Duration { return &c.ShutdownTimeout })},
+	{"business.timezone", "BUSINESS_TIMEZONE", "", true, text(func(c *Config) *string { return &c.Business.TimeZone })},
+	{"business.opens_at", "BUSINESS_OPENS_AT", "", true, clock(func(c *Config) *time.Duration { return &c.Business.OpensAt })},
+	{"business.closes_at", "BUSINESS_CLOSES_AT", "", true, clock(func(c *Config) *time.Duration { return &c.Business.ClosesAt })},
+	{"business.days", "BUSINESS_DAYS", "", true, weekdays(func(c *Config) *[]time.Weekday { return &c.Business.Weekdays })},
+	{"business.appointment_duration", "APPOINTMENT_DURATION", "", true, duration(func(c *Config) *time.Duration { return &c.Business.AppointmentDuration })},
+	{"business.buffer", "APPOINTMENT_BUFFER", "", true, duration(func(c *Config) *time.Duration { return &c.Business.Buffer })},
+	{"business.trainer_timezones", "TRAINER_TIMEZONES", "", true, text(func(c *Config) *string { return &c.TrainerTimezones })},
+	{"business.max_availability_window", "MAX_AVAILABILITY_WINDOW", "", true, duration(func(c *Config) *time.Duration { return &c.Business.MaxAvailabilityWindow })},
+	{"rate_limit.key", "RATE_LIMIT_KEY", "", false, text(func(c *Config) *string { return &c.RateLimit.Key })},
+	{"rate_limit.api_keys", "RATE_LIMIT_API_KEYS", "", true, list(func(c *Config) *[]string { return &c.RateLimit.APIKeys })},
+	{"rate_limit.routes", "RATE_LIMIT_ROUTES", "", true, routeLimits(func(c *Config) *map[string]ratelimit.Limit { return &c.RateLimit.Routes })},
+	{"rate_limit.bookings_per_user", "RATE_LIMIT_BOOKINGS_PER_USER", "", true, limit(func(c *Config) *ratelimit.Limit { return &c.RateLimit.BookingsPerUser })},
+}
+
+var (
+	settingsByKey  = map[string]setting{}
+	settingsByFlag = map[string]setting{}
+
+	// secrets are never shown, only whether they are set
+	secrets = map[string]bool{"db.password": true, "admin.token": true, "rate_limit.api_keys": true}
+
+	// weekdayNames accepts both full and short names, e.g. "monday" and "mon"
+	weekdayNames = map[string]time.Weekday{}
+)
+
+func init() {
+	for _, s := range settings {
+		settingsByKey[s.key] = s
+		if s.flag != "" {
+			settingsByFlag[s.flag] = s
+		}
+	}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		name := strings.ToLower(day.String())
+		weekdayNames[name] = day
+		weekdayNames[name[:3]] = day
+	}
+}
+
+// describe names the setting by its file key and environment variable, e.g.
+// "log.level (LOG_LEVEL)", whichever source it came from
+func describe(key string) string {
+	return fmt.Sprintf("%s (%s)", key, settingsByKey[key].env)
+}
+
+func text(ptr func(*Config) *string) field {
+	return field{
+		set: func(c *Config, value string) error {
+			*ptr(c) = value
+			return nil
+		},
+		get: func(c *Config) string { return *ptr(c) },
+	}
+}
+
+func boolean(ptr func(*Config) *bool) field {
+	return field{
+		set: func(c *Config, value string) error {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("%q is not a boolean", value)
+			}
+			*ptr(c) = parsed
+			return nil
+		},
+		get: func(c *Config) string { return strconv.FormatBool(*ptr(c)) },
+	}
+}
+
+func duration(ptr func(*Config) *time.Duration) field {
+	return field{
+		set: func(c *Config, value string) error {
+			parsed, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("%q is not a duration, e.g. 30m", value)
+			}
+			*ptr(c) = parsed
+			return nil
+		},
+		get: func(c *Config) string { return ptr(c).String() },
+	}
+}
+
+// clock parses a wall clock time, e.g. "08:00", into its offset from
+// midnight. "24:00" is midnight at the end of the day.
+func clock(ptr func(*Config) *time.Duration) field {
+	return field{
+		set: func(c *Config, value string) error {
+			hours, minutes, ok := strings.Cut(value, ":")
+			h, errH := strconv.Atoi(hours)
+			m, errM := strconv.Atoi(minutes)
+			if !ok || errH != nil || errM != nil || h < 0 || m < 0 || m > 59 || h*60+m > 24*60 {
+				return fmt.Errorf("%q is not a time of day, e.g. 08:00", value)
+			}
+			*ptr(c) = time.Duration(h)*time.Hour + time.Duration(m)*time.Minute
+			return nil
+		},
+		get: func(c *Config) string { return formatClock(*ptr(c)) },
+	}
+}
+
+// weekdays parses a comma separated list of weekdays, e.g. "mon,tue,wed".
+// An empty list means every day.
+func weekdays(ptr func(*Config) *[]time.Weekday) field {
+	return field{
+		set: func(c *Config, value string) error {
+			var days []time.Weekday
+			for _, name := range strings.Split(value, ",") {
+				name = strings.ToLower(strings.TrimSpace(name))
+				if name == "" {
+					continue
+				}
+				day, ok := weekdayNames[name]
+				if !ok {
+					return fmt.Errorf("%q is not a weekday, e.g. mon", name)
+				}
+				days = append(days, day)
+			}
+			*ptr(c) = days
+			return nil
+		},
+		get: func(c *Config) string {
+			if len(*ptr(c)) == 0 {
+				return ""
+			}
+			return formatWeekdays(*ptr(c))
+		},
+	}
+}
+
+// list parses a comma separated list, e.g. "email,phone"
+func list(ptr func(*Config) *[]string) field {
+	return field{
+		set: func(c *Config, value string) error {
+			var items []string
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			*ptr(c) = items
+			return nil
+		},
+		get: func(c *Config) string { return strings.Join(*ptr(c), ",") },
+	}
+}
+
+// rates parses a comma separated list of names and their rate, e.g.
+// "Slot calculation=100,Listed appointments=10"
+func rates(ptr func(*Config) *map[string]int) field {
+	return field{
+		set: func(c *Config, value string) error {
+			parsed := map[string]int{}
+			for _, item := range strings.Split(value, ",") {
+				if strings.TrimSpace(item) == "" {
+					continue
+				}
+				name, rate, ok := strings.Cut(item, "=")
+				n, err := strconv.Atoi(strings.TrimSpace(rate))
+				if !ok || err != nil || n < 1 || strings.TrimSpace(name) == "" {
+					return fmt.Errorf("%q is not a name and rate, e.g. Slot calculation=100", item)
+				}
+				parsed[strings.TrimSpace(name)] = n
+			}
+			*ptr(c) = parsed
+			return nil
+		},
+		get: func(c *Config) string { return formatRates(*ptr(c)) },
+	}
+}
+
+// limit parses a rate limit, e.g. "10/s". Empty is no limit.
+func limit(ptr func(*Config) *ratelimit.Limit) field {
+	return field{
+		set: func(c *Config, value string) error {
+			parsed, err := ratelimit.ParseLimit(value)
+			if err != nil {
+				return err
+			}
+			*ptr(c) = parsed
+			return nil
+		},
+		get: func(c *Config) string { return ptr(c).String() },
+	}
+}
+
+// routeLimits parses a comma separated list of routes and their rate limit,
+// e.g. "GET /api/v1/appointments/:id=10/s,POST /api/v1/appointments=60/m"
+func routeLimits(ptr func(*Config) *map[string]ratelimit.Limit) field {
+	return field{
+		set: func(c *Config, value string) error {
+			parsed := map[string]ratelimit.Limit{}
+			for _, item := range strings.Split(value, ",") {
+				if strings.TrimSpace(item) == "" {
+					continue
+				}
+				i := strings.LastIndex(item, "=")
+				method, path, ok := strings.Cut(strings.TrimSpace(item[:max(i, 0)]), " ")
+				path = strings.TrimSpace(path)
+				if i < 0 || !ok || method == "" || !strings.HasPrefix(path, "/") {
+					return fmt.Errorf("%q is not a route and rate limit, e.g. POST /api/v1/appointments=60/m", item)
+				}
+				routeLimit, err := ratelimit.ParseLimit(item[i+1:])
+				if err != nil {
+					return err
+				}
+				parsed[strings.ToUpper(method)+" "+path] = routeLimit
+			}
+			*ptr(c) = parsed
+			return nil
+		},
+		get: func(c *Config) string { return formatRouteLimits(*ptr(c)) },
+	}
+}
+
+// formatRouteLimits is the inverse of routeLimits, in route order
+func formatRouteLimits(limits map[string]ratelimit.Limit) string {
+	items := make([]string, 0, len(limits))
+	for _, route := range sortedKeys(limits) {
+		items = append(items, route+"="+limits[route].String())
+	}
+	return strings.Join(items, ",")
+}
+
+// formatRates is the inverse of rates, in name order
+func formatRates(rates map[string]int) string {
+	items := make([]string, 0, len(rates))
+	for _, name := range sortedKeys(rates) {
+		items = append(items, fmt.Sprintf("%s=%d", name, rates[name]))
+	}
+	return strings.Join(items, ",")
+}
+
+// formatClock is the inverse of clock
+func formatClock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
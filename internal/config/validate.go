@@ -0,0 +1,137 @@
+package config
+
+import (
+	"appointment-service/internal/model"
+	"fmt"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Problems lists everything wrong with a configuration, so it can all be
+// fixed in one go
+type Problems []string
+
+func (p Problems) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(p, "\n  - ")
+}
+
+// check appends err, prefixed by the formatted source, unless it is nil
+func (p Problems) check(err error, format string, args ...any) Problems {
+	if err == nil {
+		return p
+	}
+	return append(p, fmt.Sprintf(format, args...)+": "+err.Error())
+}
+
+// Validate reports every problem with the configuration, as Problems, or nil
+// if there are none
+func (c *Config) Validate() error {
+	var p Problems
+	problem := func(key, format string, args ...any) {
+		p = append(p, describe(key)+" "+fmt.Sprintf(format, args...))
+	}
+	oneOf := func(key, value string, allowed ...string) {
+		for _, a := range allowed {
+			if strings.EqualFold(value, a) {
+				return
+			}
+		}
+		problem(key, "is %q, must be one of %s", value, strings.Join(allowed, ", "))
+	}
+
+	// Service
+	// -------
+	oneOf("app.env", string(c.Environment), string(Development), string(Production), string(Test))
+	if !isPort(c.Port) {
+		problem("app.port", "is %q, must be a port number between 1 and 65535", c.Port)
+	}
+	oneOf("log.level", c.LogLevel, "debug", "info", "warn", "error")
+	oneOf("log.format", c.LogFormat, "text", "json")
+	for _, pattern := range c.LogRedact {
+		if _, err := path.Match(pattern, ""); err != nil {
+			problem("log.redact", "has %q, must be a pattern such as *password*", pattern)
+		}
+	}
+	oneOf("tracing.exporter", c.TracesExporter, "none", "otlp", "stdout")
+	for _, proxy := range c.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			problem("app.trusted_proxies", "has %q, must be an IP address or CIDR range", proxy)
+		}
+	}
+	if c.ShutdownDelay < 0 {
+		problem("shutdown.delay", "is %s, must not be negative", c.ShutdownDelay)
+	}
+	if c.ShutdownTimeout <= 0 {
+		problem("shutdown.timeout", "is %s, must be positive", c.ShutdownTimeout)
+	}
+
+	// Storage
+	// -------
+	switch c.StorageType {
+	case Memory:
+	case SqlLite3:
+		if c.SqlLite3DbFile == "" {
+			problem("storage.db_file", "is required for sqlite3 storage")
+		}
+	case Postgres:
+		for _, required := range []struct{ key, value string }{
+			{"db.host", c.DB.Host},
+			{"db.name", c.DB.Name},
+			{"db.user", c.DB.User},
+		} {
+			if required.value == "" {
+				problem(required.key, "is required for postgres storage")
+			}
+		}
+		if !isPort(c.DB.Port) {
+			problem("db.port", "is %q, must be a port number between 1 and 65535", c.DB.Port)
+		}
+		oneOf("db.sslmode", c.DB.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+	default:
+		oneOf("storage.type", string(c.StorageType), string(Memory), string(SqlLite3), string(Postgres))
+	}
+
+	// Business rules
+	// --------------
+	b := c.Business
+	if _, err := time.LoadLocation(b.TimeZone); err != nil || b.TimeZone == "" {
+		problem("business.timezone", "is %q, must be an IANA time zone, e.g. Europe/Paris", b.TimeZone)
+	}
+	if _, err := model.ParseTrainerTimezones(c.TrainerTimezones); err != nil {
+		problem("business.trainer_timezones", "is %q, %v", c.TrainerTimezones, err)
+	}
+	if b.OpensAt >= b.ClosesAt {
+		problem("business.closes_at", "is %s, must be after business.opens_at %s", formatClock(b.ClosesAt), formatClock(b.OpensAt))
+	}
+	if b.AppointmentDuration <= 0 {
+		problem("business.appointment_duration", "is %s, must be positive", b.AppointmentDuration)
+	} else if b.OpensAt < b.ClosesAt && b.AppointmentDuration > b.ClosesAt-b.OpensAt {
+		problem("business.appointment_duration", "is %s, longer than the business day", b.AppointmentDuration)
+	}
+	if b.Buffer < 0 {
+		problem("business.buffer", "is %s, must not be negative", b.Buffer)
+	}
+	if b.MaxAvailabilityWindow < 0 {
+		problem("business.max_availability_window", "is %s, must not be negative", b.MaxAvailabilityWindow)
+	}
+
+	// Rate limits
+	// -----------
+	oneOf("rate_limit.key", c.RateLimit.Key, "ip", "api_key")
+	if strings.EqualFold(c.RateLimit.Key, "api_key") && len(c.RateLimit.APIKeys) == 0 {
+		problem("rate_limit.api_keys", "is required for rate limits by api_key")
+	}
+
+	if len(p) == 0 {
+		return nil
+	}
+	return p
+}
+
+func isPort(s string) bool {
+	port, err := strconv.Atoi(s)
+	return err == nil && port >= 1 && port <= 65535
+}
@@ -0,0 +1,11 @@
+package dto
+
+// LogLevelRequest changes the service's log level
+type LogLevelRequest struct {
+	Level string `json:"level" binding:"required"`
+}
+
+// LogLevelResponse is the service's log level, e.g. "debug"
+type LogLevelResponse struct {
+	Level string `json:"level"`
+}
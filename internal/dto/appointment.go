@@ -13,8 +13,45 @@ type CreateAppointmentRequest struct {
 	UserId    int64     `json:"user_id" binding:"required,gt=0"`
 }
 
+// ListOptionsRequest holds the time range, status filter, sort order and paging
+// options shared by the listing endpoints.
+// Status may be repeated or comma separated, e.g. status=scheduled,completed
+type ListOptionsRequest struct {
+	StartsAt time.Time `form:"starts_at" time_format:"2006-01-02T15:04:05Z07:00"`
+	EndsAt   time.Time `form:"ends_at" time_format:"2006-01-02T15:04:05Z07:00"`
+	Status   []string  `form:"status"`
+	Sort     string    `form:"sort"`
+	Limit    int       `form:"limit"`
+	Cursor   string    `form:"cursor"`
+}
+
+// ListAppointmentsRequest binds the trainer from the URL and the optional
+// user filter and list options from the query string.
 type ListAppointmentsRequest struct {
 	TrainerId int64 `uri:"trainer_id" binding:"required"`
+	UserId    int64 `form:"user_id"`
+	ListOptionsRequest
+}
+
+// ListUserAppointmentsRequest binds the user (client) from the URL and the
+// optional trainer filter and list options from the query string.
+type ListUserAppointmentsRequest struct {
+	UserId    int64 `uri:"user_id" binding:"required"`
+	TrainerId int64 `form:"trainer_id"`
+	ListOptionsRequest
+}
+
+type AppointmentIdRequest struct {
+	Id int64 `uri:"id" binding:"required,gt=0"`
+}
+
+type RescheduleAppointmentRequest struct {
+	StartTime time.Time `json:"start_time" binding:"required" time_format:"2006-01-02T15:04:05Z"`
+	EndTime   time.Time `json:"end_time" binding:"required,gtfield=StartTime" time_format:"2006-01-02T15:04:05Z"`
+}
+
+type UpdateStatusRequest struct {
+	Status string `json:"status" binding:"required"`
 }
 
 type GetAvailabilityRequest struct {
@@ -23,6 +60,14 @@ type GetAvailabilityRequest struct {
 	EndsAt    time.Time `form:"ends_at" time_format:"2006-01-02T15:04:05Z07:00"`
 }
 
+// GetCalendarRequest binds the trainer from the URL and the inclusive local
+// date range from the query string, e.g. from=2025-06-01&to=2025-06-30
+type GetCalendarRequest struct {
+	TrainerId int64     `uri:"trainer_id"`
+	From      time.Time `form:"from" time_format:"2006-01-02"`
+	To        time.Time `form:"to" time_format:"2006-01-02"`
+}
+
 // Response DTO Types
 type AppointmentResponse struct {
 	Id        int64     `json:"id"`
@@ -30,11 +75,63 @@ type AppointmentResponse struct {
 	StartTime time.Time `json:"start_time" time_format:"2006-01-02T15:04:05Z"`
 	EndTime   time.Time `json:"end_time" time_format:"2006-01-02T15:04:05Z"`
 	UserId    int64     `json:"user_id"`
+	Status    string    `json:"status"`
+	Version   int64     `json:"version"`
+	*LocalTimes
+}
+
+type ListAppointmentsResponse struct {
+	Appointments []AppointmentResponse `json:"appointments"`
+	NextCursor   string                `json:"next_cursor,omitempty"`
 }
 
 type AvailabilityResponse struct {
 	StartTime time.Time `json:"start_time"`
 	EndTime   time.Time `json:"end_time"`
+	*LocalTimes
+}
+
+// CalendarResponse summarises availability per local date in TimeZone
+type CalendarResponse struct {
+	TimeZone string                `json:"time_zone"`
+	Days     []CalendarDayResponse `json:"days"`
+}
+
+// CalendarDayResponse summarises one local date. The first and last open slot
+// start times are local with an offset and omitted when no slot is open.
+type CalendarDayResponse struct {
+	Date          string `json:"date"`
+	OpenSlots     int    `json:"open_slots"`
+	FirstOpenSlot string `json:"first_open_slot,omitempty"`
+	LastOpenSlot  string `json:"last_open_slot,omitempty"`
+	Closed        bool   `json:"closed"`
+	FullyBooked   bool   `json:"fully_booked"`
+}
+
+// LocalTimes presents a time range in the time zone the caller asked for.
+// It is only included when a zone was requested, the UTC start_time and
+// end_time fields are always present.
+type LocalTimes struct {
+	LocalStartTime string `json:"local_start_time"`
+	LocalEndTime   string `json:"local_end_time"`
+	LocalDate      string `json:"local_date"`
+	TimeZone       string `json:"time_zone"`
+}
+
+// NewLocalTimes renders start and end in loc, e.g. 2025-06-01T11:00:00-07:00,
+// along with the local date the range starts on. A nil loc yields nil.
+func NewLocalTimes(start, end time.Time, loc *time.Location) *LocalTimes {
+	if loc == nil {
+		return nil
+	}
+
+	localStart := start.In(loc)
+	return &LocalTimes{
+		LocalStartTime: localStart.Format(time.RFC3339),
+		LocalEndTime:   end.In(loc).Format(time.RFC3339),
+		LocalDate:      localStart.Format(time.DateOnly),
+		TimeZone:       loc.String(),
+	}
 }
 
 // Custom marshaler for AvailabilityResponse to ensure UTC output
@@ -42,9 +139,11 @@ func (r AvailabilityResponse) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&struct {
 		StartTime string `json:"start_time"`
 		EndTime   string `json:"end_time"`
+		*LocalTimes
 	}{
-		StartTime: r.StartTime.UTC().Format(time.RFC3339),
-		EndTime:   r.EndTime.UTC().Format(time.RFC3339),
+		StartTime:  r.StartTime.UTC().Format(time.RFC3339),
+		EndTime:    r.EndTime.UTC().Format(time.RFC3339),
+		LocalTimes: r.LocalTimes,
 	})
 }
 
@@ -1,6 +1,12 @@
 package dto
 
-import "appointment-service/internal/model"
+import (
+	"appointment-service/internal/errors"
+	"appointment-service/internal/model"
+	"fmt"
+	"strings"
+	"time"
+)
 
 func ToAppointmentModel(r *CreateAppointmentRequest) model.Appointment {
 	return model.Appointment{
@@ -11,29 +17,120 @@ func ToAppointmentModel(r *CreateAppointmentRequest) model.Appointment {
 	}
 }
 
-func ToAppointmentResponse(m *model.Appointment) AppointmentResponse {
+// ToAppointmentResponse converts a model appointment to a response DTO,
+// adding local times when loc is not nil
+func ToAppointmentResponse(m *model.Appointment, loc *time.Location) AppointmentResponse {
 	return AppointmentResponse{
-		Id:        m.Id,
-		StartTime: m.StartTime,
-		EndTime:   m.EndTime,
-		TrainerId: m.TrainerId,
-		UserId:    m.UserId,
+		Id:         m.Id,
+		StartTime:  m.StartTime,
+		EndTime:    m.EndTime,
+		TrainerId:  m.TrainerId,
+		UserId:     m.UserId,
+		Status:     string(m.Status),
+		Version:    m.Version,
+		LocalTimes: NewLocalTimes(m.StartTime, m.EndTime, loc),
 	}
 }
 
-// ToListAppointmentsResponse converts model appointments to a response DTO
-func ToListAppointmentsResponse(appointments []model.Appointment) []AppointmentResponse {
-	response := make([]AppointmentResponse, len(appointments))
+// ToAppointmentQuery converts a trainer list request into a model query
+func ToAppointmentQuery(r *ListAppointmentsRequest) (model.AppointmentQuery, error) {
+	return toAppointmentQuery(r.TrainerId, r.UserId, &r.ListOptionsRequest)
+}
+
+// ToUserAppointmentQuery converts a user list request into a model query for
+// ListClientAppointments, which takes the user separately
+func ToUserAppointmentQuery(r *ListUserAppointmentsRequest) (model.AppointmentQuery, error) {
+	return toAppointmentQuery(r.TrainerId, 0, &r.ListOptionsRequest)
+}
+
+// toAppointmentQuery builds a model query, parsing the statuses, sort order
+// and cursor. Paging defaults are applied by the service.
+func toAppointmentQuery(trainerId, userId int64, r *ListOptionsRequest) (model.AppointmentQuery, error) {
+	query := model.AppointmentQuery{
+		TrainerId: trainerId,
+		UserId:    userId,
+		StartsAt:  r.StartsAt.UTC(),
+		EndsAt:    r.EndsAt.UTC(),
+		Sort:      model.SortOrder(strings.ToLower(r.Sort)),
+		Limit:     r.Limit,
+	}
+
+	for _, param := range r.Status {
+		for _, raw := range strings.Split(param, ",") {
+			status := model.AppointmentStatus(strings.TrimSpace(raw))
+			if !status.IsValid() {
+				return query, errors.ValidationError(fmt.Sprintf("unknown appointment status %q", raw)).
+					WithCode(errors.CodeUnknownStatus).
+					WithDetail("field", "status")
+			}
+			query.Statuses = append(query.Statuses, status)
+		}
+	}
+
+	if r.Cursor != "" {
+		cursor, err := model.DecodeCursor(r.Cursor)
+		if err != nil {
+			return query, errors.ValidationError("cursor is malformed").
+				WithCode(errors.CodeInvalidCursor).
+				WithDetail("field", "cursor")
+		}
+		query.After = cursor
+	}
+
+	return query, nil
+}
+
+// ToListAppointmentsResponse converts a page of model appointments to a response DTO,
+// adding local times when loc is not nil
+func ToListAppointmentsResponse(page *model.AppointmentPage, loc *time.Location) ListAppointmentsResponse {
+	appointments := make([]AppointmentResponse, len(page.Appointments))
 
-	for i, apt := range appointments {
-		response[i] = AppointmentResponse{
-			Id:        apt.Id,
-			StartTime: apt.StartTime,
-			EndTime:   apt.EndTime,
-			TrainerId: apt.TrainerId,
-			UserId:    apt.UserId,
+	for i := range page.Appointments {
+		appointments[i] = ToAppointmentResponse(&page.Appointments[i], loc)
+	}
+
+	return ListAppointmentsResponse{
+		Appointments: appointments,
+		NextCursor:   page.NextCursor,
+	}
+}
+
+// ToAvailabilityResponse converts available slots to response DTOs in UTC,
+// adding local times when loc is not nil
+func ToAvailabilityResponse(slots []model.TimeSlot, loc *time.Location) []AvailabilityResponse {
+	response := make([]AvailabilityResponse, len(slots))
+
+	for i, slot := range slots {
+		response[i] = AvailabilityResponse{
+			StartTime:  slot.StartTime.UTC(),
+			EndTime:    slot.EndTime.UTC(),
+			LocalTimes: NewLocalTimes(slot.StartTime, slot.EndTime, loc),
 		}
 	}
 
 	return response
 }
+
+// ToCalendarResponse converts a model calendar to a response DTO, rendering
+// dates and times in the calendar's time zone
+func ToCalendarResponse(calendar *model.Calendar) CalendarResponse {
+	days := make([]CalendarDayResponse, len(calendar.Days))
+
+	for i, day := range calendar.Days {
+		days[i] = CalendarDayResponse{
+			Date:        day.Date.In(calendar.Location).Format(time.DateOnly),
+			OpenSlots:   day.OpenSlots,
+			Closed:      day.Closed,
+			FullyBooked: day.FullyBooked,
+		}
+		if day.OpenSlots > 0 {
+			days[i].FirstOpenSlot = day.FirstOpen.In(calendar.Location).Format(time.RFC3339)
+			days[i].LastOpenSlot = day.LastOpen.In(calendar.Location).Format(time.RFC3339)
+		}
+	}
+
+	return CalendarResponse{
+		TimeZone: calendar.Location.String(),
+		Days:     days,
+	}
+}
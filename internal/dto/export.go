@@ -0,0 +1,201 @@
+package dto
+
+import (
+	"appointment-service/internal/errors"
+	"appointment-service/internal/model"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// ExportFormat is the file format of an export
+type ExportFormat string
+
+const (
+	ExportCSV     ExportFormat = "csv"
+	ExportNDJSON  ExportFormat = "ndjson"
+	ExportParquet ExportFormat = "parquet"
+)
+
+// exportRowGroupSize bounds the rows a Parquet export buffers before writing
+// them out as a row group, which bounds its memory use
+const exportRowGroupSize = 10000
+
+// ExportRequest binds the format, filters and order of an export from the
+// query string. Status may be repeated or comma separated, as for listings.
+type ExportRequest struct {
+	Format    string    `form:"format"`
+	TrainerId int64     `form:"trainer_id" binding:"gte=0"`
+	UserId    int64     `form:"user_id" binding:"gte=0"`
+	StartsAt  time.Time `form:"starts_at" time_format:"2006-01-02T15:04:05Z07:00"`
+	EndsAt    time.Time `form:"ends_at" time_format:"2006-01-02T15:04:05Z07:00"`
+	Status    []string  `form:"status"`
+	Sort      string    `form:"sort"`
+}
+
+// ToExportQuery converts an export request into a model query
+func ToExportQuery(r *ExportRequest) (model.AppointmentQuery, error) {
+	return toAppointmentQuery(r.TrainerId, r.UserId, &ListOptionsRequest{
+		StartsAt: r.StartsAt,
+		EndsAt:   r.EndsAt,
+		Status:   r.Status,
+		Sort:     r.Sort,
+	})
+}
+
+// ParseExportFormat checks an export format, defaulting to CSV
+func ParseExportFormat(format string) (ExportFormat, error) {
+	switch f := ExportFormat(strings.ToLower(format)); f {
+	case "":
+		return ExportCSV, nil
+	case ExportCSV, ExportNDJSON, ExportParquet:
+		return f, nil
+	default:
+		return "", errors.ValidationError(fmt.Sprintf("format must be %q, %q or %q", ExportCSV, ExportNDJSON, ExportParquet)).
+			WithCode(errors.CodeFieldInvalid).
+			WithDetail("field", "format")
+	}
+}
+
+// ContentType is the media type of an export in the format
+func (f ExportFormat) ContentType() string {
+	switch f {
+	case ExportNDJSON:
+		return "application/x-ndjson"
+	case ExportParquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "text/csv"
+	}
+}
+
+// ExportWriter writes appointments one at a time. Close writes whatever is
+// still buffered, and for Parquet the file footer; it does not close the
+// underlying writer.
+type ExportWriter interface {
+	Write(apt model.Appointment) error
+	Close() error
+}
+
+// NewExportWriter returns a writer for the format. Every format has the same
+// columns, named like the API's fields, with times in UTC. NDJSON lines are
+// the API's appointment responses, which import reads back.
+func NewExportWriter(w io.Writer, format ExportFormat) ExportWriter {
+	switch format {
+	case ExportNDJSON:
+		buffered := bufio.NewWriter(w)
+		return &ndjsonExportWriter{buffered: buffered, enc: json.NewEncoder(buffered)}
+	case ExportParquet:
+		return &parquetExportWriter{
+			w:    parquet.NewGenericWriter[exportRecord](w, parquet.MaxRowsPerRowGroup(exportRowGroupSize)),
+			rows: make([]exportRecord, 0, exportRowGroupSize),
+		}
+	default:
+		return &csvExportWriter{w: csv.NewWriter(w)}
+	}
+}
+
+// exportRecord is one exported appointment, as a Parquet row
+type exportRecord struct {
+	Id        int64     `parquet:"id"`
+	TrainerId int64     `parquet:"trainer_id"`
+	UserId    int64     `parquet:"user_id"`
+	StartTime time.Time `parquet:"start_time,timestamp(millisecond)"`
+	EndTime   time.Time `parquet:"end_time,timestamp(millisecond)"`
+	Status    string    `parquet:"status,dict"`
+	Version   int64     `parquet:"version"`
+}
+
+var exportColumns = []string{"id", "trainer_id", "user_id", "start_time", "end_time", "status", "version"}
+
+type csvExportWriter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func (e *csvExportWriter) Write(apt model.Appointment) error {
+	if !e.headerWritten {
+		e.headerWritten = true
+		if err := e.w.Write(exportColumns); err != nil {
+			return err
+		}
+	}
+	return e.w.Write([]string{
+		strconv.FormatInt(apt.Id, 10),
+		strconv.FormatInt(apt.TrainerId, 10),
+		strconv.FormatInt(apt.UserId, 10),
+		apt.StartTime.UTC().Format(time.RFC3339),
+		apt.EndTime.UTC().Format(time.RFC3339),
+		string(apt.Status),
+		strconv.FormatInt(apt.Version, 10),
+	})
+}
+
+// Close writes the header too if no appointment was exported, so an empty
+// export is still a valid CSV file
+func (e *csvExportWriter) Close() error {
+	if !e.headerWritten {
+		e.headerWritten = true
+		if err := e.w.Write(exportColumns); err != nil {
+			return err
+		}
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type ndjsonExportWriter struct {
+	buffered *bufio.Writer
+	enc      *json.Encoder
+}
+
+func (e *ndjsonExportWriter) Write(apt model.Appointment) error {
+	return e.enc.Encode(ToAppointmentResponse(&apt, nil))
+}
+
+func (e *ndjsonExportWriter) Close() error {
+	return e.buffered.Flush()
+}
+
+type parquetExportWriter struct {
+	w    *parquet.GenericWriter[exportRecord]
+	rows []exportRecord
+}
+
+func (e *parquetExportWriter) Write(apt model.Appointment) error {
+	e.rows = append(e.rows, exportRecord{
+		Id:        apt.Id,
+		TrainerId: apt.TrainerId,
+		UserId:    apt.UserId,
+		StartTime: apt.StartTime.UTC(),
+		EndTime:   apt.EndTime.UTC(),
+		Status:    string(apt.Status),
+		Version:   apt.Version,
+	})
+	if len(e.rows) == cap(e.rows) {
+		return e.flush()
+	}
+	return nil
+}
+
+func (e *parquetExportWriter) flush() error {
+	if _, err := e.w.Write(e.rows); err != nil {
+		return err
+	}
+	e.rows = e.rows[:0]
+	return nil
+}
+
+func (e *parquetExportWriter) Close() error {
+	if err := e.flush(); err != nil {
+		return err
+	}
+	return e.w.Close()
+}
@@ -0,0 +1,96 @@
+package dto
+
+import (
+	"appointment-service/internal/model"
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExportWriter tests writing appointments in every export format.
+//
+// It includes the following test cases:
+//
+// * CSV has a header and one UTC row per appointment, also when empty
+// * NDJSON lines read back as import rows
+// * Parquet spanning several row groups reads back every appointment
+// * Unknown formats are rejected
+func TestExportWriter(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	start := time.Date(2025, 6, 2, 12, 0, 0, 0, ny)
+	appointments := func(n int) []model.Appointment {
+		apts := make([]model.Appointment, n)
+		for i := range apts {
+			apts[i] = model.Appointment{
+				Id:        int64(i + 1),
+				TrainerId: 1,
+				UserId:    2,
+				StartTime: start.Add(time.Duration(i) * time.Hour),
+				EndTime:   start.Add(time.Duration(i)*time.Hour + 30*time.Minute),
+				Status:    model.StatusScheduled,
+				Version:   1,
+			}
+		}
+		return apts
+	}
+	export := func(t *testing.T, format ExportFormat, apts []model.Appointment) []byte {
+		var buf bytes.Buffer
+		w := NewExportWriter(&buf, format)
+		for _, apt := range apts {
+			require.NoError(t, w.Write(apt))
+		}
+		require.NoError(t, w.Close())
+		return buf.Bytes()
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		records, err := csv.NewReader(bytes.NewReader(export(t, ExportCSV, appointments(2)))).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 3)
+		assert.Equal(t, exportColumns, records[0])
+		assert.Equal(t, []string{"1", "1", "2", "2025-06-02T16:00:00Z", "2025-06-02T16:30:00Z", "scheduled", "1"}, records[1])
+
+		assert.Equal(t, strings.Join(exportColumns, ",")+"\n", string(export(t, ExportCSV, nil)))
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		rows, err := ParseImport(bytes.NewReader(export(t, ExportNDJSON, appointments(2))), ImportNDJSON)
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+		assert.NoError(t, rows[1].Err)
+		assert.True(t, start.Add(time.Hour).Equal(rows[1].Appointment.StartTime))
+	})
+
+	t.Run("parquet", func(t *testing.T) {
+		apts := appointments(exportRowGroupSize + 5)
+		data := export(t, ExportParquet, apts)
+
+		file, err := parquet.OpenFile(bytes.NewReader(data), int64(len(data)))
+		require.NoError(t, err)
+		assert.Len(t, file.RowGroups(), 2)
+
+		records, err := parquet.Read[exportRecord](bytes.NewReader(data), int64(len(data)))
+		require.NoError(t, err)
+		require.Len(t, records, len(apts))
+		last := records[len(records)-1]
+		assert.Equal(t, apts[len(apts)-1].Id, last.Id)
+		assert.True(t, apts[len(apts)-1].StartTime.Equal(last.StartTime))
+		assert.Equal(t, "scheduled", last.Status)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := ParseExportFormat("xlsx")
+		assert.Error(t, err)
+
+		format, err := ParseExportFormat("")
+		require.NoError(t, err)
+		assert.Equal(t, ExportCSV, format)
+	})
+}
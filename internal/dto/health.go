@@ -0,0 +1,53 @@
+package dto
+
+import (
+	"appointment-service/internal/health"
+)
+
+// Probe statuses
+const (
+	HealthOK       = "ok"
+	HealthFailing  = "failing"
+	HealthDraining = "draining"
+)
+
+// HealthResponse is the body of the liveness probe
+type HealthResponse struct {
+	Status string `json:"status"`
+}
+
+// ReadinessResponse is the body of the readiness probe: the overall status
+// and that of each check. Check errors are only logged, as they may reveal
+// internals such as database paths.
+type ReadinessResponse struct {
+	Status string                   `json:"status"`
+	Checks map[string]CheckResponse `json:"checks,omitempty"`
+}
+
+// CheckResponse is the outcome of one readiness check
+type CheckResponse struct {
+	Status     string `json:"status"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// ToReadinessResponse converts a readiness report to its response
+func ToReadinessResponse(report health.Report) ReadinessResponse {
+	response := ReadinessResponse{Status: HealthOK}
+	switch {
+	case report.Draining:
+		response.Status = HealthDraining
+		return response
+	case !report.Ready:
+		response.Status = HealthFailing
+	}
+
+	response.Checks = make(map[string]CheckResponse, len(report.Results))
+	for _, result := range report.Results {
+		status := HealthOK
+		if result.Err != nil {
+			status = HealthFailing
+		}
+		response.Checks[result.Name] = CheckResponse{Status: status, DurationMs: result.Duration.Milliseconds()}
+	}
+	return response
+}
@@ -0,0 +1,326 @@
+package dto
+
+import (
+	"appointment-service/internal/errors"
+	"appointment-service/internal/model"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ImportFormat is the file format of a bulk import
+type ImportFormat string
+
+const (
+	ImportCSV    ImportFormat = "csv"
+	ImportNDJSON ImportFormat = "ndjson"
+)
+
+// ImportRequest binds the import options from the query string, e.g.
+// dry_run=true&mode=partial&batch_size=200. Format overrides the format
+// implied by the Content-Type.
+type ImportRequest struct {
+	Format    string `form:"format"`
+	DryRun    bool   `form:"dry_run"`
+	Mode      string `form:"mode"`
+	BatchSize int    `form:"batch_size"`
+}
+
+// ToImportOptions converts the request to service options
+func (r *ImportRequest) ToImportOptions() model.ImportOptions {
+	return model.ImportOptions{
+		Mode:      model.ImportMode(r.Mode),
+		DryRun:    r.DryRun,
+		BatchSize: r.BatchSize,
+	}
+}
+
+// importRecord is one row of an import file, in either format. CSV files
+// have a header row naming the columns, in any order.
+type importRecord struct {
+	TrainerId int64     `json:"trainer_id"`
+	UserId    int64     `json:"user_id"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Status    string    `json:"status"`
+}
+
+var (
+	importColumns         = []string{"trainer_id", "user_id", "start_time", "end_time"}
+	optionalImportColumns = []string{"status"}
+)
+
+// ParseImport reads every row of an import file. Rows that cannot be parsed
+// are returned with their error, so they are reported rather than failing
+// the whole file. Only a file that cannot be read at all, or that has more
+// than model.MaxImportRows rows or an NDJSON line longer than
+// model.MaxImportLineBytes, is an error. A body cut off by
+// http.MaxBytesReader is reported as too large.
+func ParseImport(r io.Reader, format ImportFormat) ([]model.ImportRow, error) {
+	switch format {
+	case ImportCSV:
+		return parseImportCSV(r)
+	case ImportNDJSON:
+		return parseImportNDJSON(r)
+	default:
+		return nil, errors.ValidationError(fmt.Sprintf("import format must be %q or %q", ImportCSV, ImportNDJSON)).
+			WithCode(errors.CodeInvalidImportFile).
+			WithDetail("field", "format")
+	}
+}
+
+func parseImportCSV(r io.Reader) ([]model.ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if tooLarge := importTooLarge(err); tooLarge != nil {
+			return nil, tooLarge
+		}
+		return nil, invalidImportFile("CSV header is missing or malformed", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	var missing []string
+	for _, name := range importColumns {
+		if _, ok := columns[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, invalidImportFile(fmt.Sprintf("CSV header is missing columns %s", strings.Join(missing, ", ")), nil).
+			WithDetail("missing_columns", missing)
+	}
+
+	var rows []model.ImportRow
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if len(rows) == model.MaxImportRows {
+			return nil, tooManyImportRows()
+		}
+
+		if err != nil {
+			if tooLarge := importTooLarge(err); tooLarge != nil {
+				return nil, tooLarge
+			}
+			var parseErr *csv.ParseError
+			if !stderrors.As(err, &parseErr) {
+				return nil, invalidImportFile("reading CSV", err)
+			}
+			rows = append(rows, model.ImportRow{Line: parseErr.StartLine, Err: malformedRow(err)})
+			continue
+		}
+		// FieldPos is only valid after a successful Read
+		line, _ := reader.FieldPos(0)
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(fields) {
+				return strings.TrimSpace(fields[i])
+			}
+			return ""
+		}
+
+		var record importRecord
+		var violations []errors.Violation
+		for _, name := range append(importColumns, optionalImportColumns...) {
+			if err := record.set(name, field(name)); err != nil {
+				violations = append(violations, errors.Violation{Field: name, Code: errors.CodeFieldInvalid, Message: err.Error()})
+			}
+		}
+		rows = append(rows, record.toImportRow(line, violations))
+	}
+}
+
+// set parses one CSV field into the record. Empty fields are left zero.
+func (rec *importRecord) set(name, value string) error {
+	if value == "" {
+		return nil
+	}
+
+	var err error
+	switch name {
+	case "trainer_id":
+		rec.TrainerId, err = strconv.ParseInt(value, 10, 64)
+	case "user_id":
+		rec.UserId, err = strconv.ParseInt(value, 10, 64)
+	case "start_time":
+		rec.StartTime, err = time.Parse(time.RFC3339, value)
+	case "end_time":
+		rec.EndTime, err = time.Parse(time.RFC3339, value)
+	case "status":
+		rec.Status = value
+	}
+	if err != nil {
+		return fmt.Errorf("%s %q is not valid", name, value)
+	}
+	return nil
+}
+
+func parseImportNDJSON(r io.Reader) ([]model.ImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), model.MaxImportLineBytes)
+
+	var rows []model.ImportRow
+	line := 1
+	for ; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		if len(rows) == model.MaxImportRows {
+			return nil, tooManyImportRows()
+		}
+
+		var record importRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			rows = append(rows, model.ImportRow{Line: line, Err: malformedRow(err)})
+			continue
+		}
+		rows = append(rows, record.toImportRow(line, nil))
+	}
+	if err := scanner.Err(); err != nil {
+		if tooLarge := importTooLarge(err); tooLarge != nil {
+			return nil, tooLarge
+		}
+		if stderrors.Is(err, bufio.ErrTooLong) {
+			return nil, invalidImportFile(fmt.Sprintf("line %d is longer than %d bytes", line, model.MaxImportLineBytes), err).
+				WithDetail("line", line).
+				WithDetail("max_line_bytes", model.MaxImportLineBytes)
+		}
+		return nil, invalidImportFile("reading NDJSON", err)
+	}
+	return rows, nil
+}
+
+// toImportRow checks the required fields and status, adding any problems to
+// the violations already found while parsing
+func (rec *importRecord) toImportRow(line int, violations []errors.Violation) model.ImportRow {
+	required := func(field string, missing bool) {
+		if missing {
+			violations = append(violations, errors.Violation{Field: field, Code: errors.CodeFieldRequired, Message: field + " is required"})
+		}
+	}
+	required("trainer_id", rec.TrainerId <= 0)
+	required("user_id", rec.UserId <= 0)
+	required("start_time", rec.StartTime.IsZero())
+	required("end_time", rec.EndTime.IsZero())
+
+	status := model.AppointmentStatus(rec.Status)
+	if status != "" && !status.IsValid() {
+		violations = append(violations, errors.Violation{
+			Field:   "status",
+			Code:    errors.CodeUnknownStatus,
+			Message: fmt.Sprintf("unknown appointment status %q", rec.Status),
+		})
+	}
+
+	row := model.ImportRow{
+		Line: line,
+		Appointment: model.Appointment{
+			TrainerId: rec.TrainerId,
+			UserId:    rec.UserId,
+			StartTime: rec.StartTime.UTC(),
+			EndTime:   rec.EndTime.UTC(),
+			Status:    status,
+		},
+	}
+	if len(violations) > 0 {
+		row.Err = errors.ValidationErrors(violations)
+	}
+	return row
+}
+
+func invalidImportFile(message string, err error) *errors.AppError {
+	appErr := errors.ValidationError(message).WithCode(errors.CodeInvalidImportFile)
+	appErr.Err = err
+	return appErr
+}
+
+// importTooLarge reports a body cut off by http.MaxBytesReader, nil for any
+// other error
+func importTooLarge(err error) *errors.AppError {
+	var tooLarge *http.MaxBytesError
+	if !stderrors.As(err, &tooLarge) {
+		return nil
+	}
+	return errors.PayloadTooLargeError(fmt.Sprintf("import is larger than %d bytes", tooLarge.Limit)).
+		WithDetail("max_bytes", tooLarge.Limit)
+}
+
+func tooManyImportRows() *errors.AppError {
+	return invalidImportFile(fmt.Sprintf("import has more than %d rows", model.MaxImportRows), nil).
+		WithDetail("max_rows", model.MaxImportRows)
+}
+
+func malformedRow(err error) *errors.AppError {
+	appErr := errors.ValidationError("row is malformed").WithCode(errors.CodeInvalidRequest)
+	appErr.Err = err
+	return appErr
+}
+
+// Import report DTOs
+type ImportReportResponse struct {
+	Mode     string              `json:"mode"`
+	DryRun   bool                `json:"dry_run"`
+	Accepted int                 `json:"accepted"`
+	Rejected int                 `json:"rejected"`
+	Imported int                 `json:"imported"`
+	Rows     []ImportRowResponse `json:"rows"`
+}
+
+// ImportRowResponse reports one row. Status is "imported", "accepted" for a
+// valid row that was not committed (a dry run, or an all-or-nothing import
+// with rejected rows), or "rejected" with the errors and any details such as
+// conflicting appointment IDs or lines.
+type ImportRowResponse struct {
+	Line          int                `json:"line"`
+	Status        string             `json:"status"`
+	AppointmentId int64              `json:"appointment_id,omitempty"`
+	Errors        []ProblemViolation `json:"errors,omitempty"`
+	Details       map[string]any     `json:"details,omitempty"`
+}
+
+// ToImportReportResponse converts an import report to a response DTO
+func ToImportReportResponse(report *model.ImportReport) ImportReportResponse {
+	rows := make([]ImportRowResponse, len(report.Rows))
+	for i, row := range report.Rows {
+		rows[i] = ImportRowResponse{Line: row.Line, AppointmentId: row.AppointmentId}
+		switch {
+		case row.AppointmentId != 0:
+			rows[i].Status = "imported"
+		case row.Accepted:
+			rows[i].Status = "accepted"
+		default:
+			rows[i].Status = "rejected"
+		}
+
+		for _, v := range errors.ViolationsOf(row.Err) {
+			rows[i].Errors = append(rows[i].Errors, ProblemViolation{Field: v.Field, Code: string(v.Code), Message: v.Message})
+		}
+		if appErr, ok := errors.IsAppError(row.Err); ok {
+			rows[i].Details = appErr.Details
+		}
+	}
+
+	return ImportReportResponse{
+		Mode:     string(report.Mode),
+		DryRun:   report.DryRun,
+		Accepted: report.Accepted,
+		Rejected: report.Rejected,
+		Imported: report.Imported,
+		Rows:     rows,
+	}
+}
@@ -0,0 +1,115 @@
+package dto
+
+import (
+	"appointment-service/internal/errors"
+	"appointment-service/internal/model"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseImport tests reading CSV and NDJSON import files.
+//
+// It includes the following test cases:
+//
+// * CSV columns in any order, with an optional status column
+// * Bad fields, missing fields, unknown statuses and malformed quotes are row errors on the right line
+// * Malformed NDJSON lines are row errors, blank lines are skipped
+// * A CSV header missing columns, or an unknown format, fails the whole file
+// * An NDJSON line that is too long fails the file naming the line, a body that is too large as such
+func TestParseImport(t *testing.T) {
+	start := time.Date(2025, 6, 2, 16, 0, 0, 0, time.UTC)
+
+	violationFields := func(t *testing.T, err error) []string {
+		var fields []string
+		for _, v := range errors.ViolationsOf(err) {
+			fields = append(fields, v.Field)
+		}
+		return fields
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		csv := "user_id,trainer_id,start_time,end_time,status\n" +
+			"2,1,2025-06-02T12:00:00-04:00,2025-06-02T16:30:00Z,completed\n" +
+			"2,x,2025-06-02T16:00:00Z,,\n" +
+			"2,1,2025-06-02T16:00:00Z,2025-06-02T16:30:00Z,done\n" +
+			"\"2\"x,1,2025-06-02T16:00:00Z,2025-06-02T16:30:00Z\n" +
+			"2,1,2025-06-02T17:00:00Z,2025-06-02T17:30:00Z\n"
+		rows, err := ParseImport(strings.NewReader(csv), ImportCSV)
+		require.NoError(t, err)
+		require.Len(t, rows, 5)
+
+		assert.NoError(t, rows[0].Err)
+		assert.Equal(t, 2, rows[0].Line)
+		assert.Equal(t, int64(1), rows[0].Appointment.TrainerId)
+		assert.Equal(t, start, rows[0].Appointment.StartTime)
+		assert.Equal(t, model.StatusCompleted, rows[0].Appointment.Status)
+
+		assert.Equal(t, 3, rows[1].Line)
+		assert.ElementsMatch(t, []string{"trainer_id", "trainer_id", "end_time"}, violationFields(t, rows[1].Err))
+		assert.Equal(t, []string{"status"}, violationFields(t, rows[2].Err))
+
+		assert.Equal(t, 5, rows[3].Line)
+		assert.Error(t, rows[3].Err)
+		assert.Equal(t, 6, rows[4].Line)
+		assert.NoError(t, rows[4].Err)
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		ndjson := `{"trainer_id":1,"user_id":2,"start_time":"2025-06-02T16:00:00Z","end_time":"2025-06-02T16:30:00Z"}` + "\n\n" +
+			"not json\n" +
+			`{"trainer_id":1}` + "\n"
+		rows, err := ParseImport(strings.NewReader(ndjson), ImportNDJSON)
+		require.NoError(t, err)
+		require.Len(t, rows, 3)
+
+		assert.NoError(t, rows[0].Err)
+		assert.Equal(t, start, rows[0].Appointment.StartTime)
+		assert.Equal(t, model.AppointmentStatus(""), rows[0].Appointment.Status)
+		assert.Equal(t, 3, rows[1].Line)
+		assert.Error(t, rows[1].Err)
+		assert.Equal(t, 4, rows[2].Line)
+		assert.Equal(t, []string{"user_id", "start_time", "end_time"}, violationFields(t, rows[2].Err))
+	})
+
+	t.Run("bad files", func(t *testing.T) {
+		_, err := ParseImport(strings.NewReader("trainer_id,start_time\n"), ImportCSV)
+		appErr, ok := errors.IsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, errors.CodeInvalidImportFile, appErr.ErrorCode)
+		assert.Equal(t, []string{"user_id", "end_time"}, appErr.Details["missing_columns"])
+
+		_, err = ParseImport(strings.NewReader(""), "xml")
+		appErr, ok = errors.IsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, errors.CodeInvalidImportFile, appErr.ErrorCode)
+	})
+
+	t.Run("size limits", func(t *testing.T) {
+		row := `{"trainer_id":1,"user_id":2,"start_time":"2025-06-02T16:00:00Z","end_time":"2025-06-02T16:30:00Z"}` + "\n"
+		long := `{"notes":"` + strings.Repeat("x", model.MaxImportLineBytes) + `"}` + "\n"
+		_, err := ParseImport(strings.NewReader(row+long+row), ImportNDJSON)
+		appErr, ok := errors.IsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, errors.CodeInvalidImportFile, appErr.ErrorCode)
+		assert.Equal(t, "line 2 is longer than 65536 bytes", appErr.Message)
+		assert.Equal(t, 2, appErr.Details["line"])
+
+		for format, body := range map[ImportFormat]string{
+			ImportNDJSON: strings.Repeat(row, 10),
+			ImportCSV:    "trainer_id,user_id,start_time,end_time\n" + strings.Repeat("1,2,2025-06-02T16:00:00Z,2025-06-02T16:30:00Z\n", 10),
+		} {
+			_, err := ParseImport(http.MaxBytesReader(nil, io.NopCloser(strings.NewReader(body)), 200), format)
+			appErr, ok := errors.IsAppError(err)
+			require.True(t, ok, format)
+			assert.Equal(t, http.StatusRequestEntityTooLarge, appErr.Code, format)
+			assert.Equal(t, errors.CodePayloadTooLarge, appErr.ErrorCode, format)
+			assert.Equal(t, int64(200), appErr.Details["max_bytes"], format)
+		}
+	})
+}
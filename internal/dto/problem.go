@@ -0,0 +1,23 @@
+package dto
+
+// ProblemResponse is an RFC 7807 problem details body, served as
+// application/problem+json. Code and Details are extension members:
+// Code is the stable machine readable reason, Details any structured context
+// and Errors lists every violation when validation errors are aggregated.
+type ProblemResponse struct {
+	Type     string             `json:"type"`
+	Title    string             `json:"title"`
+	Status   int                `json:"status"`
+	Detail   string             `json:"detail,omitempty"`
+	Instance string             `json:"instance,omitempty"`
+	Code     string             `json:"code"`
+	Details  map[string]any     `json:"details,omitempty"`
+	Errors   []ProblemViolation `json:"errors,omitempty"`
+}
+
+// ProblemViolation is a single failed check within a problem response
+type ProblemViolation struct {
+	Field   string `json:"field,omitempty"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,94 @@
+package dto
+
+import (
+	"appointment-service/internal/model"
+	"time"
+)
+
+// UtilizationRequest binds a utilization report's query string. Without
+// trainer_id the report covers every trainer with appointments in the range.
+type UtilizationRequest struct {
+	TrainerId int64     `form:"trainer_id" binding:"gte=0"`
+	Period    string    `form:"period"`
+	From      time.Time `form:"from" time_format:"2006-01-02"`
+	To        time.Time `form:"to" time_format:"2006-01-02"`
+}
+
+// ToUtilizationQuery converts a utilization request into a model query
+func ToUtilizationQuery(r *UtilizationRequest) model.UtilizationQuery {
+	return model.UtilizationQuery{
+		TrainerId: r.TrainerId,
+		Period:    model.ReportPeriod(r.Period),
+		From:      r.From,
+		To:        r.To,
+	}
+}
+
+// UtilizationResponse holds one entry per trainer
+type UtilizationResponse struct {
+	Period   string                       `json:"period"`
+	Trainers []TrainerUtilizationResponse `json:"trainers"`
+}
+
+// TrainerUtilizationResponse holds a trainer's periods, as local dates in
+// TimeZone
+type TrainerUtilizationResponse struct {
+	TrainerId int64                       `json:"trainer_id"`
+	TimeZone  string                      `json:"time_zone"`
+	Periods   []PeriodUtilizationResponse `json:"periods"`
+}
+
+// PeriodUtilizationResponse summarises the local dates From through To, both
+// inclusive. UtilizationPercent is booked over available minutes.
+type PeriodUtilizationResponse struct {
+	From               string             `json:"from"`
+	To                 string             `json:"to"`
+	AvailableMinutes   int                `json:"available_minutes"`
+	BookedMinutes      int                `json:"booked_minutes"`
+	UtilizationPercent float64            `json:"utilization_percent"`
+	Appointments       int                `json:"appointments"`
+	Cancellations      int                `json:"cancellations"`
+	NoShows            int                `json:"no_shows"`
+	PeakHours          []PeakHourResponse `json:"peak_hours"`
+}
+
+// PeakHourResponse counts the appointments starting in a local hour of day
+type PeakHourResponse struct {
+	Hour         int `json:"hour"`
+	Appointments int `json:"appointments"`
+}
+
+// ToUtilizationResponse converts a utilization report to a response DTO
+func ToUtilizationResponse(report *model.UtilizationReport) UtilizationResponse {
+	trainers := make([]TrainerUtilizationResponse, len(report.Trainers))
+	for i, trainer := range report.Trainers {
+		periods := make([]PeriodUtilizationResponse, len(trainer.Periods))
+		for j, p := range trainer.Periods {
+			peaks := make([]PeakHourResponse, len(p.PeakHours))
+			for k, h := range p.PeakHours {
+				peaks[k] = PeakHourResponse{Hour: h.Hour, Appointments: h.Appointments}
+			}
+
+			// End is the start of the next day, report the last date included
+			last := p.End.Add(-time.Nanosecond)
+			periods[j] = PeriodUtilizationResponse{
+				From:               p.Start.In(trainer.Location).Format(time.DateOnly),
+				To:                 last.In(trainer.Location).Format(time.DateOnly),
+				AvailableMinutes:   p.AvailableMinutes,
+				BookedMinutes:      p.BookedMinutes,
+				UtilizationPercent: p.Utilization,
+				Appointments:       p.Appointments,
+				Cancellations:      p.Cancellations,
+				NoShows:            p.NoShows,
+				PeakHours:          peaks,
+			}
+		}
+		trainers[i] = TrainerUtilizationResponse{
+			TrainerId: trainer.TrainerId,
+			TimeZone:  trainer.Location.String(),
+			Periods:   periods,
+		}
+	}
+
+	return UtilizationResponse{Period: string(report.Period), Trainers: trainers}
+}
@@ -0,0 +1,56 @@
+package errors
+
+// ErrorCode is a stable, machine readable error reason. Clients should switch
+// on these instead of matching on error messages, which may change.
+type ErrorCode string
+
+// Generic codes, used by the constructors when nothing more specific applies
+const (
+	CodeValidationFailed     ErrorCode = "VALIDATION_FAILED"
+	CodeNotFound             ErrorCode = "NOT_FOUND"
+	CodeConflict             ErrorCode = "CONFLICT"
+	CodeVersionMismatch      ErrorCode = "VERSION_MISMATCH"
+	CodePreconditionRequired ErrorCode = "PRECONDITION_REQUIRED"
+	CodeUnauthorized         ErrorCode = "UNAUTHORIZED"
+	CodeRateLimited          ErrorCode = "RATE_LIMITED"
+	CodePayloadTooLarge      ErrorCode = "PAYLOAD_TOO_LARGE"
+	CodeInternal             ErrorCode = "INTERNAL_ERROR"
+)
+
+// Request codes
+const (
+	CodeInvalidRequest    ErrorCode = "INVALID_REQUEST"
+	CodeFieldRequired     ErrorCode = "FIELD_REQUIRED"
+	CodeFieldInvalid      ErrorCode = "FIELD_INVALID"
+	CodeInvalidTimeRange  ErrorCode = "INVALID_TIME_RANGE"
+	CodeInvalidCursor     ErrorCode = "INVALID_CURSOR"
+	CodeInvalidPageSize   ErrorCode = "INVALID_PAGE_SIZE"
+	CodeInvalidSortOrder  ErrorCode = "INVALID_SORT_ORDER"
+	CodeInvalidETag       ErrorCode = "INVALID_ETAG"
+	CodeInvalidTimeZone   ErrorCode = "INVALID_TIME_ZONE"
+	CodeInvalidImportFile ErrorCode = "INVALID_IMPORT_FILE"
+	CodeRouteNotFound     ErrorCode = "ROUTE_NOT_FOUND"
+)
+
+// Domain codes
+const (
+	CodeAppointmentNotFound     ErrorCode = "APPOINTMENT_NOT_FOUND"
+	CodeInvalidDuration         ErrorCode = "INVALID_DURATION"
+	CodeOutsideBusinessHours    ErrorCode = "OUTSIDE_BUSINESS_HOURS"
+	CodeTrainerUnavailable      ErrorCode = "TRAINER_UNAVAILABLE"
+	CodeUserUnavailable         ErrorCode = "USER_UNAVAILABLE"
+	CodeUnknownStatus           ErrorCode = "UNKNOWN_STATUS"
+	CodeInvalidStatusTransition ErrorCode = "INVALID_STATUS_TRANSITION"
+)
+
+// Infrastructure codes
+const (
+	CodeRequestCancelled ErrorCode = "REQUEST_CANCELLED"
+	CodeStorageFailure   ErrorCode = "STORAGE_FAILURE"
+	CodeNotImplemented   ErrorCode = "NOT_IMPLEMENTED"
+)
+
+// TypeURI returns the RFC 7807 problem type identifying this code
+func (c ErrorCode) TypeURI() string {
+	return "urn:appointment-service:problem:" + string(c)
+}
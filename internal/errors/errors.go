@@ -1,15 +1,25 @@
 package errors
 
 import (
+	"context"
+	stderrors "errors"
 	"fmt"
 	"net/http"
 )
 
-// AppError represents an application-specific error
+// AppError represents an application-specific error.
+//
+// Code is the HTTP status the error maps to. ErrorCode is the stable,
+// machine readable reason clients can switch on, and Details carries
+// structured context such as field names or conflicting appointment IDs.
+// Violations lists every failed check when validation errors are aggregated.
 type AppError struct {
-	Message string
-	Code    int
-	Err     error
+	Message    string
+	Code       int
+	ErrorCode  ErrorCode
+	Details    map[string]any
+	Violations []Violation
+	Err        error
 }
 
 // Error returns the error message
@@ -20,41 +30,149 @@ func (e *AppError) Error() string {
 	return e.Message
 }
 
+// Unwrap returns the underlying error, if any
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// WithCode replaces the generic error code with a more specific one
+func (e *AppError) WithCode(code ErrorCode) *AppError {
+	e.ErrorCode = code
+	return e
+}
+
+// WithDetail attaches a structured detail, e.g. the offending field name
+func (e *AppError) WithDetail(key string, value any) *AppError {
+	if e.Details == nil {
+		e.Details = make(map[string]any)
+	}
+	e.Details[key] = value
+	return e
+}
+
 // ValidationError returns a new AppError for validation failures
 func ValidationError(message string) *AppError {
 	return &AppError{
-		Message: message,
-		Code:    http.StatusBadRequest,
+		Message:   message,
+		Code:      http.StatusBadRequest,
+		ErrorCode: CodeValidationFailed,
 	}
 }
 
 // NotFoundError returns a new AppError for resource not found
 func NotFoundError(message string) *AppError {
 	return &AppError{
-		Message: message,
-		Code:    http.StatusNotFound,
+		Message:   message,
+		Code:      http.StatusNotFound,
+		ErrorCode: CodeNotFound,
 	}
 }
 
 // ConflictError returns a new AppError for conflicting resources
 func ConflictError(message string) *AppError {
 	return &AppError{
-		Message: message,
-		Code:    http.StatusConflict,
+		Message:   message,
+		Code:      http.StatusConflict,
+		ErrorCode: CodeConflict,
+	}
+}
+
+// PreconditionFailedError returns a new AppError for stale writes, where the
+// caller's version of a resource no longer matches the stored one
+func PreconditionFailedError(message string) *AppError {
+	return &AppError{
+		Message:   message,
+		Code:      http.StatusPreconditionFailed,
+		ErrorCode: CodeVersionMismatch,
+	}
+}
+
+// PreconditionRequiredError returns a new AppError for writes that must be
+// conditional but were sent without a precondition (e.g. no If-Match header)
+func PreconditionRequiredError(message string) *AppError {
+	return &AppError{
+		Message:   message,
+		Code:      http.StatusPreconditionRequired,
+		ErrorCode: CodePreconditionRequired,
+	}
+}
+
+// UnauthorizedError returns a new AppError for requests without valid
+// credentials
+func UnauthorizedError(message string) *AppError {
+	return &AppError{
+		Message:   message,
+		Code:      http.StatusUnauthorized,
+		ErrorCode: CodeUnauthorized,
+	}
+}
+
+// RateLimitedError returns a new AppError for clients that sent more
+// requests than they are allowed
+func RateLimitedError(message string) *AppError {
+	return &AppError{
+		Message:   message,
+		Code:      http.StatusTooManyRequests,
+		ErrorCode: CodeRateLimited,
+	}
+}
+
+// PayloadTooLargeError returns a new AppError for request bodies beyond
+// their size limit
+func PayloadTooLargeError(message string) *AppError {
+	return &AppError{
+		Message:   message,
+		Code:      http.StatusRequestEntityTooLarge,
+		ErrorCode: CodePayloadTooLarge,
 	}
 }
 
 // InternalError wraps internal server errors
 func InternalError(message string, err error) *AppError {
 	return &AppError{
-		Message: message,
-		Code:    http.StatusInternalServerError,
-		Err:     err,
+		Message:   message,
+		Code:      http.StatusInternalServerError,
+		ErrorCode: CodeInternal,
+		Err:       err,
 	}
 }
 
-// IsAppError checks if an error is an AppError
+// RequestCancelledError wraps a context error when a request was cancelled
+// or timed out before the work could finish
+func RequestCancelledError(err error) *AppError {
+	return InternalError("context cancelled", err).WithCode(CodeRequestCancelled)
+}
+
+// StorageError wraps failures of the underlying storage, e.g. a failed query.
+// Queries aborted by a cancelled or expired context are reported as
+// RequestCancelledError instead, as the storage itself did not fail.
+func StorageError(message string, err error) *AppError {
+	if stderrors.Is(err, context.Canceled) || stderrors.Is(err, context.DeadlineExceeded) {
+		return RequestCancelledError(err)
+	}
+	return InternalError(message, err).WithCode(CodeStorageFailure)
+}
+
+// AppointmentNotFoundError returns a NotFoundError for a missing appointment
+func AppointmentNotFoundError(id int64) *AppError {
+	return NotFoundError(fmt.Sprintf("appointment %d not found", id)).
+		WithCode(CodeAppointmentNotFound).
+		WithDetail("appointment_id", id)
+}
+
+// VersionMismatchError returns a PreconditionFailedError for a write carrying
+// a stale appointment version
+func VersionMismatchError(id, expected, current int64) *AppError {
+	return PreconditionFailedError(fmt.Sprintf(
+		"appointment %d has been modified: expected version %d, current version %d",
+		id, expected, current)).
+		WithDetail("appointment_id", id).
+		WithDetail("current_version", current)
+}
+
+// IsAppError checks if an error is, or wraps, an AppError
 func IsAppError(err error) (*AppError, bool) {
-	appErr, ok := err.(*AppError)
+	var appErr *AppError
+	ok := stderrors.As(err, &appErr)
 	return appErr, ok
 }
@@ -0,0 +1,53 @@
+package errors
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Violation is a single failed check, e.g. one validation rule or one bad field
+type Violation struct {
+	Field   string
+	Code    ErrorCode
+	Message string
+}
+
+// ValidationErrors returns a new AppError reporting every violation at once.
+// A single violation keeps its own code and message, so clients switching on
+// the code see the same result whether or not errors were aggregated.
+func ValidationErrors(violations []Violation) *AppError {
+	appErr := &AppError{
+		Message:    fmt.Sprintf("request has %d validation errors", len(violations)),
+		Code:       http.StatusBadRequest,
+		ErrorCode:  CodeValidationFailed,
+		Violations: violations,
+	}
+	if len(violations) == 1 {
+		appErr.Message = violations[0].Message
+		appErr.ErrorCode = violations[0].Code
+		if violations[0].Field != "" {
+			appErr.WithDetail("field", violations[0].Field)
+		}
+	}
+	return appErr
+}
+
+// ViolationsOf flattens an error into violations. Aggregated errors return
+// their violations, any other AppError becomes a single violation using its
+// "field" detail, and nil returns nothing.
+func ViolationsOf(err error) []Violation {
+	if err == nil {
+		return nil
+	}
+
+	appErr, ok := IsAppError(err)
+	if !ok {
+		return []Violation{{Code: CodeValidationFailed, Message: err.Error()}}
+	}
+	if len(appErr.Violations) > 0 {
+		return appErr.Violations
+	}
+
+	field, _ := appErr.Details["field"].(string)
+	return []Violation{{Field: field, Code: appErr.ErrorCode, Message: appErr.Message}}
+}
@@ -0,0 +1,129 @@
+// Package health decides whether the service is ready for traffic. Readiness
+// is the sum of named checks, e.g. the repository answering a ping or the
+// schema being migrated, which components register with a Checker. Once the
+// service starts shutting down it is never ready again, so load balancers
+// stop sending traffic while in-flight requests drain.
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTimeout bounds each check, so one hanging dependency can't hang
+// the probe
+const DefaultTimeout = 2 * time.Second
+
+// Check reports whether one dependency is usable, nil meaning it is
+type Check func(ctx context.Context) error
+
+// Result is the outcome of one check
+type Result struct {
+	Name     string
+	Err      error
+	Duration time.Duration
+}
+
+// Report is the outcome of a readiness probe. While draining, no checks
+// are run.
+type Report struct {
+	Ready    bool
+	Draining bool
+	Results  []Result
+}
+
+// Checker runs the registered checks. It is safe for concurrent use.
+type Checker struct {
+	timeout  time.Duration
+	draining atomic.Bool
+
+	mu     sync.RWMutex
+	names  []string
+	checks []Check
+}
+
+// NewChecker creates a Checker giving each check at most timeout, or
+// DefaultTimeout when zero
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Checker{timeout: timeout}
+}
+
+// Add registers check under name. Checks are reported in the order added.
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.names = append(c.names, name)
+	c.checks = append(c.checks, check)
+}
+
+// Drain makes every later probe fail, for good
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Draining reports whether Drain was called
+func (c *Checker) Draining() bool {
+	return c.draining.Load()
+}
+
+// Ready runs every check concurrently and reports whether all passed
+func (c *Checker) Ready(ctx context.Context) Report {
+	if c.Draining() {
+		return Report{Draining: true}
+	}
+
+	c.mu.RLock()
+	names, checks := c.names, c.checks
+	c.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i := range checks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = c.run(ctx, names[i], checks[i])
+		}(i)
+	}
+	wg.Wait()
+
+	report := Report{Ready: true, Results: results}
+	for _, result := range results {
+		if result.Err != nil {
+			report.Ready = false
+		}
+	}
+	return report
+}
+
+// run runs one check within the timeout. A check ignoring its context is
+// reported as failed when the timeout passes, and left to finish on its own.
+func (c *Checker) run(ctx context.Context, name string, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				done <- fmt.Errorf("check panicked: %v", recovered)
+			}
+		}()
+		done <- check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("check timed out after %s", c.timeout)
+	}
+	return Result{Name: name, Err: err, Duration: time.Since(start)}
+}
@@ -0,0 +1,113 @@
+package health
+
+import (
+	"appointment-service/internal/migrate"
+	"appointment-service/internal/repository/sqlite3"
+	"context"
+	stderrors "errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChecker tests how checks add up to readiness.
+//
+// It includes the following test cases:
+//
+// * No checks, which is ready
+// * Passing checks, reported in the order added
+// * One failing check fails readiness
+// * A check ignoring its context times out
+// * A panicking check fails instead of crashing
+// * Draining fails readiness without running checks
+func TestChecker(t *testing.T) {
+	ctx := context.Background()
+	pass := func(context.Context) error { return nil }
+
+	t.Run("no checks", func(t *testing.T) {
+		assert.True(t, NewChecker(0).Ready(ctx).Ready)
+	})
+
+	t.Run("passing", func(t *testing.T) {
+		c := NewChecker(0)
+		c.Add("b", pass)
+		c.Add("a", pass)
+
+		report := c.Ready(ctx)
+		assert.True(t, report.Ready)
+		require.Len(t, report.Results, 2)
+		assert.Equal(t, "b", report.Results[0].Name)
+		assert.Equal(t, "a", report.Results[1].Name)
+	})
+
+	t.Run("failing", func(t *testing.T) {
+		c := NewChecker(0)
+		c.Add("ok", pass)
+		c.Add("down", func(context.Context) error { return stderrors.New("connection refused") })
+
+		report := c.Ready(ctx)
+		assert.False(t, report.Ready)
+		assert.NoError(t, report.Results[0].Err)
+		assert.EqualError(t, report.Results[1].Err, "connection refused")
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		c := NewChecker(10 * time.Millisecond)
+		release := make(chan struct{})
+		defer close(release)
+		c.Add("stuck", func(context.Context) error { <-release; return nil })
+
+		report := c.Ready(ctx)
+		assert.False(t, report.Ready)
+		assert.ErrorContains(t, report.Results[0].Err, "timed out")
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		c := NewChecker(0)
+		c.Add("broken", func(context.Context) error { panic("boom") })
+
+		report := c.Ready(ctx)
+		assert.False(t, report.Ready)
+		assert.ErrorContains(t, report.Results[0].Err, "boom")
+	})
+
+	t.Run("draining", func(t *testing.T) {
+		c := NewChecker(0)
+		ran := false
+		c.Add("ok", func(context.Context) error { ran = true; return nil })
+		c.Drain()
+
+		report := c.Ready(ctx)
+		assert.False(t, report.Ready)
+		assert.True(t, report.Draining)
+		assert.False(t, ran)
+	})
+}
+
+// TestMigrations tests the schema migration check on SQLite.
+//
+// It includes the following test cases:
+//
+// * An unmigrated schema fails with the pending migrations
+// * A migrated schema passes
+func TestMigrations(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	db, err := sqlite3.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	migrator, err := migrate.New(db.DB, migrate.SQLite3, logger)
+	require.NoError(t, err)
+	check := Migrations(migrator)
+
+	assert.ErrorContains(t, check(ctx), "migrations pending")
+
+	require.NoError(t, migrator.Up(ctx))
+	assert.NoError(t, check(ctx))
+}
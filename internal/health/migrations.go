@@ -0,0 +1,37 @@
+package health
+
+import (
+	"appointment-service/internal/migrate"
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// Migrations checks that every migration known to this build is applied
+// cleanly, e.g. that an operator ran `migrate up` when auto-migration is off.
+// Once up to date the schema is not checked again, since reading its state
+// takes the migration lock.
+func Migrations(m *migrate.Migrator) Check {
+	var upToDate atomic.Bool
+	return func(ctx context.Context) error {
+		if upToDate.Load() {
+			return nil
+		}
+
+		status, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		switch {
+		case status.Dirty:
+			return &migrate.DirtyError{Version: status.Version}
+		case len(status.Pending) > 0:
+			return fmt.Errorf("schema is at version %d, %d migrations pending", status.Version, len(status.Pending))
+		case !status.UpToDate():
+			return fmt.Errorf("%w: %d", migrate.ErrUnknownVersion, status.Version)
+		}
+
+		upToDate.Store(true)
+		return nil
+	}
+}
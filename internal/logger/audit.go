@@ -0,0 +1,35 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// LevelAudit is above every other level, so audit records are logged
+// whatever the configured level
+const LevelAudit = slog.Level(12)
+
+// Audit logs a change made to the running service, e.g. its log level,
+// at LevelAudit. The record's source is the caller of Audit.
+func Audit(ctx context.Context, l *slog.Logger, msg string, args ...any) {
+	if !l.Enabled(ctx, LevelAudit) {
+		return
+	}
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:]) // skip runtime.Callers and Audit
+	r := slog.NewRecord(time.Now(), LevelAudit, msg, pcs[0])
+	r.Add(args...)
+	_ = l.Handler().Handle(ctx, r)
+}
+
+// nameAuditLevel shows LevelAudit as AUDIT rather than ERROR+4
+func nameAuditLevel(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.LevelKey && len(groups) == 0 {
+		if level, ok := a.Value.Any().(slog.Level); ok && level == LevelAudit {
+			a.Value = slog.StringValue("AUDIT")
+		}
+	}
+	return a
+}
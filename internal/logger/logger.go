@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"io"
 	"log/slog"
 	"os"
 	"strings"
@@ -24,28 +25,58 @@ type Attributes struct {
 // Config holds the logger attributes and behavior configuration
 type Config struct {
 	Attributes Attributes
-	Level      slog.Level
 	AddSource  bool
 	Format     Format
+
+	// Level is the minimum level logged. A *slog.LevelVar changes it while
+	// running, for every logger derived from this one.
+	Level slog.Leveler
+
+	// Output is where log records are written, os.Stdout when nil
+	Output io.Writer
+
+	// Redact lists key patterns, e.g. "*password*" or "query.email", whose
+	// values are logged as [REDACTED]
+	Redact []string
+
+	// Sample logs one in every n records of a message, e.g.
+	// {"Slot calculation": 100}, for messages too frequent to log them all
+	Sample map[string]int
 }
 
 // NewLogger creates a new logger instance with the provided configuration
-// Allows the loglevel to be set from the config
+// Allows the loglevel to be set from the config, and changed while running through a slog.LevelVar
 // Allows the AddSource to be set from the config, where this will add the source file and line number to the log output
 // Allows the Format to be set from the config (json or text)
 // Adds the service name, version, commit sha, and build time to every log message
+// Adds the trace and span ID to messages logged with the context of a span
+// Redacts the values of attributes matching the Redact patterns, and samples the Sample messages
 func NewLogger(cfg Config) *slog.Logger {
 	opts := &slog.HandlerOptions{
-		Level:     cfg.Level,
-		AddSource: cfg.AddSource,
+		Level:       cfg.Level,
+		AddSource:   cfg.AddSource,
+		ReplaceAttr: nameAuditLevel,
 	}
 
-	var baseLogger *slog.Logger
+	output := cfg.Output
+	if output == nil {
+		output = os.Stdout
+	}
+
+	var handler slog.Handler
 	if cfg.Format == FormatJSON {
-		baseLogger = slog.New(slog.NewJSONHandler(os.Stdout, opts))
+		handler = slog.NewJSONHandler(output, opts)
 	} else {
-		baseLogger = slog.New(slog.NewTextHandler(os.Stdout, opts))
+		handler = slog.NewTextHandler(output, opts)
+	}
+	if len(cfg.Redact) > 0 {
+		handler = newRedactHandler(handler, cfg.Redact)
+	}
+	handler = traceHandler{handler}
+	if len(cfg.Sample) > 0 {
+		handler = newSampleHandler(handler, cfg.Sample)
 	}
+	baseLogger := slog.New(handler)
 
 	return baseLogger.With(
 		"service", cfg.Attributes.ServiceName,
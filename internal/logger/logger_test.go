@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRedact tests redacting log attributes by key.
+//
+// It includes the following test cases:
+//
+// * Keys matching a pattern, whatever their case
+// * Members of groups, by key or by dotted path
+// * Attributes added with With, and under WithGroup
+// * Other attributes, which are left as they are
+func TestRedact(t *testing.T) {
+	var out bytes.Buffer
+	log := NewLogger(Config{
+		Level:  slog.LevelInfo,
+		Output: &out,
+		Redact: []string{"*password*", "email", "query.name"},
+	})
+
+	log.Info("Signed up",
+		"DB_PASSWORD", "hunter2",
+		"email", "ada@example.com",
+		slog.Group("query", "name", "Ada", "tz", "Europe/London"),
+		"name", "kept outside the query")
+	assert.Contains(t, out.String(), "DB_PASSWORD=[REDACTED] email=[REDACTED] query.name=[REDACTED] query.tz=Europe/London")
+	assert.Contains(t, out.String(), `name="kept outside the query"`)
+	assert.NotContains(t, out.String(), "hunter2")
+	assert.NotContains(t, out.String(), "ada@")
+
+	out.Reset()
+	log.With("email", "ada@example.com").WithGroup("query").Info("Listed", "name", "Ada", "page", 2)
+	assert.Contains(t, out.String(), "email=[REDACTED] query.name=[REDACTED] query.page=2")
+}
+
+// TestSample tests sampling frequent messages.
+//
+// It includes the following test cases:
+//
+// * One in every rate records is logged, starting with the first, with its rate
+// * Loggers derived with With share the count
+// * Warnings and other messages are always logged
+func TestSample(t *testing.T) {
+	var out bytes.Buffer
+	log := NewLogger(Config{
+		Level:  slog.LevelInfo,
+		Output: &out,
+		Sample: map[string]int{"Slot calculation": 3},
+	})
+
+	for i := range 7 {
+		log.With("request", i).InfoContext(context.Background(), "Slot calculation")
+	}
+	log.Warn("Slot calculation")
+	log.Info("request completed")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 5)
+	assert.Contains(t, lines[0], "request=0 sample_rate=3")
+	assert.Contains(t, lines[1], "request=3 sample_rate=3")
+	assert.Contains(t, lines[2], "request=6 sample_rate=3")
+	assert.Contains(t, lines[3], `level=WARN msg="Slot calculation"`)
+	assert.NotContains(t, lines[3], "sample_rate")
+	assert.Contains(t, lines[4], `msg="request completed"`)
+}
@@ -0,0 +1,86 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"path"
+	"strings"
+)
+
+// Redacted replaces the value of an attribute whose key is redacted
+const Redacted = "[REDACTED]"
+
+// redactHandler replaces the values of attributes whose key matches one of
+// its patterns with Redacted, at any depth of groups, so credentials and
+// personal data never reach the output. Patterns are path.Match globs, e.g.
+// "*password*", matched case insensitively against both the key and its
+// dotted path through groups, e.g. "query.email".
+type redactHandler struct {
+	slog.Handler
+	patterns []string
+
+	// prefix is the dotted path of the groups opened with WithGroup
+	prefix string
+}
+
+func newRedactHandler(h slog.Handler, patterns []string) redactHandler {
+	lowered := make([]string, len(patterns))
+	for i, p := range patterns {
+		lowered[i] = strings.ToLower(p)
+	}
+	return redactHandler{Handler: h, patterns: lowered}
+}
+
+func (h redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(h.redact(h.prefix, a))
+		return true
+	})
+	return h.Handler.Handle(ctx, redacted)
+}
+
+func (h redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = h.redact(h.prefix, a)
+	}
+	return redactHandler{h.Handler.WithAttrs(redacted), h.patterns, h.prefix}
+}
+
+func (h redactHandler) WithGroup(name string) slog.Handler {
+	return redactHandler{h.Handler.WithGroup(name), h.patterns, h.prefix + name + "."}
+}
+
+// redact returns a with its value, or the values of its group's members,
+// redacted where their key matches. prefix is the path of a's group.
+func (h redactHandler) redact(prefix string, a slog.Attr) slog.Attr {
+	if a.Key != "" && (h.matches(a.Key) || h.matches(prefix+a.Key)) {
+		return slog.String(a.Key, Redacted)
+	}
+
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() != slog.KindGroup {
+		return a
+	}
+	if a.Key != "" {
+		prefix += a.Key + "."
+	}
+	members := a.Value.Group()
+	redacted := make([]slog.Attr, len(members))
+	for i, member := range members {
+		redacted[i] = h.redact(prefix, member)
+	}
+	a.Value = slog.GroupValue(redacted...)
+	return a
+}
+
+func (h redactHandler) matches(key string) bool {
+	key = strings.ToLower(key)
+	for _, pattern := range h.patterns {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// sampleHandler logs one in every rate records of the messages it samples,
+// e.g. one in 100 "Slot calculation" records, starting with the first.
+// Records at warn level and above are always logged. Logged samples carry
+// their rate as sample_rate, so counts can be scaled back up.
+type sampleHandler struct {
+	slog.Handler
+
+	// samplers are shared by every handler derived with WithAttrs or
+	// WithGroup, so a message is sampled across all request loggers
+	samplers map[string]*sampler
+}
+
+type sampler struct {
+	rate uint64
+	seen atomic.Uint64
+}
+
+// newSampleHandler samples the messages of rates at their rate. Rates of 1
+// and below log every record.
+func newSampleHandler(h slog.Handler, rates map[string]int) sampleHandler {
+	samplers := make(map[string]*sampler, len(rates))
+	for msg, rate := range rates {
+		if rate > 1 {
+			samplers[msg] = &sampler{rate: uint64(rate)}
+		}
+	}
+	return sampleHandler{Handler: h, samplers: samplers}
+}
+
+func (h sampleHandler) Handle(ctx context.Context, r slog.Record) error {
+	if s, ok := h.samplers[r.Message]; ok && r.Level < slog.LevelWarn {
+		if (s.seen.Add(1)-1)%s.rate != 0 {
+			return nil
+		}
+		r.AddAttrs(slog.Uint64("sample_rate", s.rate))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h sampleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return sampleHandler{h.Handler.WithAttrs(attrs), h.samplers}
+}
+
+func (h sampleHandler) WithGroup(name string) slog.Handler {
+	return sampleHandler{h.Handler.WithGroup(name), h.samplers}
+}
@@ -0,0 +1,164 @@
+// Package metrics exposes the service's Prometheus metrics: HTTP traffic,
+// booking outcomes, availability latency, repository latency and database
+// pool statistics.
+//
+// Collectors live on a registry of their own rather than the global default,
+// so every Application, and every test, starts from zero. The repository and
+// service are instrumented by decorators, leaving their implementations free
+// of metrics code.
+package metrics
+
+import (
+	"appointment-service/internal/errors"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "appointments"
+
+// Metrics holds the service's collectors and the registry serving them
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests        *prometheus.CounterVec
+	httpDuration        *prometheus.HistogramVec
+	bookingsCreated     prometheus.Counter
+	bookingConflicts    *prometheus.CounterVec
+	validationFailures  *prometheus.CounterVec
+	availabilityLatency *prometheus.HistogramVec
+	repositoryLatency   *prometheus.HistogramVec
+	repositoryErrors    *prometheus.CounterVec
+}
+
+// New creates the collectors on a fresh registry, along with the standard Go
+// runtime and process collectors
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests handled, by method, route template and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency, by method and route template.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		bookingsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "bookings_created_total",
+			Help:      "Appointments booked, singly or by import.",
+		}),
+		bookingConflicts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "booking_conflicts_total",
+			Help:      "Bookings and reschedules rejected by a conflict, by error code.",
+		}, []string{"reason"}),
+		validationFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "validation_failures_total",
+			Help:      "Business rule violations of bookings and reschedules, by rule error code.",
+		}, []string{"rule"}),
+		availabilityLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "availability_duration_seconds",
+			Help:      "Time to compute availability, by operation.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 4, 8),
+		}, []string{"operation"}),
+		repositoryLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "repository_query_duration_seconds",
+			Help:      "Repository call latency, by storage backend and method.",
+			Buckets:   prometheus.ExponentialBuckets(0.0001, 4, 9),
+		}, []string{"backend", "method"}),
+		repositoryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "repository_errors_total",
+			Help:      "Repository calls failing with a server side error, by storage backend and method.",
+		}, []string{"backend", "method"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.bookingsCreated,
+		m.bookingConflicts,
+		m.validationFailures,
+		m.availabilityLatency,
+		m.repositoryLatency,
+		m.repositoryErrors,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// RegisterDB exports the connection pool statistics of db, labelled with the
+// storage backend
+func (m *Metrics) RegisterDB(backend string, db *sql.DB) error {
+	return m.registry.Register(collectors.NewDBStatsCollector(db, backend))
+}
+
+// ObserveRequest records one handled HTTP request. Route is the route
+// template, e.g. /api/v1/appointments/:id, so IDs don't explode the labels.
+func (m *Metrics) ObserveRequest(method, route string, status int, d time.Duration) {
+	m.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.httpDuration.WithLabelValues(method, route).Observe(d.Seconds())
+}
+
+// BookingsCreated counts n new appointments
+func (m *Metrics) BookingsCreated(n int) {
+	m.bookingsCreated.Add(float64(n))
+}
+
+// BookingRejected counts why a booking or reschedule failed: conflicts by
+// their error code, rule violations by each violated rule's code. Other
+// failures are not booking outcomes and are left to the HTTP metrics.
+func (m *Metrics) BookingRejected(err error) {
+	appErr, ok := errors.IsAppError(err)
+	if !ok {
+		return
+	}
+
+	switch appErr.Code {
+	case http.StatusConflict:
+		m.bookingConflicts.WithLabelValues(string(appErr.ErrorCode)).Inc()
+	case http.StatusBadRequest:
+		for _, v := range errors.ViolationsOf(appErr) {
+			m.validationFailures.WithLabelValues(string(v.Code)).Inc()
+		}
+	}
+}
+
+// ObserveAvailability records how long an availability operation took
+func (m *Metrics) ObserveAvailability(operation string, d time.Duration) {
+	m.availabilityLatency.WithLabelValues(operation).Observe(d.Seconds())
+}
+
+// ObserveQuery records one repository call. Only server side failures count
+// as errors; a missing appointment, stale version or cancelled request is a
+// normal outcome.
+func (m *Metrics) ObserveQuery(backend, method string, d time.Duration, err error) {
+	m.repositoryLatency.WithLabelValues(backend, method).Observe(d.Seconds())
+	if err == nil {
+		return
+	}
+	if appErr, ok := errors.IsAppError(err); ok &&
+		(appErr.Code < http.StatusInternalServerError || appErr.ErrorCode == errors.CodeRequestCancelled) {
+		return
+	}
+	m.repositoryErrors.WithLabelValues(backend, method).Inc()
+}
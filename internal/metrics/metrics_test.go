@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"appointment-service/internal/errors"
+	"appointment-service/internal/model"
+	"appointment-service/internal/repository/memory"
+	"appointment-service/internal/service"
+	"context"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestInstrumentation tests the repository and service decorators.
+//
+// It includes the following test cases:
+//
+// * Bookings created, singly and by import
+// * Conflicts by reason and rule violations by rule, parse errors excluded
+// * Repository latency by method, with not found not counted as an error
+// * Availability latency, and the /metrics exposition with every metric in the namespace
+func TestInstrumentation(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	hours := &service.StaticWorkingHours{Default: model.WorkingHours{Location: time.UTC, Start: 8 * time.Hour, End: 18 * time.Hour}}
+
+	m := New()
+	repo, err := InstrumentRepository(memory.New(logger), "memory", m)
+	require.NoError(t, err)
+	svc := InstrumentService(service.NewAppointmentService(repo, hours, service.DefaultRules(), logger), m)
+
+	at := func(hour, minute int) time.Time { return time.Date(2025, 6, 2, hour, minute, 0, 0, time.UTC) }
+	booking := func(user int64, hour, minute int) model.Appointment {
+		return model.Appointment{TrainerId: 1, UserId: user, StartTime: at(hour, minute), EndTime: at(hour, minute).Add(30 * time.Minute)}
+	}
+
+	_, err = svc.Create(ctx, booking(2, 10, 0))
+	require.NoError(t, err)
+	_, err = svc.Create(ctx, booking(3, 10, 0))
+	require.Error(t, err)
+	long := booking(3, 11, 0)
+	long.EndTime = at(12, 0)
+	_, err = svc.Create(ctx, long)
+	require.Error(t, err)
+
+	_, err = svc.Import(ctx, []model.ImportRow{
+		{Line: 2, Appointment: booking(4, 13, 0)},
+		{Line: 3, Appointment: booking(5, 13, 0)},
+		{Line: 4, Err: errors.ValidationError("row is malformed")},
+	}, model.ImportOptions{Mode: model.ImportPartial})
+	require.NoError(t, err)
+
+	_, err = svc.Get(ctx, 99)
+	require.Error(t, err)
+	_, err = svc.GetAvailability(ctx, 1, at(0, 0), at(23, 0))
+	require.NoError(t, err)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.bookingsCreated))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.bookingConflicts.WithLabelValues("TRAINER_UNAVAILABLE")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.validationFailures.WithLabelValues("INVALID_DURATION")))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.validationFailures))
+	assert.Equal(t, 0, testutil.CollectAndCount(m.repositoryErrors))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.availabilityLatency))
+
+	m.ObserveRequest("GET", "/api/v1/appointments/:id", 200, time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Contains(t, rec.Body.String(), `appointments_http_requests_total{method="GET",route="/api/v1/appointments/:id",status="200"} 1`)
+	assert.Contains(t, rec.Body.String(), `appointments_http_request_duration_seconds_count{method="GET",route="/api/v1/appointments/:id"} 1`)
+	assert.Contains(t, rec.Body.String(), `appointments_repository_query_duration_seconds_count{backend="memory",method="Get"} 1`)
+	assert.Contains(t, rec.Body.String(), `appointments_repository_query_duration_seconds_count{backend="memory",method="CreateBatch"} 1`)
+}
@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"appointment-service/internal/model"
+	"appointment-service/internal/repository"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// InstrumentRepository wraps repo so every call's latency and failures are
+// recorded under the given backend name. If repo is backed by a *sql.DB its
+// pool statistics are exported too.
+func InstrumentRepository(repo repository.AppointmentRepository, backend string, m *Metrics) (repository.AppointmentRepository, error) {
+	if db, ok := repo.(interface{ DB() *sql.DB }); ok {
+		if err := m.RegisterDB(backend, db.DB()); err != nil {
+			return nil, err
+		}
+	}
+	return &instrumentedRepository{repo: repo, backend: backend, metrics: m}, nil
+}
+
+type instrumentedRepository struct {
+	repo    repository.AppointmentRepository
+	backend string
+	metrics *Metrics
+}
+
+// observe starts timing a call, and returns the function recording it with
+// the error *err holds by then. Use as defer r.observe("Get", &err)().
+func (r *instrumentedRepository) observe(method string, err *error) func() {
+	start := time.Now()
+	return func() {
+		r.metrics.ObserveQuery(r.backend, method, time.Since(start), *err)
+	}
+}
+
+func (r *instrumentedRepository) List(ctx context.Context, query model.AppointmentQuery) (page *model.AppointmentPage, err error) {
+	defer r.observe("List", &err)()
+	return r.repo.List(ctx, query)
+}
+
+func (r *instrumentedRepository) ListClientAppointments(ctx context.Context, userID int64, query model.AppointmentQuery) (page *model.AppointmentPage, err error) {
+	defer r.observe("ListClientAppointments", &err)()
+	return r.repo.ListClientAppointments(ctx, userID, query)
+}
+
+func (r *instrumentedRepository) Stream(ctx context.Context, query model.AppointmentQuery, fn func(model.Appointment) error) (err error) {
+	defer r.observe("Stream", &err)()
+	return r.repo.Stream(ctx, query, fn)
+}
+
+func (r *instrumentedRepository) Get(ctx context.Context, id int64) (apt *model.Appointment, err error) {
+	defer r.observe("Get", &err)()
+	return r.repo.Get(ctx, id)
+}
+
+func (r *instrumentedRepository) Create(ctx context.Context, appointment model.Appointment) (apt *model.Appointment, err error) {
+	defer r.observe("Create", &err)()
+	return r.repo.Create(ctx, appointment)
+}
+
+func (r *instrumentedRepository) CreateBatch(ctx context.Context, appointments []model.Appointment) (created []model.Appointment, err error) {
+	defer r.observe("CreateBatch", &err)()
+	return r.repo.CreateBatch(ctx, appointments)
+}
+
+func (r *instrumentedRepository) Update(ctx context.Context, appointment model.Appointment) (apt *model.Appointment, err error) {
+	defer r.observe("Update", &err)()
+	return r.repo.Update(ctx, appointment)
+}
+
+func (r *instrumentedRepository) Delete(ctx context.Context, id int64) (err error) {
+	defer r.observe("Delete", &err)()
+	return r.repo.Delete(ctx, id)
+}
+
+func (r *instrumentedRepository) BookingStats(ctx context.Context, query model.BookingStatsQuery) (stats []model.BookingStats, err error) {
+	defer r.observe("BookingStats", &err)()
+	return r.repo.BookingStats(ctx, query)
+}
+
+func (r *instrumentedRepository) GetTrainerBookings(ctx context.Context, trainerID int64, startsAt, endsAt time.Time) (bookings []model.Appointment, err error) {
+	defer r.observe("GetTrainerBookings", &err)()
+	return r.repo.GetTrainerBookings(ctx, trainerID, startsAt, endsAt)
+}
+
+func (r *instrumentedRepository) GetClientBookings(ctx context.Context, clientID int64, startsAt, endsAt time.Time) (bookings []model.Appointment, err error) {
+	defer r.observe("GetClientBookings", &err)()
+	return r.repo.GetClientBookings(ctx, clientID, startsAt, endsAt)
+}
+
+func (r *instrumentedRepository) Ping(ctx context.Context) (err error) {
+	defer r.observe("Ping", &err)()
+	return r.repo.Ping(ctx)
+}
+
+func (r *instrumentedRepository) Close() error {
+	return r.repo.Close()
+}
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"appointment-service/internal/model"
+	"appointment-service/internal/service"
+	"context"
+	"time"
+)
+
+// InstrumentService wraps svc so booking outcomes and availability latency
+// are recorded. Calls are passed through unchanged.
+func InstrumentService(svc service.AppointmentServicer, m *Metrics) service.AppointmentServicer {
+	return &instrumentedService{AppointmentServicer: svc, metrics: m}
+}
+
+// instrumentedService embeds the service, so methods without metrics of
+// their own are passed through as they are
+type instrumentedService struct {
+	service.AppointmentServicer
+	metrics *Metrics
+}
+
+func (s *instrumentedService) Create(ctx context.Context, appointment model.Appointment) (*model.Appointment, error) {
+	created, err := s.AppointmentServicer.Create(ctx, appointment)
+	if err != nil {
+		s.metrics.BookingRejected(err)
+		return nil, err
+	}
+	s.metrics.BookingsCreated(1)
+	return created, nil
+}
+
+// Import counts the imported appointments, and the rows rejected by a
+// conflict or rule. Rows that could not even be parsed are not bookings.
+func (s *instrumentedService) Import(ctx context.Context, rows []model.ImportRow, opts model.ImportOptions) (*model.ImportReport, error) {
+	report, err := s.AppointmentServicer.Import(ctx, rows, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, result := range report.Rows {
+		if result.Err != nil && rows[i].Err == nil {
+			s.metrics.BookingRejected(result.Err)
+		}
+	}
+	s.metrics.BookingsCreated(report.Imported)
+	return report, nil
+}
+
+func (s *instrumentedService) Reschedule(ctx context.Context, id int64, startTime, endTime time.Time, version int64) (*model.Appointment, error) {
+	rescheduled, err := s.AppointmentServicer.Reschedule(ctx, id, startTime, endTime, version)
+	if err != nil {
+		s.metrics.BookingRejected(err)
+	}
+	return rescheduled, err
+}
+
+func (s *instrumentedService) GetAvailability(ctx context.Context, trainerID int64, windowStartsAt time.Time, windowEndsAt time.Time) ([]model.TimeSlot, error) {
+	defer s.observe("availability", time.Now())
+	return s.AppointmentServicer.GetAvailability(ctx, trainerID, windowStartsAt, windowEndsAt)
+}
+
+func (s *instrumentedService) GetCalendar(ctx context.Context, trainerID int64, from, to time.Time, loc *time.Location) (*model.Calendar, error) {
+	defer s.observe("calendar", time.Now())
+	return s.AppointmentServicer.GetCalendar(ctx, trainerID, from, to, loc)
+}
+
+func (s *instrumentedService) Utilization(ctx context.Context, query model.UtilizationQuery) (*model.UtilizationReport, error) {
+	defer s.observe("utilization", time.Now())
+	return s.AppointmentServicer.Utilization(ctx, query)
+}
+
+func (s *instrumentedService) observe(operation string, start time.Time) {
+	s.metrics.ObserveAvailability(operation, time.Since(start))
+}
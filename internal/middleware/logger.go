@@ -1,31 +1,55 @@
 package middleware
 
 import (
+	"appointment-service/internal/logger"
 	"log/slog"
+	"net/url"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func GinLogger(logger *slog.Logger) gin.HandlerFunc {
+// GinLogger logs every completed request, with the request's logger when
+// RequestID ran before it. Query parameters are logged one by one, e.g.
+// query.tz, so the logger can redact them by name.
+func GinLogger(base *slog.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
-		query := c.Request.URL.RawQuery
+		query := c.Request.URL.Query()
 
 		c.Next()
 
 		latency := time.Since(start)
 		status := c.Writer.Status()
 
-		logger.Info("request completed",
+		ctx := c.Request.Context()
+		logger.FromContext(ctx, base).InfoContext(ctx, "request completed",
 			"status", status,
 			"method", c.Request.Method,
 			"path", path,
-			"query", query,
+			slog.Group("query", queryAttrs(query)...),
 			"latency_ms", latency.Milliseconds(),
 			"client_ip", c.ClientIP(),
 			"user_agent", c.Request.UserAgent(),
 		)
 	}
 }
+
+// queryAttrs returns the query parameters in name order, repeated ones
+// joined by commas
+func queryAttrs(query url.Values) []any {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	attrs := make([]any, len(names))
+	for i, name := range names {
+		attrs[i] = slog.String(name, strings.Join(query[name], ","))
+	}
+	return attrs
+}
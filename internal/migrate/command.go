@@ -0,0 +1,70 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Usage describes the commands RunCommand accepts
+const Usage = `commands:
+  up              apply all pending migrations
+  down [N]        roll back the last N migrations (default 1)
+  status          show the applied version and pending migrations
+  force VERSION   mark VERSION as cleanly applied without running anything,
+                  after repairing a dirty schema by hand (0 clears it)`
+
+// RunCommand runs one migrate command line, e.g. "down 2", writing any
+// report to w
+func RunCommand(ctx context.Context, m *Migrator, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing migrate command\n%s", Usage)
+	}
+
+	switch command, params := args[0], args[1:]; command {
+	case "up":
+		return m.Up(ctx)
+
+	case "down":
+		steps := 1
+		if len(params) > 0 {
+			var err error
+			if steps, err = strconv.Atoi(params[0]); err != nil {
+				return fmt.Errorf("invalid step count %q", params[0])
+			}
+		}
+		return m.Down(ctx, steps)
+
+	case "status":
+		status, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		PrintStatus(w, status)
+		return nil
+
+	case "force":
+		if len(params) == 0 {
+			return fmt.Errorf("missing version\n%s", Usage)
+		}
+		version, err := strconv.ParseUint(params[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", params[0])
+		}
+		return m.Force(ctx, version)
+
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", command, Usage)
+	}
+}
+
+// PrintStatus writes the status one field per line
+func PrintStatus(w io.Writer, status Status) {
+	fmt.Fprintf(w, "version: %d\n", status.Version)
+	fmt.Fprintf(w, "latest:  %d\n", status.Latest)
+	fmt.Fprintf(w, "dirty:   %t\n", status.Dirty)
+	for _, m := range status.Pending {
+		fmt.Fprintf(w, "pending: %d_%s\n", m.Version, m.Name)
+	}
+}
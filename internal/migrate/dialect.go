@@ -0,0 +1,54 @@
+package migrate
+
+import (
+	"appointment-service/internal/config"
+	"fmt"
+)
+
+// Dialect holds the SQL a database needs to track and lock migrations
+type Dialect struct {
+	// Name is the dialect's directory in the embedded migrations
+	Name string
+
+	createTable  string
+	lock         string
+	selectState  string
+	clearVersion string
+	setVersion   string
+}
+
+// SQLite3 takes the lock with a write that matches no rows. That promotes
+// the transaction to SQLite's single RESERVED write lock, so other
+// instances wait on their busy timeout until this run commits.
+var SQLite3 = Dialect{
+	Name:         "sqlite3",
+	createTable:  `CREATE TABLE IF NOT EXISTS schema_migrations (version uint64 NOT NULL PRIMARY KEY, dirty bool NOT NULL)`,
+	lock:         `DELETE FROM schema_migrations WHERE 0 = 1`,
+	selectState:  `SELECT version, dirty FROM schema_migrations LIMIT 1`,
+	clearVersion: `DELETE FROM schema_migrations`,
+	setVersion:   `INSERT INTO schema_migrations (version, dirty) VALUES (?, ?)`,
+}
+
+// Postgres takes a transaction scoped advisory lock, released when the run
+// commits or rolls back
+var Postgres = Dialect{
+	Name:         "postgres",
+	createTable:  `CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)`,
+	lock:         `SELECT pg_advisory_xact_lock(7318640912)`,
+	selectState:  `SELECT version, dirty FROM schema_migrations LIMIT 1`,
+	clearVersion: `DELETE FROM schema_migrations`,
+	setVersion:   `INSERT INTO schema_migrations (version, dirty) VALUES ($1, $2)`,
+}
+
+// DialectFor returns the dialect of a storage type. Memory storage has no
+// schema and so no dialect.
+func DialectFor(storageType config.StorageType) (Dialect, error) {
+	switch storageType {
+	case config.SqlLite3:
+		return SQLite3, nil
+	case config.Postgres:
+		return Postgres, nil
+	default:
+		return Dialect{}, fmt.Errorf("storage type %q has no schema to migrate", storageType)
+	}
+}
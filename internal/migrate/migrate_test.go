@@ -0,0 +1,220 @@
+package migrate
+
+import (
+	"appointment-service/migrations"
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// TestLoad tests reading migrations from a directory.
+//
+// It includes the following test cases:
+//
+// * Files are paired by version and ordered numerically
+// * A version without an up file is rejected
+// * Unexpected file names are rejected
+// * Every dialect carries the same migrations
+func TestLoad(t *testing.T) {
+	t.Run("ordered by version", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"m/10_later.up.sql":   {Data: []byte("later up")},
+			"m/2_first.up.sql":    {Data: []byte("first up")},
+			"m/2_first.down.sql":  {Data: []byte("first down")},
+			"m/10_later.down.sql": {Data: []byte("later down")},
+		}
+
+		loaded, err := Load(fsys, "m")
+		require.NoError(t, err)
+		assert.Equal(t, []Migration{
+			{Version: 2, Name: "first", Up: "first up", Down: "first down"},
+			{Version: 10, Name: "later", Up: "later up", Down: "later down"},
+		}, loaded)
+	})
+
+	t.Run("missing up file", func(t *testing.T) {
+		fsys := fstest.MapFS{"m/1_only.down.sql": {Data: []byte("down")}}
+
+		_, err := Load(fsys, "m")
+		assert.ErrorContains(t, err, "no up file")
+	})
+
+	t.Run("unexpected file name", func(t *testing.T) {
+		fsys := fstest.MapFS{"m/create.sql": {Data: []byte("up")}}
+
+		_, err := Load(fsys, "m")
+		assert.ErrorContains(t, err, "unexpected migration file name")
+	})
+
+	t.Run("dialects match", func(t *testing.T) {
+		sqlite, err := Load(migrations.FS, SQLite3.Name)
+		require.NoError(t, err)
+		postgres, err := Load(migrations.FS, Postgres.Name)
+		require.NoError(t, err)
+
+		require.Len(t, postgres, len(sqlite))
+		for i := range sqlite {
+			assert.Equal(t, sqlite[i].Version, postgres[i].Version)
+			assert.Equal(t, sqlite[i].Name, postgres[i].Name)
+			assert.NotEmpty(t, sqlite[i].Down)
+			assert.NotEmpty(t, postgres[i].Down)
+		}
+	})
+}
+
+// TestMigrator tests applying the embedded sqlite3 migrations.
+//
+// It includes the following test cases:
+//
+// * Up applies everything and is a no-op the second time
+// * Down rolls back the given number of steps and Up reapplies them
+// * A dirty schema blocks Up until Force marks it repaired
+// * A schema ahead of this build is refused
+// * A failing migration rolls back the whole run
+// * Concurrent instances apply each migration exactly once
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("up", func(t *testing.T) {
+		db, migrator := newMigrator(t, filepath.Join(t.TempDir(), "test.db"))
+
+		status, err := migrator.Status(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, status.Version)
+		assert.Len(t, status.Pending, len(migrator.Migrations()))
+
+		require.NoError(t, migrator.Up(ctx))
+		require.NoError(t, migrator.Up(ctx))
+
+		status, err = migrator.Status(ctx)
+		require.NoError(t, err)
+		assert.True(t, status.UpToDate())
+		assert.Equal(t, uint64(4), status.Version)
+		assert.True(t, hasColumn(t, db, "version"))
+	})
+
+	t.Run("down and up again", func(t *testing.T) {
+		db, migrator := newMigrator(t, filepath.Join(t.TempDir(), "test.db"))
+		require.NoError(t, migrator.Up(ctx))
+
+		require.NoError(t, migrator.Down(ctx, 3))
+		status, err := migrator.Status(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), status.Version)
+		assert.False(t, hasColumn(t, db, "version"))
+
+		require.NoError(t, migrator.Down(ctx, 5))
+		status, err = migrator.Status(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, status.Version)
+
+		require.NoError(t, migrator.Up(ctx))
+		assert.True(t, hasColumn(t, db, "version"))
+	})
+
+	t.Run("dirty", func(t *testing.T) {
+		db, migrator := newMigrator(t, filepath.Join(t.TempDir(), "test.db"))
+		require.NoError(t, migrator.Up(ctx))
+		_, err := db.Exec("UPDATE schema_migrations SET dirty = 1")
+		require.NoError(t, err)
+
+		var dirty *DirtyError
+		require.ErrorAs(t, migrator.Up(ctx), &dirty)
+		assert.Equal(t, uint64(4), dirty.Version)
+		require.ErrorAs(t, migrator.Down(ctx, 1), &dirty)
+
+		require.NoError(t, migrator.Force(ctx, 4))
+		require.NoError(t, migrator.Up(ctx))
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		db, migrator := newMigrator(t, filepath.Join(t.TempDir(), "test.db"))
+		require.NoError(t, migrator.Up(ctx))
+		_, err := db.Exec("UPDATE schema_migrations SET version = 99")
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, migrator.Up(ctx), ErrUnknownVersion)
+		assert.ErrorIs(t, migrator.Force(ctx, 99), ErrUnknownVersion)
+
+		status, err := migrator.Status(ctx)
+		require.NoError(t, err)
+		assert.False(t, status.UpToDate())
+	})
+
+	t.Run("failing migration", func(t *testing.T) {
+		db, migrator := newMigrator(t, filepath.Join(t.TempDir(), "test.db"))
+		migrator.migrations = []Migration{
+			{Version: 1, Name: "good", Up: "CREATE TABLE good (id INTEGER)"},
+			{Version: 2, Name: "bad", Up: "CREATE TABLE bad (id INTEGER); NOT SQL"},
+		}
+
+		require.Error(t, migrator.Up(ctx))
+
+		status, err := migrator.Status(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, status.Version)
+		assert.False(t, status.Dirty)
+		var tables int
+		require.NoError(t, db.QueryRow("SELECT count(*) FROM sqlite_master WHERE name IN ('good', 'bad')").Scan(&tables))
+		assert.Zero(t, tables)
+	})
+
+	t.Run("concurrent instances", func(t *testing.T) {
+		dbPath := filepath.Join(t.TempDir(), "test.db")
+
+		const instances = 4
+		errs := make([]error, instances)
+		var wg sync.WaitGroup
+		for i := range instances {
+			_, migrator := newMigrator(t, dbPath)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = migrator.Up(ctx)
+			}()
+		}
+		wg.Wait()
+
+		for _, err := range errs {
+			assert.NoError(t, err)
+		}
+		_, migrator := newMigrator(t, dbPath)
+		status, err := migrator.Status(ctx)
+		require.NoError(t, err)
+		assert.True(t, status.UpToDate())
+	})
+}
+
+// newMigrator opens its own connection pool to the sqlite3 database at
+// dbPath, as a separate instance of the service would
+func newMigrator(t *testing.T, dbPath string) (*sql.DB, *Migrator) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", dbPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := New(db, SQLite3, discard)
+	require.NoError(t, err)
+	return db, migrator
+}
+
+func hasColumn(t *testing.T, db *sql.DB, column string) bool {
+	t.Helper()
+
+	var count int
+	err := db.QueryRow("SELECT count(*) FROM pragma_table_info('appointments') WHERE name = ?", column).Scan(&count)
+	require.NoError(t, err)
+	return count > 0
+}
@@ -0,0 +1,229 @@
+package migrate
+
+import (
+	"appointment-service/migrations"
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// DirtyError means a migration failed part way outside of this package,
+// e.g. through the golang-migrate CLI, and the schema is in an unknown
+// state. Nothing runs until it is repaired by hand and marked with Force.
+type DirtyError struct {
+	Version uint64
+}
+
+func (e *DirtyError) Error() string {
+	return fmt.Sprintf("schema is dirty at version %d, repair it by hand then run `migrate force <version>`", e.Version)
+}
+
+// ErrUnknownVersion means the database was migrated by a newer build
+var ErrUnknownVersion = stderrors.New("database schema version is unknown to this build")
+
+// Status describes how far the database schema is migrated
+type Status struct {
+	Version uint64
+	Dirty   bool
+	Latest  uint64
+	Pending []Migration
+}
+
+// UpToDate reports whether every migration is applied cleanly
+func (s Status) UpToDate() bool {
+	return !s.Dirty && len(s.Pending) == 0 && s.Version == s.Latest
+}
+
+// Migrator applies the embedded migrations of one dialect to a database
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+	logger     *slog.Logger
+}
+
+// New creates a Migrator for the embedded migrations of the dialect
+func New(db *sql.DB, dialect Dialect, logger *slog.Logger) (*Migrator, error) {
+	loaded, err := Load(migrations.FS, dialect.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: loaded, logger: logger}, nil
+}
+
+// Migrations returns the known migrations, ordered by version
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Status reports the applied version and the migrations still pending
+func (m *Migrator) Status(ctx context.Context) (Status, error) {
+	var status Status
+	err := m.withLock(ctx, func(tx *sql.Tx, version uint64, dirty bool) error {
+		status = m.status(version, dirty)
+		return nil
+	})
+	return status, err
+}
+
+// Up applies every pending migration. Returns a DirtyError if the schema is
+// dirty and ErrUnknownVersion if it is ahead of this build.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.withLock(ctx, func(tx *sql.Tx, version uint64, dirty bool) error {
+		if err := m.checkState(version, dirty); err != nil {
+			return err
+		}
+
+		for _, migration := range m.status(version, dirty).Pending {
+			if err := m.apply(ctx, tx, migration.Version, migration.Name, "up", migration.Up); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Down rolls back the last steps applied migrations, or all of them if
+// there are fewer
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("steps must be at least 1, got %d", steps)
+	}
+
+	return m.withLock(ctx, func(tx *sql.Tx, version uint64, dirty bool) error {
+		if err := m.checkState(version, dirty); err != nil {
+			return err
+		}
+
+		for i := m.index(version); i >= 0 && steps > 0; i, steps = i-1, steps-1 {
+			migration := m.migrations[i]
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+			}
+
+			var previous uint64
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+			if err := m.apply(ctx, tx, previous, migration.Name, "down", migration.Down); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Force records the schema as cleanly migrated to version without running
+// anything. It is how a dirty schema is marked repaired. Version 0 means no
+// migrations applied.
+func (m *Migrator) Force(ctx context.Context, version uint64) error {
+	if version != 0 && m.index(version) < 0 {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	return m.withLock(ctx, func(tx *sql.Tx, _ uint64, _ bool) error {
+		return m.setVersion(ctx, tx, version)
+	})
+}
+
+// withLock runs fn in a transaction holding the migration lock, with the
+// version and dirty flag as they are once the lock is held
+func (m *Migrator) withLock(ctx context.Context, fn func(tx *sql.Tx, version uint64, dirty bool) error) error {
+	if _, err := m.db.ExecContext(ctx, m.dialect.createTable); err != nil {
+		return fmt.Errorf("creating schema_migrations: %w", err)
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("starting migration transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, m.dialect.lock); err != nil {
+		return fmt.Errorf("taking migration lock: %w", err)
+	}
+
+	var version uint64
+	var dirty bool
+	err = tx.QueryRowContext(ctx, m.dialect.selectState).Scan(&version, &dirty)
+	if err != nil && !stderrors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("reading schema version: %w", err)
+	}
+
+	if err := fn(tx, version, dirty); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing migrations: %w", err)
+	}
+	return nil
+}
+
+// apply runs one migration body and records the version it leaves behind
+func (m *Migrator) apply(ctx context.Context, tx *sql.Tx, version uint64, name, direction, body string) error {
+	started := time.Now()
+	if _, err := tx.ExecContext(ctx, body); err != nil {
+		return fmt.Errorf("migration %s %s: %w", name, direction, err)
+	}
+	if err := m.setVersion(ctx, tx, version); err != nil {
+		return err
+	}
+
+	m.logger.Info("Applied migration",
+		"dialect", m.dialect.Name,
+		"migration", name,
+		"direction", direction,
+		"schema_version", version,
+		"duration", time.Since(started))
+	return nil
+}
+
+// setVersion records version as cleanly applied, 0 clears it
+func (m *Migrator) setVersion(ctx context.Context, tx *sql.Tx, version uint64) error {
+	if _, err := tx.ExecContext(ctx, m.dialect.clearVersion); err != nil {
+		return fmt.Errorf("clearing schema version: %w", err)
+	}
+	if version == 0 {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, m.dialect.setVersion, version, false); err != nil {
+		return fmt.Errorf("recording schema version: %w", err)
+	}
+	return nil
+}
+
+// checkState refuses to migrate a dirty schema or one ahead of this build
+func (m *Migrator) checkState(version uint64, dirty bool) error {
+	if dirty {
+		return &DirtyError{Version: version}
+	}
+	if version != 0 && m.index(version) < 0 {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+	return nil
+}
+
+func (m *Migrator) status(version uint64, dirty bool) Status {
+	status := Status{Version: version, Dirty: dirty}
+	for _, migration := range m.migrations {
+		status.Latest = migration.Version
+		if migration.Version > version {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+	return status
+}
+
+// index returns the position of version in the migrations, or -1
+func (m *Migrator) index(version uint64) int {
+	for i, migration := range m.migrations {
+		if migration.Version == version {
+			return i
+		}
+	}
+	return -1
+}
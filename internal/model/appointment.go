@@ -7,12 +7,23 @@ import (
 )
 
 // Appointment represents a scheduled meeting between a user and a trainer.
+// Version is bumped by the repository on every update and is used for
+// optimistic concurrency control.
 type Appointment struct {
 	Id        int64
 	StartTime time.Time
 	EndTime   time.Time
 	TrainerId int64
 	UserId    int64
+	Status    AppointmentStatus
+	Version   int64
+}
+
+// Overlaps reports whether the appointment shares any time with the half-open
+// range [startsAt, endsAt). Appointments occupy [StartTime, EndTime), so
+// back-to-back appointments do not overlap.
+func (a *Appointment) Overlaps(startsAt, endsAt time.Time) bool {
+	return a.StartTime.Before(endsAt) && startsAt.Before(a.EndTime)
 }
 
 // Defines a type for validation rules, then we can pass
@@ -21,13 +32,23 @@ type ValidationRule func(a *Appointment) error
 
 // MustBeThirtyMinutes checks if the duration of the given appointment is exactly 30 minutes.
 func MustBeThirtyMinutes(a *Appointment) error {
-	duration := a.EndTime.Sub(a.StartTime)
-	if duration != 30*time.Minute {
-		return errors.ValidationError(
-			fmt.Sprintf("appointment must be exactly 30 minutes, got %v", duration),
-		)
+	return MustLast(30 * time.Minute)(a)
+}
+
+// MustLast returns a rule checking that the appointment lasts exactly d
+func MustLast(d time.Duration) ValidationRule {
+	return func(a *Appointment) error {
+		duration := a.EndTime.Sub(a.StartTime)
+		if duration != d {
+			return errors.ValidationError(
+				fmt.Sprintf("appointment must be exactly %g minutes, got %v", d.Minutes(), duration),
+			).WithCode(errors.CodeInvalidDuration).
+				WithDetail("field", "end_time").
+				WithDetail("expected_minutes", d.Minutes()).
+				WithDetail("actual_minutes", duration.Minutes())
+		}
+		return nil
 	}
-	return nil
 }
 
 // MustBeDuringBusinessHours checks if the appointment's start and end times
@@ -42,16 +63,41 @@ func MustBeDuringBusinessHours(a *Appointment) error {
 	businessEnd := time.Date(year, month, day, 17, 0, 0, 0, loc)
 
 	if startLocal.Before(businessStart) || startLocal.After(businessEnd) {
-		return errors.ValidationError("appointment must start between 8am and 5pm Pacific")
+		return errors.ValidationError("appointment must start between 8am and 5pm Pacific").
+			WithCode(errors.CodeOutsideBusinessHours).
+			WithDetail("field", "start_time")
 	}
 
 	if endLocal.Before(businessStart) || endLocal.After(businessEnd) {
-		return errors.ValidationError("appointment must end between 8am and 5pm Pacific")
+		return errors.ValidationError("appointment must end between 8am and 5pm Pacific").
+			WithCode(errors.CodeOutsideBusinessHours).
+			WithDetail("field", "end_time")
 	}
 
 	return nil
 }
 
+// MustBeWithinWorkingHours returns a rule checking that the appointment falls
+// within the given working hours, in the working hours' own time zone.
+func MustBeWithinWorkingHours(hours WorkingHours) ValidationRule {
+	return func(a *Appointment) error {
+		if !hours.Contains(a.StartTime, a.EndTime) {
+			return errors.ValidationError(fmt.Sprintf(
+				"appointment must be within working hours, %s to %s %s",
+				formatWallClock(hours.Start), formatWallClock(hours.End), hours.Location)).
+				WithCode(errors.CodeOutsideBusinessHours).
+				WithDetail("field", "start_time").
+				WithDetail("time_zone", hours.Location.String())
+		}
+		return nil
+	}
+}
+
+// formatWallClock formats an offset from midnight as a 24 hour clock time, e.g. 08:00
+func formatWallClock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
 // DefaultValidationRules is a set of validation rules that can be used
 // should you not want to define your own set of rules.
 var DefaultValidationRules = []ValidationRule{
@@ -59,7 +105,8 @@ var DefaultValidationRules = []ValidationRule{
 	MustBeDuringBusinessHours,
 }
 
-// Validate runs the given validation rules on the appointment.
+// Validate runs the given validation rules on the appointment, stopping at
+// the first failing rule (fail-fast).
 // Allows callers/clients to define the set of rules appropriate for the context.
 func (a *Appointment) Validate(rules []ValidationRule) error {
 	for _, validationRule := range rules {
@@ -69,3 +116,18 @@ func (a *Appointment) Validate(rules []ValidationRule) error {
 	}
 	return nil
 }
+
+// ValidateAll runs every given validation rule on the appointment and reports
+// all failures together as one aggregated validation error, so a caller can
+// fix every problem in a single round-trip. Returns nil if all rules pass.
+func (a *Appointment) ValidateAll(rules []ValidationRule) error {
+	var violations []errors.Violation
+	for _, validationRule := range rules {
+		violations = append(violations, errors.ViolationsOf(validationRule(a))...)
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return errors.ValidationErrors(violations)
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"appointment-service/internal/errors"
 	"testing"
 	"time"
 
@@ -201,3 +202,68 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+// TestValidateAll tests the aggregate validation mode for appointments.
+//
+// It includes the following test cases:
+//
+// * Valid appointment meeting all validation rules
+// * Appointment failing a single rule
+// * Appointment failing both the duration and the business hours rules
+//
+// Note: Unlike Validate, ValidateAll must report every failing rule, each as
+// its own violation with the rule's code and field, so that a client can fix
+// all problems in a single round-trip.
+func TestValidateAll(t *testing.T) {
+	loc, _ := time.LoadLocation("America/Los_Angeles")
+
+	tests := []struct {
+		name      string
+		startTime time.Time
+		endTime   time.Time
+		wantCodes []errors.ErrorCode
+	}{
+		{
+			name:      "valid appointment with all rules",
+			startTime: time.Date(2023, 10, 10, 9, 0, 0, 0, loc),
+			endTime:   time.Date(2023, 10, 10, 9, 30, 0, 0, loc),
+			wantCodes: nil,
+		},
+		{
+			name:      "appointment less than 30 minutes",
+			startTime: time.Date(2023, 10, 10, 9, 0, 0, 0, loc),
+			endTime:   time.Date(2023, 10, 10, 9, 20, 0, 0, loc),
+			wantCodes: []errors.ErrorCode{errors.CodeInvalidDuration},
+		},
+		{
+			name:      "appointment with bad duration and outside business hours",
+			startTime: time.Date(2023, 10, 10, 6, 0, 0, 0, loc),
+			endTime:   time.Date(2023, 10, 10, 6, 45, 0, 0, loc),
+			wantCodes: []errors.ErrorCode{errors.CodeInvalidDuration, errors.CodeOutsideBusinessHours},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appointment := &Appointment{
+				StartTime: tt.startTime,
+				EndTime:   tt.endTime,
+			}
+			err := appointment.ValidateAll(DefaultValidationRules)
+			if tt.wantCodes == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var gotCodes []errors.ErrorCode
+			for _, violation := range errors.ViolationsOf(err) {
+				assert.NotEmpty(t, violation.Field)
+				gotCodes = append(gotCodes, violation.Code)
+			}
+			assert.Equal(t, tt.wantCodes, gotCodes)
+
+			// Fail-fast mode still stops at the first rule
+			assert.Len(t, errors.ViolationsOf(appointment.Validate(DefaultValidationRules)), 1)
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// MaxCalendarDays caps how many days a single calendar request may cover,
+// enough for a quarter or any month view with its leading and trailing weeks
+const MaxCalendarDays = 92
+
+// Calendar summarises a trainer's availability per local date, in Location
+type Calendar struct {
+	Location *time.Location
+	Days     []CalendarDay
+}
+
+// CalendarDay summarises one local date. Date is local midnight (or the first
+// instant of the day if midnight is skipped by DST). FirstOpen and LastOpen are
+// the start times of the first and last open slots, and are zero when no slot
+// is open. A day is Closed when it has no working hours at all, and
+// FullyBooked when it has working hours but every slot is taken.
+type CalendarDay struct {
+	Date        time.Time
+	OpenSlots   int
+	FirstOpen   time.Time
+	LastOpen    time.Time
+	Closed      bool
+	FullyBooked bool
+}
@@ -0,0 +1,63 @@
+package model
+
+// ImportMode decides what happens to the valid rows of an import that also
+// has rejected rows
+type ImportMode string
+
+const (
+	// ImportAllOrNothing imports nothing unless every row is valid, and then
+	// commits every row in one transaction
+	ImportAllOrNothing ImportMode = "all_or_nothing"
+	// ImportPartial imports every valid row and reports the rest
+	ImportPartial ImportMode = "partial"
+)
+
+const (
+	DefaultImportBatchSize = 100
+	MaxImportBatchSize     = 1000
+	MaxImportRows          = 50000
+
+	// MaxImportBytes caps the size of an import body, and MaxImportLineBytes
+	// the size of an NDJSON line
+	MaxImportBytes     = 32 << 20
+	MaxImportLineBytes = 64 << 10
+)
+
+// ImportOptions controls a bulk import. A zero Mode means all-or-nothing and
+// a zero BatchSize DefaultImportBatchSize. BatchSize only applies to partial
+// imports, an all-or-nothing import is committed in one batch.
+type ImportOptions struct {
+	Mode      ImportMode
+	DryRun    bool
+	BatchSize int
+}
+
+// ImportRow is one row of an import file. Line is the row's line number in
+// the file, and Err is set when the row could not be parsed.
+type ImportRow struct {
+	Line        int
+	Appointment Appointment
+	Err         error
+}
+
+// ImportRowResult is the outcome of one row. Accepted rows passed every
+// check, and have an AppointmentId once they are committed. Err says why a
+// row was rejected.
+type ImportRowResult struct {
+	Line          int
+	Accepted      bool
+	AppointmentId int64
+	Err           error
+}
+
+// ImportReport is the outcome of a bulk import, one result per row in file
+// order. Imported counts the appointments actually stored, which is zero for
+// a dry run.
+type ImportReport struct {
+	Mode     ImportMode
+	DryRun   bool
+	Rows     []ImportRowResult
+	Accepted int
+	Rejected int
+	Imported int
+}
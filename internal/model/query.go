@@ -0,0 +1,118 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// SortOrder is the order appointments are listed in, by start time
+type SortOrder string
+
+const (
+	SortAscending  SortOrder = "asc"
+	SortDescending SortOrder = "desc"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// AppointmentQuery describes which appointments to list and how to page through them.
+// Zero values mean "no filter": a zero TrainerId or UserId matches everyone, a zero
+// StartsAt or EndsAt leaves that side of the time range open.
+//
+// The time range matches appointments whose start time is in [StartsAt, EndsAt).
+// Results are ordered by (StartTime, Id) so that paging is stable even when
+// several appointments start at the same time.
+type AppointmentQuery struct {
+	TrainerId int64
+	UserId    int64
+	StartsAt  time.Time
+	EndsAt    time.Time
+	Statuses  []AppointmentStatus
+	Sort      SortOrder
+	Limit     int
+	After     *PageCursor
+}
+
+// AppointmentPage is one page of a listing. NextCursor is empty on the last page.
+type AppointmentPage struct {
+	Appointments []Appointment
+	NextCursor   string
+}
+
+// PageCursor marks the last appointment of a page; the next page starts right after it.
+type PageCursor struct {
+	StartTime time.Time `json:"s"`
+	Id        int64     `json:"i"`
+}
+
+// CursorAfter builds the cursor pointing just past the given appointment
+func CursorAfter(a Appointment) *PageCursor {
+	return &PageCursor{StartTime: a.StartTime.UTC(), Id: a.Id}
+}
+
+// Encode returns the opaque, URL-safe form of the cursor handed out to clients
+func (c *PageCursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor parses a cursor produced by PageCursor.Encode
+func DecodeCursor(encoded string) (*PageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	var c PageCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// IsAfterCursor reports whether the appointment comes after the cursor in the given sort order
+func (a *Appointment) IsAfterCursor(c *PageCursor, order SortOrder) bool {
+	if c == nil {
+		return true
+	}
+	if a.StartTime.Equal(c.StartTime) {
+		if order == SortDescending {
+			return a.Id < c.Id
+		}
+		return a.Id > c.Id
+	}
+	if order == SortDescending {
+		return a.StartTime.Before(c.StartTime)
+	}
+	return a.StartTime.After(c.StartTime)
+}
+
+// Matches reports whether the appointment satisfies the query's filters.
+// Paging (cursor and limit) is not considered.
+func (q *AppointmentQuery) Matches(a *Appointment) bool {
+	if q.TrainerId != 0 && a.TrainerId != q.TrainerId {
+		return false
+	}
+	if q.UserId != 0 && a.UserId != q.UserId {
+		return false
+	}
+	if !q.StartsAt.IsZero() && a.StartTime.Before(q.StartsAt) {
+		return false
+	}
+	if !q.EndsAt.IsZero() && !a.StartTime.Before(q.EndsAt) {
+		return false
+	}
+	if len(q.Statuses) > 0 {
+		for _, status := range q.Statuses {
+			if a.Status == status {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
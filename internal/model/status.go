@@ -0,0 +1,36 @@
+package model
+
+// AppointmentStatus represents where an appointment is in its lifecycle.
+type AppointmentStatus string
+
+const (
+	StatusScheduled AppointmentStatus = "scheduled"
+	StatusCancelled AppointmentStatus = "cancelled"
+	StatusCompleted AppointmentStatus = "completed"
+	StatusNoShow    AppointmentStatus = "no_show"
+)
+
+// allowedTransitions maps each status to the statuses it may move to.
+// Only scheduled appointments can change; every other status is final.
+var allowedTransitions = map[AppointmentStatus][]AppointmentStatus{
+	StatusScheduled: {StatusCancelled, StatusCompleted, StatusNoShow},
+}
+
+// IsValid reports whether the status is one of the known statuses.
+func (s AppointmentStatus) IsValid() bool {
+	switch s {
+	case StatusScheduled, StatusCancelled, StatusCompleted, StatusNoShow:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo reports whether an appointment in status s may move to next.
+func (s AppointmentStatus) CanTransitionTo(next AppointmentStatus) bool {
+	for _, allowed := range allowedTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package model
+
+import "time"
+
+// ReportPeriod is the length of the periods a utilization report is broken
+// into, in the trainer's local zone. Weeks start on Monday.
+type ReportPeriod string
+
+const (
+	PeriodDay   ReportPeriod = "day"
+	PeriodWeek  ReportPeriod = "week"
+	PeriodMonth ReportPeriod = "month"
+)
+
+// IsValid reports whether p is a known period
+func (p ReportPeriod) IsValid() bool {
+	switch p {
+	case PeriodDay, PeriodWeek, PeriodMonth:
+		return true
+	}
+	return false
+}
+
+// MaxReportDays caps how many days a single utilization report may cover
+const MaxReportDays = 366
+
+// BookingStatsBucket is the granularity of BookingStats. A quarter hour
+// divides every UTC offset in use, so buckets never straddle a local hour.
+const BookingStatsBucket = 15 * time.Minute
+
+// BookingStatsQuery selects the appointments to aggregate: those of TrainerId,
+// or of every trainer when zero, starting in [StartsAt, EndsAt)
+type BookingStatsQuery struct {
+	TrainerId int64
+	StartsAt  time.Time
+	EndsAt    time.Time
+}
+
+// BookingStats aggregates one trainer's appointments with one status whose
+// start times fall in the same BookingStatsBucket. Start is the bucket's start
+// in UTC, and BookedMinutes the appointments' summed length.
+type BookingStats struct {
+	TrainerId     int64
+	Start         time.Time
+	Status        AppointmentStatus
+	Appointments  int
+	BookedMinutes int
+}
+
+// UtilizationQuery asks for the utilization of TrainerId, or of every trainer
+// with appointments when zero, for the local dates From through To, both
+// inclusive, in periods of Period.
+type UtilizationQuery struct {
+	TrainerId int64
+	Period    ReportPeriod
+	From      time.Time
+	To        time.Time
+}
+
+// UtilizationReport holds one entry per trainer, ordered by trainer ID
+type UtilizationReport struct {
+	Period   ReportPeriod
+	Trainers []TrainerUtilization
+}
+
+// TrainerUtilization holds a trainer's periods in order, in Location
+type TrainerUtilization struct {
+	TrainerId int64
+	Location  *time.Location
+	Periods   []PeriodUtilization
+}
+
+// PeriodUtilization summarises one period, [Start, End) in local time. The
+// first and last periods are cut to the report's dates.
+//
+// AvailableMinutes is the working time, BookedMinutes the time taken by
+// appointments that held a slot (scheduled, completed or no-show), and
+// Utilization the share of the one in the other, in percent. Appointments
+// counts those same appointments, Cancellations and NoShows their own
+// statuses. PeakHours are the local hours of day with the most appointments.
+type PeriodUtilization struct {
+	Start            time.Time
+	End              time.Time
+	AvailableMinutes int
+	BookedMinutes    int
+	Utilization      float64
+	Appointments     int
+	Cancellations    int
+	NoShows          int
+	PeakHours        []HourCount
+}
+
+// HourCount is the number of appointments starting in a local hour of day
+type HourCount struct {
+	Hour         int
+	Appointments int
+}
@@ -0,0 +1,180 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WorkingHours describes when a trainer takes appointments, in the trainer's
+// own time zone. Start and End are wall clock offsets from local midnight,
+// e.g. 8h and 17h for 8am to 5pm; End may be 24h for "until midnight".
+// An empty Weekdays means every day of the week is a working day.
+//
+// Working hours are wall clock based: on DST transition days a trainer
+// working 8am to 5pm still starts at 8am local time, the day is just an hour
+// shorter or longer in absolute time. Wall clock times that are skipped by a
+// DST gap simply never occur, and times repeated by a DST overlap occur twice.
+type WorkingHours struct {
+	Location *time.Location
+	Start    time.Duration
+	End      time.Duration
+	Weekdays []time.Weekday
+}
+
+// DefaultWorkingHours returns the service's standard business hours,
+// 8am to 5pm Pacific Time. Fails when the time zone database is missing.
+func DefaultWorkingHours() (WorkingHours, error) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		return WorkingHours{}, fmt.Errorf("failed to load Pacific timezone: %w", err)
+	}
+	return WorkingHours{
+		Location: loc,
+		Start:    8 * time.Hour,
+		End:      17 * time.Hour,
+	}, nil
+}
+
+// ParseTrainerTimezones parses per-trainer time zones of the form
+// "12=Asia/Kolkata,13=America/St_Johns" into loaded locations.
+// An empty string yields no overrides.
+func ParseTrainerTimezones(raw string) (map[int64]*time.Location, error) {
+	timezones := make(map[int64]*time.Location)
+	if strings.TrimSpace(raw) == "" {
+		return timezones, nil
+	}
+
+	for _, entry := range strings.Split(raw, ",") {
+		id, zone, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found {
+			return nil, fmt.Errorf("trainer time zone %q must look like <trainer_id>=<IANA zone>", entry)
+		}
+
+		trainerID, err := strconv.ParseInt(strings.TrimSpace(id), 10, 64)
+		if err != nil || trainerID <= 0 {
+			return nil, fmt.Errorf("trainer time zone %q has an invalid trainer id", entry)
+		}
+
+		loc, err := time.LoadLocation(strings.TrimSpace(zone))
+		if err != nil {
+			return nil, fmt.Errorf("trainer time zone %q: %w", entry, err)
+		}
+		timezones[trainerID] = loc
+	}
+
+	return timezones, nil
+}
+
+// IsWorkingDay reports whether the given local weekday is a working day
+func (w WorkingHours) IsWorkingDay(day time.Weekday) bool {
+	if len(w.Weekdays) == 0 {
+		return true
+	}
+	for _, d := range w.Weekdays {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains reports whether [start, end) lies within working hours of the local
+// date on which it starts
+func (w WorkingHours) Contains(start, end time.Time) bool {
+	localStart := start.In(w.Location)
+	if !w.IsWorkingDay(localStart.Weekday()) {
+		return false
+	}
+
+	year, month, day := localStart.Date()
+	return wallClockSince(year, month, day, localStart) >= w.Start &&
+		wallClockSince(year, month, day, end.In(w.Location)) <= w.End
+}
+
+// IsAligned reports whether t falls on a slot boundary of the local wall
+// clock, e.g. :00 or :30 for 30 minute slots
+func (w WorkingHours) IsAligned(t time.Time, slotLength time.Duration) bool {
+	local := t.In(w.Location)
+	year, month, day := local.Date()
+	return wallClockSince(year, month, day, local)%slotLength == 0
+}
+
+// NextAligned returns the first instant at or after t that falls on a slot
+// boundary of the local wall clock
+func (w WorkingHours) NextAligned(t time.Time, slotLength time.Duration) time.Time {
+	for {
+		local := t.In(w.Location)
+		year, month, day := local.Date()
+		offset := wallClockSince(year, month, day, local) % slotLength
+		if offset == 0 {
+			return t
+		}
+
+		// Moving forward by the missing wall clock time lands on the boundary,
+		// unless a UTC offset change sits in between, hence the re-check
+		t = t.Add(slotLength - offset)
+	}
+}
+
+// wallClockSince returns how far local's wall clock is past midnight of the
+// given date, e.g. 25h for 1am on the following day. The result is based on
+// the wall clock alone and ignores DST shifts in between.
+func wallClockSince(year int, month time.Month, day int, local time.Time) time.Duration {
+	midnight := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	wall := time.Date(local.Year(), local.Month(), local.Day(),
+		local.Hour(), local.Minute(), local.Second(), local.Nanosecond(), time.UTC)
+	return wall.Sub(midnight)
+}
+
+// Span returns when working hours on the given local date begin and end, in
+// absolute time. The span covers every instant whose wall clock lies within
+// working hours: if Start or End falls into a DST gap, the span begins or
+// ends at the gap, and if End is repeated by a DST overlap, the span ends at
+// its later occurrence. It does not check whether the date is a working day.
+func (w WorkingHours) Span(year int, month time.Month, day int) (start, end time.Time) {
+	start = w.wallClock(year, month, day, w.Start)
+	if at, ok := w.gap(year, month, day, start, w.Start); ok {
+		start = at
+	}
+
+	end = w.wallClock(year, month, day, w.End)
+	if at, ok := w.gap(year, month, day, end, w.End); ok {
+		end = at
+	} else if _, next := end.ZoneBounds(); !next.IsZero() {
+		// Clocks going back after end may repeat it, and the later occurrence
+		// is still within working hours
+		_, before := end.Zone()
+		_, after := next.Zone()
+		later := end.Add(time.Duration(before-after) * time.Second)
+		if later.After(end) && !later.Before(next) && wallClockSince(year, month, day, later.In(w.Location)) == w.End {
+			end = later
+		}
+	}
+
+	return start, end
+}
+
+// wallClock returns the instant at the given wall clock offset from local
+// midnight of the date. For a wall clock skipped by a DST gap, it is an
+// instant next to the gap.
+func (w WorkingHours) wallClock(year int, month time.Month, day int, offset time.Duration) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, int(offset), w.Location)
+}
+
+// gap reports whether the wall clock offset, resolved to t by wallClock, was
+// skipped by a DST gap, and if so the instant the gap occurs at
+func (w WorkingHours) gap(year int, month time.Month, day int, t time.Time, offset time.Duration) (time.Time, bool) {
+	wall := wallClockSince(year, month, day, t)
+	if wall == offset {
+		return time.Time{}, false
+	}
+
+	// t landed after the gap if its wall clock is ahead, before it otherwise
+	zoneStart, zoneEnd := t.ZoneBounds()
+	if wall > offset {
+		return zoneStart, true
+	}
+	return zoneEnd, true
+}
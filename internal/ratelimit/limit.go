@@ -0,0 +1,74 @@
+// Package ratelimit limits how often clients may call, with a token bucket
+// per client.
+//
+// A bucket holds up to Limit.Requests tokens and refills evenly over
+// Limit.Period, so a client may send a burst of Requests at once and then
+// keeps to Requests per Period. Every request takes one token, and requests
+// finding the bucket empty are refused.
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit allows Requests per Period, in bursts of up to Requests. The zero
+// Limit allows everything.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// units are the short periods of ParseLimit
+var units = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+}
+
+// ParseLimit parses a limit such as "10/s", "60/m" or "5/30s", requests per
+// second, minute, hour or any duration. An empty string is no limit.
+func ParseLimit(s string) (Limit, error) {
+	if strings.TrimSpace(s) == "" {
+		return Limit{}, nil
+	}
+
+	requests, per, ok := strings.Cut(strings.TrimSpace(s), "/")
+	n, err := strconv.Atoi(requests)
+	if !ok || err != nil || n < 1 {
+		return Limit{}, fmt.Errorf("%q is not a rate limit, e.g. 10/s", s)
+	}
+	period, ok := units[per]
+	if !ok {
+		period, err = time.ParseDuration(per)
+		if err != nil || period <= 0 {
+			return Limit{}, fmt.Errorf("%q is not a rate limit, e.g. 10/s", s)
+		}
+	}
+	return Limit{Requests: n, Period: period}, nil
+}
+
+// String formats the limit the way ParseLimit reads it, or "" for no limit
+func (l Limit) String() string {
+	if l.Unlimited() {
+		return ""
+	}
+	for unit, period := range units {
+		if l.Period == period {
+			return fmt.Sprintf("%d/%s", l.Requests, unit)
+		}
+	}
+	return fmt.Sprintf("%d/%s", l.Requests, l.Period)
+}
+
+// Unlimited reports whether the limit allows everything
+func (l Limit) Unlimited() bool {
+	return l.Requests < 1 || l.Period <= 0
+}
+
+// interval is the time it takes to refill one token
+func (l Limit) interval() time.Duration {
+	return l.Period / time.Duration(l.Requests)
+}
@@ -0,0 +1,164 @@
+package ratelimit
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Decision is the outcome of a request to a Limiter
+type Decision struct {
+	Allowed bool
+
+	// Limit is the limit applied, the zero Limit when there is none
+	Limit Limit
+
+	// Remaining is the number of requests the client may still send at once
+	Remaining int
+
+	// Reset is the time until the client's bucket is full again
+	Reset time.Duration
+
+	// RetryAfter is the time until the client may send again, when the
+	// request is refused
+	RetryAfter time.Duration
+}
+
+// MaxClients is how many clients a Limiter keeps buckets for by default
+const MaxClients = 100_000
+
+// Limiter applies a Limit to every client separately, by key, e.g. their IP
+// address. It is safe for concurrent use.
+//
+// Memory is bounded: beyond maxClients, the bucket of the client that called
+// least recently is forgotten to make room, so that client starts over with
+// a full bucket.
+type Limiter struct {
+	mu    sync.Mutex
+	limit Limit
+
+	// buckets holds the elements of recent by client key, and recent the
+	// buckets from the most to the least recently used
+	buckets    map[string]*list.Element
+	recent     *list.List
+	maxClients int
+
+	// swept is when the buckets were last cleared of full ones, which are
+	// as good as new
+	swept time.Time
+
+	now func() time.Time
+}
+
+// bucket holds a client's tokens as of at
+type bucket struct {
+	key    string
+	tokens float64
+	at     time.Time
+}
+
+// NewLimiter returns a Limiter applying limit
+func NewLimiter(limit Limit) *Limiter {
+	return &Limiter{
+		limit:      limit,
+		buckets:    map[string]*list.Element{},
+		recent:     list.New(),
+		maxClients: MaxClients,
+		now:        time.Now,
+	}
+}
+
+// Limit returns the limit applied
+func (l *Limiter) Limit() Limit {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+// SetLimit changes the limit, e.g. on a config reload. Clients keep the
+// tokens they have, up to the new limit's burst.
+func (l *Limiter) SetLimit(limit Limit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+	if limit.Unlimited() {
+		clear(l.buckets)
+		l.recent.Init()
+	}
+}
+
+// Allow takes a token from the bucket of the client key, and reports whether
+// there was one
+func (l *Limiter) Allow(key string) Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limit.Unlimited() {
+		return Decision{Allowed: true}
+	}
+	now := l.now()
+	l.sweep(now)
+
+	capacity := float64(l.limit.Requests)
+	b := l.bucket(key, now)
+	b.refill(now, l.limit)
+
+	decision := Decision{Limit: l.limit}
+	if b.tokens >= 1 {
+		b.tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = tokensTime(1-b.tokens, l.limit)
+	}
+	decision.Remaining = int(b.tokens)
+	decision.Reset = tokensTime(capacity-b.tokens, l.limit)
+	return decision
+}
+
+// bucket returns the bucket of the client key, as the most recently used,
+// starting a full one for a new client
+func (l *Limiter) bucket(key string, now time.Time) *bucket {
+	if elem, ok := l.buckets[key]; ok {
+		l.recent.MoveToFront(elem)
+		return elem.Value.(*bucket)
+	}
+	for len(l.buckets) >= l.maxClients {
+		l.forget(l.recent.Back())
+	}
+	b := &bucket{key: key, tokens: float64(l.limit.Requests), at: now}
+	l.buckets[key] = l.recent.PushFront(b)
+	return b
+}
+
+// forget drops the bucket in elem
+func (l *Limiter) forget(elem *list.Element) {
+	delete(l.buckets, elem.Value.(*bucket).key)
+	l.recent.Remove(elem)
+}
+
+// sweep forgets the buckets that have refilled, at most once per period, so
+// clients that stopped calling are not kept forever
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < l.limit.Period {
+		return
+	}
+	for _, elem := range l.buckets {
+		b := elem.Value.(*bucket)
+		if b.refill(now, l.limit); b.tokens >= float64(l.limit.Requests) {
+			l.forget(elem)
+		}
+	}
+	l.swept = now
+}
+
+// refill adds the tokens earned since the bucket was last used
+func (b *bucket) refill(now time.Time, limit Limit) {
+	earned := float64(now.Sub(b.at)) / float64(limit.interval())
+	b.tokens = min(b.tokens+earned, float64(limit.Requests))
+	b.at = now
+}
+
+// tokensTime is the time it takes to refill tokens
+func tokensTime(tokens float64, limit Limit) time.Duration {
+	return time.Duration(tokens * float64(limit.interval()))
+}
@@ -0,0 +1,134 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseLimit tests reading limits from configuration.
+//
+// It includes the following test cases:
+//
+// * Requests per second, minute, hour and any duration
+// * An empty string, which is no limit
+// * Malformed limits
+func TestParseLimit(t *testing.T) {
+	for s, want := range map[string]Limit{
+		"10/s":  {Requests: 10, Period: time.Second},
+		"60/m":  {Requests: 60, Period: time.Minute},
+		"1/h":   {Requests: 1, Period: time.Hour},
+		"5/30s": {Requests: 5, Period: 30 * time.Second},
+	} {
+		limit, err := ParseLimit(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, want, limit, s)
+		assert.Equal(t, s, limit.String())
+	}
+
+	limit, err := ParseLimit("")
+	require.NoError(t, err)
+	assert.True(t, limit.Unlimited())
+
+	for _, s := range []string{"10", "0/s", "ten/s", "10/fortnight", "10/-1s"} {
+		_, err := ParseLimit(s)
+		assert.ErrorContains(t, err, "is not a rate limit", s)
+	}
+}
+
+// TestLimiter tests token buckets per client.
+//
+// It includes the following test cases:
+//
+// * A burst up to the limit, then refusal with the time to retry
+// * Tokens refilling over time, up to the limit
+// * Clients having their own buckets
+// * Changing the limit, and no limit at all
+// * Forgetting clients whose bucket has refilled
+// * Forgetting the least recently seen client beyond the cap on clients
+func TestLimiter(t *testing.T) {
+	now := time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+	limiter := NewLimiter(Limit{Requests: 3, Period: 3 * time.Second})
+	limiter.now = func() time.Time { return now }
+
+	t.Run("burst", func(t *testing.T) {
+		for remaining := 2; remaining >= 0; remaining-- {
+			d := limiter.Allow("ada")
+			assert.True(t, d.Allowed)
+			assert.Equal(t, remaining, d.Remaining)
+		}
+		d := limiter.Allow("ada")
+		assert.False(t, d.Allowed)
+		assert.Equal(t, time.Second, d.RetryAfter)
+		assert.Equal(t, 3*time.Second, d.Reset)
+	})
+
+	t.Run("refill", func(t *testing.T) {
+		now = now.Add(1500 * time.Millisecond)
+		d := limiter.Allow("ada")
+		assert.True(t, d.Allowed)
+		assert.Equal(t, 0, d.Remaining)
+		assert.Equal(t, 2500*time.Millisecond, d.Reset)
+
+		d = limiter.Allow("ada")
+		assert.False(t, d.Allowed)
+		assert.Equal(t, 500*time.Millisecond, d.RetryAfter)
+
+		now = now.Add(time.Hour)
+		assert.Equal(t, 2, limiter.Allow("ada").Remaining)
+	})
+
+	t.Run("clients", func(t *testing.T) {
+		for range 3 {
+			limiter.Allow("grace")
+		}
+		assert.False(t, limiter.Allow("grace").Allowed)
+		assert.True(t, limiter.Allow("alan").Allowed)
+	})
+
+	t.Run("set limit", func(t *testing.T) {
+		limiter.SetLimit(Limit{Requests: 1, Period: time.Minute})
+		assert.False(t, limiter.Allow("grace").Allowed)
+		d := limiter.Allow("alan")
+		assert.True(t, d.Allowed)
+		assert.Equal(t, Limit{Requests: 1, Period: time.Minute}, d.Limit)
+		assert.False(t, limiter.Allow("alan").Allowed, "tokens left are capped at the new burst")
+
+		limiter.SetLimit(Limit{})
+		assert.Equal(t, Decision{Allowed: true}, limiter.Allow("grace"))
+	})
+
+	t.Run("sweep", func(t *testing.T) {
+		limiter.SetLimit(Limit{Requests: 3, Period: 3 * time.Second})
+		now = now.Add(time.Hour)
+		for range 3 {
+			limiter.Allow("ada")
+		}
+		now = now.Add(2 * time.Second)
+		for range 3 {
+			limiter.Allow("grace")
+		}
+		now = now.Add(2 * time.Second)
+		limiter.Allow("alan")
+
+		assert.Len(t, limiter.buckets, 2, "ada's bucket has refilled and is forgotten")
+		assert.NotContains(t, limiter.buckets, "ada")
+	})
+
+	t.Run("max clients", func(t *testing.T) {
+		limiter.maxClients = 2
+		now = now.Add(time.Hour)
+		for range 3 {
+			limiter.Allow("ada")
+		}
+		limiter.Allow("grace")
+		limiter.Allow("ada")
+		limiter.Allow("alan")
+
+		assert.Len(t, limiter.buckets, 2)
+		assert.NotContains(t, limiter.buckets, "grace", "least recently seen")
+		assert.False(t, limiter.Allow("ada").Allowed, "ada is kept, having called since grace")
+	})
+}
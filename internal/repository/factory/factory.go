@@ -2,15 +2,20 @@ package factory
 
 import (
 	"appointment-service/internal/config"
+	"appointment-service/internal/migrate"
 	"appointment-service/internal/repository"
 	"appointment-service/internal/repository/memory"
 	"appointment-service/internal/repository/postgres"
 	"appointment-service/internal/repository/sqlite3"
+	"context"
 	"fmt"
 	"log/slog"
+
+	"github.com/jmoiron/sqlx"
 )
 
-// NewRepository creates a new repository based on the provided configuration
+// NewRepository creates a new repository based on the provided configuration.
+// SQL repositories have their schema migrated first when cfg.AutoMigrate is set.
 func NewRepository(cfg *config.Config, logger *slog.Logger) (repository.AppointmentRepository, error) {
 	switch cfg.StorageType {
 	case config.Postgres:
@@ -25,6 +30,17 @@ func NewRepository(cfg *config.Config, logger *slog.Logger) (repository.Appointm
 		if err != nil {
 			return nil, fmt.Errorf("failed to create sqlite repository: %w", err)
 		}
+
+		if cfg.AutoMigrate {
+			migrator, err := migrate.New(repo.DB(), migrate.SQLite3, logger.With("component", "migrate"))
+			if err == nil {
+				err = migrator.Up(context.Background())
+			}
+			if err != nil {
+				repo.Close()
+				return nil, fmt.Errorf("failed to migrate sqlite schema: %w", err)
+			}
+		}
 		return repo, nil
 
 	case config.Memory:
@@ -34,3 +50,30 @@ func NewRepository(cfg *config.Config, logger *slog.Logger) (repository.Appointm
 		return nil, fmt.Errorf("unsupported storage type: %s", cfg.StorageType)
 	}
 }
+
+// NewMigrator opens the configured database for schema migrations. The
+// returned close function releases the connection.
+func NewMigrator(cfg *config.Config, logger *slog.Logger) (*migrate.Migrator, func() error, error) {
+	dialect, err := migrate.DialectFor(cfg.StorageType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var db *sqlx.DB
+	switch cfg.StorageType {
+	case config.Postgres:
+		db, err = postgres.Open(cfg.DB)
+	case config.SqlLite3:
+		db, err = sqlite3.Open(cfg.SqlLite3DbFile)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open %s database: %w", cfg.StorageType, err)
+	}
+
+	migrator, err := migrate.New(db.DB, dialect, logger.With("component", "migrate"))
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return migrator, db.Close, nil
+}
@@ -6,11 +6,83 @@ import (
 	"time"
 )
 
+// AppointmentRepository is the persistence contract for appointments.
+// Implementations must be safe for concurrent use, and must pass the
+// conformance suite in repositorytest. The memory and sqlite3 backends do.
+// The postgres backend is a stub outside this contract: every method fails
+// with code NOT_IMPLEMENTED, and only its schema migrations are maintained,
+// including the listing indexes, which nothing queries yet.
+//
+// IDs: Create and CreateBatch assign each appointment a positive ID greater than any ID it
+// assigned before. IDs are never reused, not even after Delete.
+//
+// Errors: Get, Update and Delete report a missing appointment as a
+// NotFoundError with code APPOINTMENT_NOT_FOUND. Every method fails with code
+// REQUEST_CANCELLED when its context is cancelled or expired.
+//
+// Batches: CreateBatch stores every given appointment or, on any error, none
+// of them. Appointments keep their given Status, and get IDs in the order
+// given.
+//
+// Versioning: Create stores new appointments at version 1. Update only
+// succeeds when the given appointment's Version matches the stored version,
+// and bumps the stored version by one in the same atomic step. A mismatch
+// is reported as a PreconditionFailedError, a missing appointment as a
+// NotFoundError.
+//
+// Listing: List applies every filter of the query, orders by (start time, id)
+// in the requested direction and returns at most query.Limit appointments
+// (no limit when zero), with a NextCursor when more remain. Backends page
+// with a keyset on (start time, id) rather than an offset. Postgres listing,
+// filtering and keyset paging are out of scope until that backend is
+// implemented.
+//
+// Client listing: ListClientAppointments lists the appointments of the client
+// userID, across trainers, like List with the query's UserId replaced by
+// userID. A TrainerId in the query narrows it to one trainer. SQL backends
+// serve it from the (user_id, start_time, id) index.
+//
+// Streaming: Stream calls fn for every appointment matching the query's
+// filters, in List's order, ignoring Limit and After. It reads appointments
+// as fn consumes them instead of loading them all, so memory use does not
+// grow with the result. An error from fn stops the stream and is returned
+// as is. No connection or lock is held while fn runs, so a slow consumer
+// never blocks other requests and fn may write to the repository. The
+// stream is therefore not a snapshot: appointments written while it runs
+// may or may not be visited.
+//
+// Statistics: BookingStats aggregates the appointments matching the query by
+// trainer, model.BookingStatsBucket of start time and status, ordered by
+// trainer, bucket start and status. Backends aggregate where the data lives,
+// e.g. in SQL, so only the aggregates are returned: memory in one pass over
+// its map, sqlite3 with a GROUP BY on strftime buckets. Postgres aggregation
+// is unsupported until that backend is implemented, and its BookingStats
+// fails with NOT_IMPLEMENTED.
+//
+// Bookings: GetTrainerBookings and GetClientBookings return the appointments
+// overlapping [startsAt, endsAt), using half-open interval semantics: an
+// appointment occupies [StartTime, EndTime), so one ending at 10:00 does not
+// overlap a range starting at 10:00, and back-to-back appointments never
+// conflict. Formally, an appointment is returned when
+// StartTime < endsAt && startsAt < EndTime. An empty range overlaps nothing,
+// and the result does not depend on the location of the given times. Only
+// appointments that still occupy time are returned, i.e. cancelled
+// appointments are ignored.
+//
+// Health: Ping checks the storage can serve requests, e.g. that the database
+// answers, without reading or writing any appointment.
 type AppointmentRepository interface {
-	List(ctx context.Context, trainerID int64) ([]model.Appointment, error)
+	List(ctx context.Context, query model.AppointmentQuery) (*model.AppointmentPage, error)
+	ListClientAppointments(ctx context.Context, userID int64, query model.AppointmentQuery) (*model.AppointmentPage, error)
+	Stream(ctx context.Context, query model.AppointmentQuery, fn func(model.Appointment) error) error
+	Get(ctx context.Context, id int64) (*model.Appointment, error)
 	Create(ctx context.Context, appointment model.Appointment) (*model.Appointment, error)
+	CreateBatch(ctx context.Context, appointments []model.Appointment) ([]model.Appointment, error)
+	Update(ctx context.Context, appointment model.Appointment) (*model.Appointment, error)
 	Delete(ctx context.Context, id int64) error
+	BookingStats(ctx context.Context, query model.BookingStatsQuery) ([]model.BookingStats, error)
 	GetTrainerBookings(ctx context.Context, trainerID int64, startsAt, endsAt time.Time) ([]model.Appointment, error)
 	GetClientBookings(ctx context.Context, clientID int64, startsAt, endsAt time.Time) ([]model.Appointment, error)
+	Ping(ctx context.Context) error
 	Close() error
 }
@@ -4,8 +4,8 @@ import (
 	"appointment-service/internal/errors"
 	"appointment-service/internal/model"
 	"context"
-	"fmt"
 	"log/slog"
+	"sort"
 	"sync"
 	"time"
 )
@@ -27,52 +27,233 @@ func New(logger *slog.Logger) *MemoryAppointmentRepository {
 	}
 }
 
-// Create stores a new appointment and returns the created appointment with its ID
+// Create stores a new appointment and returns the created appointment with its ID.
+// New appointments always start at version 1.
 func (r *MemoryAppointmentRepository) Create(ctx context.Context, appointment model.Appointment) (*model.Appointment, error) {
 	r.Lock()
 	defer r.Unlock()
 
 	// Check context cancellation
 	if ctx.Err() != nil {
-		return nil, errors.InternalError("context cancelled", ctx.Err())
+		return nil, errors.RequestCancelledError(ctx.Err())
 	}
 
 	// Generate new ID
 	r.lastID++
 	newAppointment := appointment
 	newAppointment.Id = r.lastID
+	newAppointment.Version = 1
 
 	r.appointments = append(r.appointments, newAppointment)
 	return &newAppointment, nil
 }
 
-// List retrieves all appointments for a given trainer
-func (r *MemoryAppointmentRepository) List(ctx context.Context, trainerId int64) ([]model.Appointment, error) {
+// CreateBatch stores all of the appointments under one lock, so they become
+// visible together. New appointments always start at version 1.
+func (r *MemoryAppointmentRepository) CreateBatch(ctx context.Context, appointments []model.Appointment) ([]model.Appointment, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if ctx.Err() != nil {
+		return nil, errors.RequestCancelledError(ctx.Err())
+	}
+
+	created := make([]model.Appointment, len(appointments))
+	for i, appointment := range appointments {
+		r.lastID++
+		created[i] = appointment
+		created[i].Id = r.lastID
+		created[i].Version = 1
+	}
+
+	r.appointments = append(r.appointments, created...)
+	return created, nil
+}
+
+// List retrieves one page of appointments matching the query, ordered by start time and ID
+func (r *MemoryAppointmentRepository) List(ctx context.Context, query model.AppointmentQuery) (*model.AppointmentPage, error) {
 	r.RLock()
 	defer r.RUnlock()
 
 	// Check context cancellation
 	if ctx.Err() != nil {
-		return nil, errors.InternalError("context cancelled", ctx.Err())
+		return nil, errors.RequestCancelledError(ctx.Err())
+	}
+
+	results := r.matching(query)
+	page := &model.AppointmentPage{Appointments: results}
+	if query.Limit > 0 && len(results) > query.Limit {
+		page.Appointments = results[:query.Limit]
+		page.NextCursor = model.CursorAfter(page.Appointments[query.Limit-1]).Encode()
+	}
+
+	return page, nil
+}
+
+// Stream calls fn for every appointment matching the query's filters. The
+// matches are copied under the lock and handed to fn without it, so a slow
+// consumer never blocks writers. The copy costs no more than the appointments
+// this repository already holds in memory.
+func (r *MemoryAppointmentRepository) Stream(ctx context.Context, query model.AppointmentQuery, fn func(model.Appointment) error) error {
+	query.Limit, query.After = 0, nil
+
+	r.RLock()
+	if ctx.Err() != nil {
+		r.RUnlock()
+		return errors.RequestCancelledError(ctx.Err())
+	}
+	results := r.matching(query)
+	r.RUnlock()
+
+	for _, apt := range results {
+		if ctx.Err() != nil {
+			return errors.RequestCancelledError(ctx.Err())
+		}
+		if err := fn(apt); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
+// matching returns every appointment matching the query after its cursor,
+// ordered by start time and ID. The caller must hold the lock.
+func (r *MemoryAppointmentRepository) matching(query model.AppointmentQuery) []model.Appointment {
 	var results []model.Appointment
 	for _, apt := range r.appointments {
-		if apt.TrainerId == trainerId {
+		if query.Matches(&apt) && apt.IsAfterCursor(query.After, query.Sort) {
 			results = append(results, apt)
 		}
 	}
 
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if query.Sort == model.SortDescending {
+			a, b = b, a
+		}
+		if a.StartTime.Equal(b.StartTime) {
+			return a.Id < b.Id
+		}
+		return a.StartTime.Before(b.StartTime)
+	})
+	return results
+}
+
+// ListClientAppointments returns one page of the appointments of the client
+// userID, across trainers
+func (r *MemoryAppointmentRepository) ListClientAppointments(ctx context.Context, userID int64, query model.AppointmentQuery) (*model.AppointmentPage, error) {
+	query.UserId = userID
+	return r.List(ctx, query)
+}
+
+// BookingStats aggregates the matching appointments by trainer, bucket and
+// status in one pass, the fallback for what the SQL backends do in the
+// database
+func (r *MemoryAppointmentRepository) BookingStats(ctx context.Context, query model.BookingStatsQuery) ([]model.BookingStats, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	// Check context cancellation
+	if ctx.Err() != nil {
+		return nil, errors.RequestCancelledError(ctx.Err())
+	}
+
+	type key struct {
+		trainerId int64
+		start     time.Time
+		status    model.AppointmentStatus
+	}
+	stats := make(map[key]*model.BookingStats)
+	seconds := make(map[key]int64)
+	for _, apt := range r.appointments {
+		if query.TrainerId != 0 && apt.TrainerId != query.TrainerId {
+			continue
+		}
+		if apt.StartTime.Before(query.StartsAt) || !apt.StartTime.Before(query.EndsAt) {
+			continue
+		}
+
+		k := key{apt.TrainerId, apt.StartTime.UTC().Truncate(model.BookingStatsBucket), apt.Status}
+		if stats[k] == nil {
+			stats[k] = &model.BookingStats{TrainerId: k.trainerId, Start: k.start, Status: k.status}
+		}
+		stats[k].Appointments++
+		seconds[k] += int64(apt.EndTime.Sub(apt.StartTime) / time.Second)
+	}
+
+	results := make([]model.BookingStats, 0, len(stats))
+	for k, s := range stats {
+		s.BookedMinutes = int(seconds[k] / 60)
+		results = append(results, *s)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.TrainerId != b.TrainerId {
+			return a.TrainerId < b.TrainerId
+		}
+		if !a.Start.Equal(b.Start) {
+			return a.Start.Before(b.Start)
+		}
+		return a.Status < b.Status
+	})
+
 	return results, nil
 }
 
+// Get retrieves a single appointment by ID
+func (r *MemoryAppointmentRepository) Get(ctx context.Context, id int64) (*model.Appointment, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	if ctx.Err() != nil {
+		return nil, errors.RequestCancelledError(ctx.Err())
+	}
+
+	for _, apt := range r.appointments {
+		if apt.Id == id {
+			found := apt
+			return &found, nil
+		}
+	}
+
+	return nil, errors.AppointmentNotFoundError(id)
+}
+
+// Update replaces a stored appointment if the caller's version matches the
+// stored version. The version check and bump happen under the write lock,
+// so concurrent updates of the same version cannot both succeed.
+func (r *MemoryAppointmentRepository) Update(ctx context.Context, appointment model.Appointment) (*model.Appointment, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if ctx.Err() != nil {
+		return nil, errors.RequestCancelledError(ctx.Err())
+	}
+
+	for i, apt := range r.appointments {
+		if apt.Id != appointment.Id {
+			continue
+		}
+		if apt.Version != appointment.Version {
+			return nil, errors.VersionMismatchError(appointment.Id, appointment.Version, apt.Version)
+		}
+
+		updated := appointment
+		updated.Version = apt.Version + 1
+		r.appointments[i] = updated
+		return &updated, nil
+	}
+
+	return nil, errors.AppointmentNotFoundError(appointment.Id)
+}
+
 // Delete removes an appointment
 func (r *MemoryAppointmentRepository) Delete(ctx context.Context, id int64) error {
 	r.Lock()
 	defer r.Unlock()
 
 	if ctx.Err() != nil {
-		return errors.InternalError("context cancelled", ctx.Err())
+		return errors.RequestCancelledError(ctx.Err())
 	}
 
 	for i, apt := range r.appointments {
@@ -83,16 +264,22 @@ func (r *MemoryAppointmentRepository) Delete(ctx context.Context, id int64) erro
 		}
 	}
 
-	return errors.NotFoundError(fmt.Sprintf("appointment with ID %d not found", id))
+	return errors.AppointmentNotFoundError(id)
 }
 
 func (r *MemoryAppointmentRepository) GetTrainerBookings(ctx context.Context, trainerID int64, startsAt time.Time, endsAt time.Time) ([]model.Appointment, error) {
-	var booked []model.Appointment
+	r.RLock()
+	defer r.RUnlock()
+
+	if ctx.Err() != nil {
+		return nil, errors.RequestCancelledError(ctx.Err())
+	}
 
+	var booked []model.Appointment
 	for _, apt := range r.appointments {
 		if apt.TrainerId == trainerID &&
-			!apt.EndTime.Before(startsAt) &&
-			!apt.StartTime.After(endsAt) {
+			apt.Status != model.StatusCancelled &&
+			apt.Overlaps(startsAt, endsAt) {
 			booked = append(booked, apt)
 		}
 	}
@@ -100,12 +287,18 @@ func (r *MemoryAppointmentRepository) GetTrainerBookings(ctx context.Context, tr
 	return booked, nil
 }
 func (r *MemoryAppointmentRepository) GetClientBookings(ctx context.Context, clientID int64, startsAt time.Time, endsAt time.Time) ([]model.Appointment, error) {
-	var booked []model.Appointment
+	r.RLock()
+	defer r.RUnlock()
+
+	if ctx.Err() != nil {
+		return nil, errors.RequestCancelledError(ctx.Err())
+	}
 
+	var booked []model.Appointment
 	for _, apt := range r.appointments {
 		if apt.UserId == clientID &&
-			!apt.EndTime.Before(startsAt) &&
-			!apt.StartTime.After(endsAt) {
+			apt.Status != model.StatusCancelled &&
+			apt.Overlaps(startsAt, endsAt) {
 			booked = append(booked, apt)
 		}
 	}
@@ -113,6 +306,14 @@ func (r *MemoryAppointmentRepository) GetClientBookings(ctx context.Context, cli
 	return booked, nil
 }
 
+// Ping always succeeds, as there is nothing to connect to
+func (r *MemoryAppointmentRepository) Ping(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return errors.RequestCancelledError(ctx.Err())
+	}
+	return nil
+}
+
 func (r *MemoryAppointmentRepository) Close() error {
 	return nil // No-op in memory storage
 }
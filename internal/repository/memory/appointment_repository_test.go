@@ -1,10 +1,17 @@
 package memory
 
 import (
+	"appointment-service/internal/errors"
 	"appointment-service/internal/model"
+	"appointment-service/internal/repository"
+	"appointment-service/internal/repository/repositorytest"
 	"context"
+	"io"
 	"log/slog"
+	"net/http"
 	"os"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -19,6 +26,8 @@ import (
 // * List appointments
 // * Delete appointment
 // * Get booked appointments
+// * Update appointment with optimistic concurrency
+// * List appointments with filters, sorting and cursor pagination
 //
 // Note: There should be little to no business logic in the repository layer.
 // Therefore, things like creating overlapping appointments for a single trainer
@@ -78,8 +87,9 @@ func TestMemoryAppointmentRepository(t *testing.T) {
 		createdApp1, _ := repo.Create(ctx, appointment1)
 		createdApp2, _ := repo.Create(ctx, appointment2)
 
-		appointments, err := repo.List(ctx, 1)
+		page, err := repo.List(ctx, model.AppointmentQuery{TrainerId: 1})
 		assert.NoError(t, err)
+		appointments := page.Appointments
 		assert.Len(t, appointments, 2)
 
 		// Verify first appointment
@@ -164,4 +174,142 @@ func TestMemoryAppointmentRepository(t *testing.T) {
 		assert.Equal(t, createdAppointment1.Id, repo.appointments[0].Id)
 		assert.Equal(t, createdAppointment3.Id, repo.appointments[1].Id)
 	})
+
+	// Tests updating an appointment with optimistic concurrency:
+	// - A new appointment should start at version 1
+	// - An update carrying the current version should succeed and bump the version
+	// - An update carrying a stale version should be rejected with 412
+	// - An update for an unknown ID should be rejected with 404
+	t.Run("Update appointment with version check", func(t *testing.T) {
+		repo := New(logger)
+		ctx := context.Background()
+
+		created, _ := repo.Create(ctx, model.Appointment{
+			TrainerId: 1,
+			UserId:    100,
+			StartTime: time.Now().Add(1 * time.Hour),
+			EndTime:   time.Now().Add(2 * time.Hour),
+			Status:    model.StatusScheduled,
+		})
+		assert.Equal(t, int64(1), created.Version)
+
+		toCancel := *created
+		toCancel.Status = model.StatusCancelled
+		updated, err := repo.Update(ctx, toCancel)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), updated.Version)
+		assert.Equal(t, model.StatusCancelled, updated.Status)
+
+		// Same (now stale) version again
+		_, err = repo.Update(ctx, toCancel)
+		appErr, ok := errors.IsAppError(err)
+		assert.True(t, ok)
+		assert.Equal(t, http.StatusPreconditionFailed, appErr.Code)
+
+		missing := toCancel
+		missing.Id = 999
+		_, err = repo.Update(ctx, missing)
+		appErr, ok = errors.IsAppError(err)
+		assert.True(t, ok)
+		assert.Equal(t, http.StatusNotFound, appErr.Code)
+	})
+
+	// Tests that concurrent updates holding the same version cannot both win:
+	// - Many goroutines race to update version 1 of the same appointment
+	// - Exactly one should succeed, the rest should get 412
+	t.Run("Concurrent updates of the same version", func(t *testing.T) {
+		repo := New(logger)
+		ctx := context.Background()
+
+		created, _ := repo.Create(ctx, model.Appointment{
+			TrainerId: 1,
+			UserId:    100,
+			StartTime: time.Now().Add(1 * time.Hour),
+			EndTime:   time.Now().Add(2 * time.Hour),
+			Status:    model.StatusScheduled,
+		})
+
+		const writers = 20
+		var wg sync.WaitGroup
+		var succeeded atomic.Int32
+		for i := 0; i < writers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := repo.Update(ctx, *created); err == nil {
+					succeeded.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(1), succeeded.Load())
+		current, _ := repo.Get(ctx, created.Id)
+		assert.Equal(t, int64(2), current.Version)
+	})
+
+	// Tests listing with filters, sort order and cursor pagination:
+	// - Appointments for another trainer, outside the range or with another status are excluded
+	// - Pages are returned in start time order and never repeat or skip an appointment
+	// - The last page has no next cursor
+	t.Run("List appointments with filters and pagination", func(t *testing.T) {
+		repo := New(logger)
+		ctx := context.Background()
+
+		base := time.Date(2025, 6, 2, 16, 0, 0, 0, time.UTC)
+		for i := 0; i < 5; i++ {
+			repo.Create(ctx, model.Appointment{
+				TrainerId: 1,
+				UserId:    100,
+				StartTime: base.Add(time.Duration(4-i) * time.Hour),
+				EndTime:   base.Add(time.Duration(4-i)*time.Hour + 30*time.Minute),
+				Status:    model.StatusScheduled,
+			})
+		}
+		repo.Create(ctx, model.Appointment{TrainerId: 2, UserId: 100, StartTime: base, EndTime: base.Add(30 * time.Minute), Status: model.StatusScheduled})
+		repo.Create(ctx, model.Appointment{TrainerId: 1, UserId: 100, StartTime: base, EndTime: base.Add(30 * time.Minute), Status: model.StatusCancelled})
+		repo.Create(ctx, model.Appointment{TrainerId: 1, UserId: 100, StartTime: base.Add(-24 * time.Hour), EndTime: base.Add(-23 * time.Hour), Status: model.StatusScheduled})
+
+		query := model.AppointmentQuery{
+			TrainerId: 1,
+			StartsAt:  base,
+			EndsAt:    base.Add(24 * time.Hour),
+			Statuses:  []model.AppointmentStatus{model.StatusScheduled},
+			Sort:      model.SortAscending,
+			Limit:     2,
+		}
+
+		var seen []time.Time
+		for pages := 0; ; pages++ {
+			page, err := repo.List(ctx, query)
+			assert.NoError(t, err)
+			for _, apt := range page.Appointments {
+				seen = append(seen, apt.StartTime)
+			}
+			if page.NextCursor == "" {
+				assert.Equal(t, 2, pages)
+				break
+			}
+			query.After, err = model.DecodeCursor(page.NextCursor)
+			assert.NoError(t, err)
+		}
+
+		assert.Len(t, seen, 5)
+		for i := 1; i < len(seen); i++ {
+			assert.True(t, seen[i-1].Before(seen[i]))
+		}
+
+		query.Sort = model.SortDescending
+		query.After = nil
+		page, err := repo.List(ctx, query)
+		assert.NoError(t, err)
+		assert.Equal(t, base.Add(4*time.Hour), page.Appointments[0].StartTime)
+	})
+}
+
+// TestConformance runs the shared repository conformance suite
+func TestConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repository.AppointmentRepository {
+		return New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	})
 }
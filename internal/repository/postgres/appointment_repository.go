@@ -6,7 +6,12 @@ import (
 	"appointment-service/internal/model"
 	"context"
 	"log/slog"
+	"net"
+	"net/url"
 	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
 )
 
 // PostgresAppointmentRepository implements AppointmentRepository using Postgres storage
@@ -18,34 +23,88 @@ type PostgresAppointmentRepository struct {
 // NewPostgresAppointmentRepository creates a new instance of PostgresAppointmentRepository
 func New(dbConfig config.DBConfig, logger *slog.Logger) (*PostgresAppointmentRepository, error) {
 	// TODO: Implement
-	return nil, errors.InternalError("Create method not implemented yet", nil)
+	return nil, errors.InternalError("postgres repository not implemented yet", nil).WithCode(errors.CodeNotImplemented)
+}
+
+// Open connects to the Postgres database described by dbConfig. The
+// repository is not implemented yet, but its schema can already be migrated
+// over this connection.
+func Open(dbConfig config.DBConfig) (*sqlx.DB, error) {
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(dbConfig.User, dbConfig.Password),
+		Host:     net.JoinHostPort(dbConfig.Host, dbConfig.Port),
+		Path:     dbConfig.Name,
+		RawQuery: url.Values{"sslmode": {dbConfig.SSLMode}}.Encode(),
+	}
+
+	db, err := sqlx.Connect("postgres", dsn.String())
+	if err != nil {
+		return nil, errors.StorageError("connecting to database", err)
+	}
+	return db, nil
 }
 
 func (r *PostgresAppointmentRepository) Create(ctx context.Context, appointment model.Appointment) (*model.Appointment, error) {
 	// TODO: Implement
-	return nil, errors.InternalError("Create method not implemented yet", nil)
+	return nil, errors.InternalError("Create method not implemented yet", nil).WithCode(errors.CodeNotImplemented)
+}
+
+func (r *PostgresAppointmentRepository) CreateBatch(ctx context.Context, appointments []model.Appointment) ([]model.Appointment, error) {
+	// TODO: Implement
+	return nil, errors.InternalError("CreateBatch method not implemented yet", nil).WithCode(errors.CodeNotImplemented)
+}
+
+func (r *PostgresAppointmentRepository) List(ctx context.Context, query model.AppointmentQuery) (*model.AppointmentPage, error) {
+	// TODO: Implement
+	return nil, errors.InternalError("List method not implemented yet", nil).WithCode(errors.CodeNotImplemented)
+}
+
+func (r *PostgresAppointmentRepository) ListClientAppointments(ctx context.Context, userID int64, query model.AppointmentQuery) (*model.AppointmentPage, error) {
+	// TODO: Implement
+	return nil, errors.InternalError("ListClientAppointments method not implemented yet", nil).WithCode(errors.CodeNotImplemented)
 }
 
-func (r *PostgresAppointmentRepository) List(ctx context.Context, trainerId int64) ([]model.Appointment, error) {
+func (r *PostgresAppointmentRepository) Stream(ctx context.Context, query model.AppointmentQuery, fn func(model.Appointment) error) error {
 	// TODO: Implement
-	return nil, errors.InternalError("Create method not implemented yet", nil)
+	return errors.InternalError("Stream method not implemented yet", nil).WithCode(errors.CodeNotImplemented)
+}
+
+func (r *PostgresAppointmentRepository) Get(ctx context.Context, id int64) (*model.Appointment, error) {
+	// TODO: Implement
+	return nil, errors.InternalError("Get method not implemented yet", nil).WithCode(errors.CodeNotImplemented)
+}
+
+func (r *PostgresAppointmentRepository) Update(ctx context.Context, appointment model.Appointment) (*model.Appointment, error) {
+	// TODO: Implement
+	return nil, errors.InternalError("Update method not implemented yet", nil).WithCode(errors.CodeNotImplemented)
 }
 
 func (r *PostgresAppointmentRepository) Delete(ctx context.Context, id int64) error {
 	// TODO: Implement
-	return errors.InternalError("Create method not implemented yet", nil)
+	return errors.InternalError("Delete method not implemented yet", nil).WithCode(errors.CodeNotImplemented)
+}
+
+func (r *PostgresAppointmentRepository) BookingStats(ctx context.Context, query model.BookingStatsQuery) ([]model.BookingStats, error) {
+	// TODO: Implement
+	return nil, errors.InternalError("BookingStats method not implemented yet", nil).WithCode(errors.CodeNotImplemented)
 }
 
 func (r *PostgresAppointmentRepository) GetTrainerBookings(ctx context.Context, trainerID int64, startsAt, endsAt time.Time) ([]model.Appointment, error) {
 	// TODO: Implement
-	return nil, errors.InternalError("Create method not implemented yet", nil)
+	return nil, errors.InternalError("GetTrainerBookings method not implemented yet", nil).WithCode(errors.CodeNotImplemented)
+}
+
+func (r *PostgresAppointmentRepository) GetClientBookings(ctx context.Context, clientID int64, startsAt, endsAt time.Time) ([]model.Appointment, error) {
+	// TODO: Implement
+	return nil, errors.InternalError("GetClientBookings method not implemented yet", nil).WithCode(errors.CodeNotImplemented)
 }
 
-func (r *PostgresAppointmentRepository) GetClientBookings(ctx context.Context, trainerID int64, startsAt, endsAt time.Time) ([]model.Appointment, error) {
+func (r *PostgresAppointmentRepository) Ping(ctx context.Context) error {
 	// TODO: Implement
-	return nil, errors.InternalError("Create method not implemented yet", nil)
+	return errors.InternalError("Ping method not implemented yet", nil).WithCode(errors.CodeNotImplemented)
 }
 
 func (r *PostgresAppointmentRepository) Close() error {
-	return errors.InternalError("Create method not implemented yet", nil)
+	return errors.InternalError("Close method not implemented yet", nil).WithCode(errors.CodeNotImplemented)
 }
@@ -0,0 +1,765 @@
+// Package repositorytest is the conformance suite for implementations of
+// repository.AppointmentRepository. Every backend runs it from its own tests,
+// so they all honour the same documented contract:
+//
+//	func TestConformance(t *testing.T) {
+//		repositorytest.Run(t, func(t *testing.T) repository.AppointmentRepository {
+//			return New(...)
+//		})
+//	}
+package repositorytest
+
+import (
+	"appointment-service/internal/errors"
+	"appointment-service/internal/model"
+	"appointment-service/internal/repository"
+	"context"
+	stderrors "errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory returns a new, empty repository. It is called once per test, and
+// the repository is closed when the test ends.
+type Factory func(t *testing.T) repository.AppointmentRepository
+
+// Run runs the whole conformance suite against repositories from newRepo.
+// Every test gets a repository of its own.
+func Run(t *testing.T, newRepo Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, repo repository.AppointmentRepository)
+	}{
+		{"CreateAndGet", testCreateAndGet},
+		{"IDGeneration", testIDGeneration},
+		{"CreateBatch", testCreateBatch},
+		{"List", testList},
+		{"ListClientAppointments", testListClientAppointments},
+		{"Stream", testStream},
+		{"BookingStats", testBookingStats},
+		{"Delete", testDelete},
+		{"NotFound", testNotFound},
+		{"Update", testUpdate},
+		{"BookingBoundaries", testBookingBoundaries},
+		{"ContextCancellation", testContextCancellation},
+		{"Concurrency", testConcurrency},
+		{"Ping", testPing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, open(t, newRepo))
+		})
+	}
+}
+
+// open creates a repository and closes it when the test ends
+func open(t *testing.T, newRepo Factory) repository.AppointmentRepository {
+	t.Helper()
+	repo := newRepo(t)
+	t.Cleanup(func() {
+		assert.NoError(t, repo.Close())
+	})
+	return repo
+}
+
+// clock returns 2025-06-02 at the given hour and minute UTC
+func clock(hour, minute int) time.Time {
+	return time.Date(2025, 6, 2, hour, minute, 0, 0, time.UTC)
+}
+
+// appointment returns a scheduled 30 minute appointment starting at the given
+// time of day on 2025-06-02 UTC
+func appointment(trainerId, userId int64, hour, minute int) model.Appointment {
+	return model.Appointment{
+		TrainerId: trainerId,
+		UserId:    userId,
+		StartTime: clock(hour, minute),
+		EndTime:   clock(hour, minute).Add(30 * time.Minute),
+		Status:    model.StatusScheduled,
+	}
+}
+
+// create stores the appointment, failing the test on error
+func create(t *testing.T, repo repository.AppointmentRepository, apt model.Appointment) *model.Appointment {
+	t.Helper()
+	created, err := repo.Create(context.Background(), apt)
+	require.NoError(t, err)
+	return created
+}
+
+// ids returns the IDs of the appointments, in order
+func ids(appointments []model.Appointment) []int64 {
+	result := make([]int64, len(appointments))
+	for i, apt := range appointments {
+		result[i] = apt.Id
+	}
+	return result
+}
+
+// assertErrorCode asserts that err is an AppError with the given HTTP status
+// and error code
+func assertErrorCode(t *testing.T, err error, status int, code errors.ErrorCode) {
+	t.Helper()
+	appErr, ok := errors.IsAppError(err)
+	if assert.True(t, ok, "expected an AppError, got %v", err) {
+		assert.Equal(t, status, appErr.Code)
+		assert.Equal(t, code, appErr.ErrorCode)
+	}
+}
+
+// testCreateAndGet checks that created appointments are stored as given.
+//
+// It includes the following test cases:
+//
+// * Create assigns an ID and version 1 and keeps every other field
+// * Get returns the same appointment
+// * Times given in another location are the same instants when read back
+func testCreateAndGet(t *testing.T, repo repository.AppointmentRepository) {
+	ctx := context.Background()
+
+	eastern := time.FixedZone("EDT", -4*60*60)
+	apt := appointment(1, 2, 10, 0)
+	apt.StartTime = apt.StartTime.In(eastern)
+	apt.EndTime = apt.EndTime.In(eastern)
+
+	created, err := repo.Create(ctx, apt)
+	require.NoError(t, err)
+	assert.Positive(t, created.Id)
+	assert.Equal(t, int64(1), created.Version)
+	assert.Equal(t, int64(1), created.TrainerId)
+	assert.Equal(t, int64(2), created.UserId)
+	assert.Equal(t, model.StatusScheduled, created.Status)
+	assert.True(t, clock(10, 0).Equal(created.StartTime), "start time %s", created.StartTime)
+	assert.True(t, clock(10, 30).Equal(created.EndTime), "end time %s", created.EndTime)
+
+	found, err := repo.Get(ctx, created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, created.Id, found.Id)
+	assert.Equal(t, created.Version, found.Version)
+	assert.Equal(t, created.Status, found.Status)
+	assert.True(t, created.StartTime.Equal(found.StartTime))
+	assert.True(t, created.EndTime.Equal(found.EndTime))
+}
+
+// testIDGeneration checks the ID contract.
+//
+// It includes the following test cases:
+//
+// * IDs are positive and increase with every create
+// * IDs are not reused after the latest appointment is deleted
+func testIDGeneration(t *testing.T, repo repository.AppointmentRepository) {
+	var last int64
+	for i := 0; i < 5; i++ {
+		created := create(t, repo, appointment(1, 2, 8+i, 0))
+		assert.Greater(t, created.Id, last)
+		last = created.Id
+	}
+
+	require.NoError(t, repo.Delete(context.Background(), last))
+	created := create(t, repo, appointment(1, 2, 14, 0))
+	assert.Greater(t, created.Id, last)
+}
+
+// testCreateBatch checks that batches are stored together and in order.
+//
+// It includes the following test cases:
+//
+// * IDs follow the order of the batch and continue after Create's IDs
+// * Every appointment is stored at version 1 with its given status
+// * An empty batch stores nothing
+func testCreateBatch(t *testing.T, repo repository.AppointmentRepository) {
+	ctx := context.Background()
+	first := create(t, repo, appointment(1, 2, 9, 0))
+
+	completed := appointment(1, 3, 10, 0)
+	completed.Status = model.StatusCompleted
+	batch := []model.Appointment{appointment(1, 2, 11, 0), completed, appointment(2, 4, 8, 0)}
+
+	created, err := repo.CreateBatch(ctx, batch)
+	require.NoError(t, err)
+	require.Len(t, created, len(batch))
+	for i, apt := range created {
+		if i == 0 {
+			assert.Greater(t, apt.Id, first.Id)
+		} else {
+			assert.Greater(t, apt.Id, created[i-1].Id)
+		}
+		assert.Equal(t, int64(1), apt.Version)
+		assert.Equal(t, batch[i].Status, apt.Status)
+		assert.Equal(t, batch[i].UserId, apt.UserId)
+		assert.True(t, batch[i].StartTime.Equal(apt.StartTime))
+
+		stored, err := repo.Get(ctx, apt.Id)
+		require.NoError(t, err)
+		assert.Equal(t, apt.Status, stored.Status)
+	}
+
+	none, err := repo.CreateBatch(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, none)
+
+	page, err := repo.List(ctx, model.AppointmentQuery{})
+	require.NoError(t, err)
+	assert.Len(t, page.Appointments, 4)
+}
+
+// testList checks filtering, ordering and paging of List.
+//
+// It includes the following test cases:
+//
+// * Trainer, user, status and start time filters
+// * Ascending and descending order by start time, then ID
+// * Paging with a cursor visits every appointment exactly once
+func testList(t *testing.T, repo repository.AppointmentRepository) {
+	ctx := context.Background()
+
+	a := create(t, repo, appointment(1, 2, 9, 0))
+	b := create(t, repo, appointment(1, 3, 10, 0))
+	c := create(t, repo, appointment(1, 2, 10, 0)) // same start as b, higher ID
+	d := create(t, repo, appointment(2, 2, 11, 0))
+	cancelled := appointment(1, 2, 12, 0)
+	cancelled.Status = model.StatusCancelled
+	e := create(t, repo, cancelled)
+
+	tests := []struct {
+		name  string
+		query model.AppointmentQuery
+		want  []int64
+	}{
+		{"everything", model.AppointmentQuery{}, []int64{a.Id, b.Id, c.Id, d.Id, e.Id}},
+		{"trainer", model.AppointmentQuery{TrainerId: 1}, []int64{a.Id, b.Id, c.Id, e.Id}},
+		{"trainer and user", model.AppointmentQuery{TrainerId: 1, UserId: 2}, []int64{a.Id, c.Id, e.Id}},
+		{"status", model.AppointmentQuery{Statuses: []model.AppointmentStatus{model.StatusCancelled}}, []int64{e.Id}},
+		{"start time range", model.AppointmentQuery{StartsAt: clock(10, 0), EndsAt: clock(11, 0)}, []int64{b.Id, c.Id}},
+		{"descending", model.AppointmentQuery{TrainerId: 1, Sort: model.SortDescending}, []int64{e.Id, c.Id, b.Id, a.Id}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := repo.List(ctx, tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ids(page.Appointments))
+			assert.Empty(t, page.NextCursor)
+		})
+	}
+
+	t.Run("paging", func(t *testing.T) {
+		for _, sort := range []model.SortOrder{model.SortAscending, model.SortDescending} {
+			query := model.AppointmentQuery{Sort: sort, Limit: 2}
+			var seen []int64
+			for pages := 1; ; pages++ {
+				page, err := repo.List(ctx, query)
+				require.NoError(t, err)
+				assert.LessOrEqual(t, len(page.Appointments), 2)
+				seen = append(seen, ids(page.Appointments)...)
+
+				if page.NextCursor == "" {
+					assert.Equal(t, 3, pages)
+					break
+				}
+				query.After, err = model.DecodeCursor(page.NextCursor)
+				require.NoError(t, err)
+			}
+
+			want := []int64{a.Id, b.Id, c.Id, d.Id, e.Id}
+			if sort == model.SortDescending {
+				want = []int64{e.Id, d.Id, c.Id, b.Id, a.Id}
+			}
+			assert.Equal(t, want, seen)
+		}
+	})
+}
+
+// testListClientAppointments checks listing a client's own appointments.
+//
+// It includes the following test cases:
+//
+// * The client alone, across trainers, with any UserId in the query replaced
+// * Combined with a trainer, a start time range and a status filter
+// * Paging with a cursor, in both orders, through appointments sharing a start time
+func testListClientAppointments(t *testing.T, repo repository.AppointmentRepository) {
+	ctx := context.Background()
+
+	a := create(t, repo, appointment(1, 2, 9, 0))
+	other := create(t, repo, appointment(1, 3, 9, 30))
+	b := create(t, repo, appointment(2, 2, 10, 0))
+	c := create(t, repo, appointment(3, 2, 10, 0)) // same start as b, higher ID
+	cancelled := appointment(1, 2, 11, 0)
+	cancelled.Status = model.StatusCancelled
+	d := create(t, repo, cancelled)
+	e := create(t, repo, appointment(2, 2, 12, 0))
+
+	t.Run("filters", func(t *testing.T) {
+		for _, tt := range []struct {
+			name   string
+			userID int64
+			query  model.AppointmentQuery
+			want   []int64
+		}{
+			{"client", 2, model.AppointmentQuery{}, []int64{a.Id, b.Id, c.Id, d.Id, e.Id}},
+			{"other client", 3, model.AppointmentQuery{}, []int64{other.Id}},
+			{"unknown client", 99, model.AppointmentQuery{}, []int64{}},
+			{"query user replaced", 3, model.AppointmentQuery{UserId: 2}, []int64{other.Id}},
+			{"trainer", 2, model.AppointmentQuery{TrainerId: 2}, []int64{b.Id, e.Id}},
+			{"time range", 2, model.AppointmentQuery{StartsAt: clock(10, 0), EndsAt: clock(12, 0)}, []int64{b.Id, c.Id, d.Id}},
+			{"status", 2, model.AppointmentQuery{Statuses: []model.AppointmentStatus{model.StatusScheduled}}, []int64{a.Id, b.Id, c.Id, e.Id}},
+		} {
+			page, err := repo.ListClientAppointments(ctx, tt.userID, tt.query)
+			require.NoError(t, err, tt.name)
+			assert.Equal(t, tt.want, ids(page.Appointments), tt.name)
+		}
+	})
+
+	t.Run("paging", func(t *testing.T) {
+		for _, sort := range []model.SortOrder{model.SortAscending, model.SortDescending} {
+			query := model.AppointmentQuery{Sort: sort, Limit: 2}
+			var pages [][]int64
+			for {
+				page, err := repo.ListClientAppointments(ctx, 2, query)
+				require.NoError(t, err)
+				pages = append(pages, ids(page.Appointments))
+				if page.NextCursor == "" {
+					break
+				}
+				query.After, err = model.DecodeCursor(page.NextCursor)
+				require.NoError(t, err)
+			}
+
+			want := [][]int64{{a.Id, b.Id}, {c.Id, d.Id}, {e.Id}}
+			if sort == model.SortDescending {
+				want = [][]int64{{e.Id, d.Id}, {c.Id, b.Id}, {a.Id}}
+			}
+			assert.Equal(t, want, pages, sort)
+		}
+	})
+}
+
+// testStream checks that Stream visits what List returns.
+//
+// It includes the following test cases:
+//
+// * Filters and both orders match List, ignoring Limit and After
+// * An error from fn stops the stream and is returned as is
+// * A context cancelled mid-stream stops it
+// * fn can write to the repository while the stream is open, without waiting
+func testStream(t *testing.T, repo repository.AppointmentRepository) {
+	ctx := context.Background()
+
+	for i := range 6 {
+		create(t, repo, appointment(int64(1+i%2), 2, 9+i, 0))
+	}
+	stream := func(ctx context.Context, query model.AppointmentQuery) ([]int64, error) {
+		var seen []int64
+		err := repo.Stream(ctx, query, func(apt model.Appointment) error {
+			seen = append(seen, apt.Id)
+			return nil
+		})
+		return seen, err
+	}
+
+	for _, query := range []model.AppointmentQuery{
+		{},
+		{TrainerId: 1, Sort: model.SortDescending},
+		{StartsAt: clock(10, 0), EndsAt: clock(13, 0)},
+	} {
+		page, err := repo.List(ctx, query)
+		require.NoError(t, err)
+
+		query.Limit = 1
+		query.After = model.CursorAfter(page.Appointments[0])
+		seen, err := stream(ctx, query)
+		require.NoError(t, err)
+		assert.Equal(t, ids(page.Appointments), seen)
+	}
+
+	t.Run("fn error", func(t *testing.T) {
+		stop := stderrors.New("stop")
+		calls := 0
+		err := repo.Stream(ctx, model.AppointmentQuery{}, func(model.Appointment) error {
+			calls++
+			if calls == 2 {
+				return stop
+			}
+			return nil
+		})
+		assert.Same(t, stop, err)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("cancelled mid-stream", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		calls := 0
+		err := repo.Stream(ctx, model.AppointmentQuery{}, func(model.Appointment) error {
+			calls++
+			cancel()
+			return nil
+		})
+		assertErrorCode(t, err, http.StatusInternalServerError, errors.CodeRequestCancelled)
+		assert.Less(t, calls, 6)
+	})
+
+	t.Run("writes while streaming", func(t *testing.T) {
+		writeCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		var written *model.Appointment
+		err := repo.Stream(ctx, model.AppointmentQuery{TrainerId: 1}, func(model.Appointment) error {
+			if written != nil {
+				return nil
+			}
+			var err error
+			written, err = repo.Create(writeCtx, appointment(3, 4, 9, 0))
+			return err
+		})
+		require.NoError(t, err)
+		require.NotNil(t, written)
+
+		stored, err := repo.Get(ctx, written.Id)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), stored.TrainerId)
+	})
+}
+
+// testBookingStats checks the aggregation behind utilization reports.
+//
+// It includes the following test cases:
+//
+// * Appointments starting in the same quarter hour with the same status are summed
+// * Statuses and trainers are kept apart, and ordered by trainer, bucket and status
+// * The start time range is half-open and the trainer filter applies
+func testBookingStats(t *testing.T, repo repository.AppointmentRepository) {
+	ctx := context.Background()
+
+	long := appointment(1, 4, 10, 10)
+	long.EndTime = long.StartTime.Add(time.Hour)
+	cancelled := appointment(1, 5, 10, 0)
+	cancelled.Status = model.StatusCancelled
+	for _, apt := range []model.Appointment{
+		appointment(1, 2, 10, 0),
+		long, // same bucket as 10:00
+		cancelled,
+		appointment(1, 3, 10, 15),
+		appointment(2, 2, 9, 45),
+		appointment(1, 2, 12, 0), // at EndsAt
+	} {
+		create(t, repo, apt)
+	}
+
+	stats, err := repo.BookingStats(ctx, model.BookingStatsQuery{StartsAt: clock(9, 45), EndsAt: clock(12, 0)})
+	require.NoError(t, err)
+	assert.Equal(t, []model.BookingStats{
+		{TrainerId: 1, Start: clock(10, 0), Status: model.StatusCancelled, Appointments: 1, BookedMinutes: 30},
+		{TrainerId: 1, Start: clock(10, 0), Status: model.StatusScheduled, Appointments: 2, BookedMinutes: 90},
+		{TrainerId: 1, Start: clock(10, 15), Status: model.StatusScheduled, Appointments: 1, BookedMinutes: 30},
+		{TrainerId: 2, Start: clock(9, 45), Status: model.StatusScheduled, Appointments: 1, BookedMinutes: 30},
+	}, stats)
+
+	stats, err = repo.BookingStats(ctx, model.BookingStatsQuery{TrainerId: 2, StartsAt: clock(0, 0), EndsAt: clock(23, 0)})
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Equal(t, int64(2), stats[0].TrainerId)
+}
+
+// testDelete checks that deleted appointments are gone everywhere.
+//
+// It includes the following test cases:
+//
+// * Get, List and the booking queries no longer return it
+// * Other appointments are untouched
+func testDelete(t *testing.T, repo repository.AppointmentRepository) {
+	ctx := context.Background()
+
+	deleted := create(t, repo, appointment(1, 2, 10, 0))
+	kept := create(t, repo, appointment(1, 2, 11, 0))
+
+	require.NoError(t, repo.Delete(ctx, deleted.Id))
+
+	_, err := repo.Get(ctx, deleted.Id)
+	assertErrorCode(t, err, http.StatusNotFound, errors.CodeAppointmentNotFound)
+
+	page, err := repo.List(ctx, model.AppointmentQuery{TrainerId: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{kept.Id}, ids(page.Appointments))
+
+	bookings, err := repo.GetTrainerBookings(ctx, 1, clock(0, 0), clock(23, 0))
+	require.NoError(t, err)
+	assert.Equal(t, []int64{kept.Id}, ids(bookings))
+
+	bookings, err = repo.GetClientBookings(ctx, 2, clock(0, 0), clock(23, 0))
+	require.NoError(t, err)
+	assert.Equal(t, []int64{kept.Id}, ids(bookings))
+}
+
+// testNotFound checks that missing appointments are reported consistently.
+//
+// It includes the following test cases:
+//
+// * Get, Update and Delete of an ID that never existed
+// * Delete of an appointment that was already deleted
+// * Listing and booking queries that match nothing are not errors
+func testNotFound(t *testing.T, repo repository.AppointmentRepository) {
+	ctx := context.Background()
+
+	_, err := repo.Get(ctx, 4242)
+	assertErrorCode(t, err, http.StatusNotFound, errors.CodeAppointmentNotFound)
+
+	missing := appointment(1, 2, 10, 0)
+	missing.Id = 4242
+	missing.Version = 1
+	_, err = repo.Update(ctx, missing)
+	assertErrorCode(t, err, http.StatusNotFound, errors.CodeAppointmentNotFound)
+
+	err = repo.Delete(ctx, 4242)
+	assertErrorCode(t, err, http.StatusNotFound, errors.CodeAppointmentNotFound)
+
+	created := create(t, repo, appointment(1, 2, 10, 0))
+	require.NoError(t, repo.Delete(ctx, created.Id))
+	err = repo.Delete(ctx, created.Id)
+	assertErrorCode(t, err, http.StatusNotFound, errors.CodeAppointmentNotFound)
+
+	page, err := repo.List(ctx, model.AppointmentQuery{TrainerId: 99})
+	require.NoError(t, err)
+	assert.Empty(t, page.Appointments)
+
+	bookings, err := repo.GetTrainerBookings(ctx, 99, clock(0, 0), clock(23, 0))
+	require.NoError(t, err)
+	assert.Empty(t, bookings)
+}
+
+// testUpdate checks the optimistic concurrency contract of Update.
+//
+// It includes the following test cases:
+//
+// * Update with the current version stores the change and bumps the version
+// * Update with a stale version is rejected and changes nothing
+func testUpdate(t *testing.T, repo repository.AppointmentRepository) {
+	ctx := context.Background()
+	created := create(t, repo, appointment(1, 2, 10, 0))
+
+	changed := *created
+	changed.Status = model.StatusCompleted
+	updated, err := repo.Update(ctx, changed)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), updated.Version)
+	assert.Equal(t, model.StatusCompleted, updated.Status)
+
+	stale := *created
+	stale.Status = model.StatusNoShow
+	_, err = repo.Update(ctx, stale)
+	assertErrorCode(t, err, http.StatusPreconditionFailed, errors.CodeVersionMismatch)
+
+	found, err := repo.Get(ctx, created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), found.Version)
+	assert.Equal(t, model.StatusCompleted, found.Status)
+}
+
+// testBookingBoundaries checks the half-open [start, end) overlap contract of
+// GetTrainerBookings and GetClientBookings.
+//
+// It includes the following test cases:
+//
+// * Ranges touching the start or end of an appointment do not overlap it
+// * Ranges overlapping by a minute, identical, inside or enclosing it do
+// * An empty range overlaps nothing
+// * The same instants given in another location give the same result
+// * Cancelled appointments are never returned
+func testBookingBoundaries(t *testing.T, repo repository.AppointmentRepository) {
+	ctx := context.Background()
+
+	// Trainer 1 and user 2 meet from 10:00 to 10:30
+	booked := create(t, repo, appointment(1, 2, 10, 0))
+
+	// A cancelled appointment in the same slot must be ignored
+	cancelled := appointment(1, 2, 10, 0)
+	cancelled.Status = model.StatusCancelled
+	create(t, repo, cancelled)
+
+	eastern := time.FixedZone("EDT", -4*60*60)
+
+	tests := []struct {
+		name     string
+		startsAt time.Time
+		endsAt   time.Time
+		want     bool
+	}{
+		{"range ends at appointment start", clock(9, 30), clock(10, 0), false},
+		{"range starts at appointment end", clock(10, 30), clock(11, 0), false},
+		{"range overlaps start by a minute", clock(9, 30), clock(10, 1), true},
+		{"range overlaps end by a minute", clock(10, 29), clock(11, 0), true},
+		{"identical range", clock(10, 0), clock(10, 30), true},
+		{"range inside appointment", clock(10, 10), clock(10, 20), true},
+		{"range encloses appointment", clock(9, 0), clock(12, 0), true},
+		{"empty range at appointment start", clock(10, 0), clock(10, 0), false},
+		{"range in another location, back to back", clock(10, 30).In(eastern), clock(11, 0).In(eastern), false},
+		{"range in another location, overlapping", clock(10, 15).In(eastern), clock(11, 0).In(eastern), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trainerBookings, err := repo.GetTrainerBookings(ctx, 1, tt.startsAt, tt.endsAt)
+			require.NoError(t, err)
+			clientBookings, err := repo.GetClientBookings(ctx, 2, tt.startsAt, tt.endsAt)
+			require.NoError(t, err)
+
+			if !tt.want {
+				assert.Empty(t, trainerBookings)
+				assert.Empty(t, clientBookings)
+				return
+			}
+
+			assert.Equal(t, []int64{booked.Id}, ids(trainerBookings))
+			assert.Equal(t, []int64{booked.Id}, ids(clientBookings))
+		})
+	}
+}
+
+// testContextCancellation checks that every method gives up on a cancelled
+// context with code REQUEST_CANCELLED, and that nothing is written.
+func testContextCancellation(t *testing.T, repo repository.AppointmentRepository) {
+	existing := create(t, repo, appointment(1, 2, 10, 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := map[string]func() error{
+		"Create": func() error {
+			_, err := repo.Create(ctx, appointment(1, 2, 11, 0))
+			return err
+		},
+		"CreateBatch": func() error {
+			_, err := repo.CreateBatch(ctx, []model.Appointment{appointment(1, 2, 11, 0), appointment(1, 2, 12, 0)})
+			return err
+		},
+		"List": func() error {
+			_, err := repo.List(ctx, model.AppointmentQuery{})
+			return err
+		},
+		"Stream": func() error {
+			return repo.Stream(ctx, model.AppointmentQuery{}, func(model.Appointment) error { return nil })
+		},
+		"Get": func() error {
+			_, err := repo.Get(ctx, existing.Id)
+			return err
+		},
+		"Update": func() error {
+			changed := *existing
+			changed.Status = model.StatusCompleted
+			_, err := repo.Update(ctx, changed)
+			return err
+		},
+		"Delete": func() error {
+			return repo.Delete(ctx, existing.Id)
+		},
+		"BookingStats": func() error {
+			_, err := repo.BookingStats(ctx, model.BookingStatsQuery{StartsAt: clock(0, 0), EndsAt: clock(23, 0)})
+			return err
+		},
+		"GetTrainerBookings": func() error {
+			_, err := repo.GetTrainerBookings(ctx, 1, clock(0, 0), clock(23, 0))
+			return err
+		},
+		"GetClientBookings": func() error {
+			_, err := repo.GetClientBookings(ctx, 2, clock(0, 0), clock(23, 0))
+			return err
+		},
+		"Ping": func() error {
+			return repo.Ping(ctx)
+		},
+	}
+
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			assertErrorCode(t, call(), http.StatusInternalServerError, errors.CodeRequestCancelled)
+		})
+	}
+
+	page, err := repo.List(context.Background(), model.AppointmentQuery{})
+	require.NoError(t, err)
+	if assert.Len(t, page.Appointments, 1) {
+		stored := page.Appointments[0]
+		assert.Equal(t, existing.Id, stored.Id)
+		assert.Equal(t, existing.Version, stored.Version)
+		assert.Equal(t, model.StatusScheduled, stored.Status)
+	}
+}
+
+// testConcurrency checks that the repository is safe for concurrent use.
+//
+// It includes the following test cases:
+//
+// * Concurrent creates all succeed with distinct IDs
+// * Of concurrent updates holding the same version, exactly one succeeds
+func testConcurrency(t *testing.T, repo repository.AppointmentRepository) {
+	ctx := context.Background()
+	const workers = 20
+
+	t.Run("creates", func(t *testing.T) {
+		var wg sync.WaitGroup
+		created := make([]int64, workers)
+		errs := make([]error, workers)
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				apt, err := repo.Create(ctx, appointment(int64(100+i), 2, 10, 0))
+				errs[i] = err
+				if err == nil {
+					created[i] = apt.Id
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		seen := make(map[int64]bool)
+		for i := 0; i < workers; i++ {
+			require.NoError(t, errs[i])
+			assert.False(t, seen[created[i]], "ID %d assigned twice", created[i])
+			seen[created[i]] = true
+		}
+	})
+
+	t.Run("updates of the same version", func(t *testing.T) {
+		original := create(t, repo, appointment(1, 2, 10, 0))
+
+		var wg sync.WaitGroup
+		errs := make([]error, workers)
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				changed := *original
+				changed.UserId = int64(1000 + i)
+				_, errs[i] = repo.Update(ctx, changed)
+			}(i)
+		}
+		wg.Wait()
+
+		succeeded := 0
+		for _, err := range errs {
+			if err == nil {
+				succeeded++
+				continue
+			}
+			assertErrorCode(t, err, http.StatusPreconditionFailed, errors.CodeVersionMismatch)
+		}
+		assert.Equal(t, 1, succeeded)
+
+		found, err := repo.Get(ctx, original.Id)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), found.Version)
+	})
+}
+
+// testPing checks that a working repository answers a ping, empty or not.
+func testPing(t *testing.T, repo repository.AppointmentRepository) {
+	require.NoError(t, repo.Ping(context.Background()))
+	create(t, repo, appointment(1, 2, 10, 0))
+	require.NoError(t, repo.Ping(context.Background()))
+}
@@ -2,11 +2,13 @@ package sqlite3
 
 import (
 	"appointment-service/internal/errors"
+	"appointment-service/internal/logger"
 	"appointment-service/internal/model"
 	"context"
+	"database/sql"
 	"fmt"
-	"log"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -21,64 +23,356 @@ type Repository struct {
 // New creates a new SQLite3 appointment repository with the given database path.
 // Returns error if connection fails.
 func New(dbPath string, logger *slog.Logger) (*Repository, error) {
-	db, err := sqlx.Connect("sqlite3", dbPath)
+	db, err := Open(dbPath)
 	if err != nil {
-		return nil, fmt.Errorf("connecting to database: %w", err)
+		return nil, err
 	}
 
-	log.Printf("Connected to SQLite DB at: %s", dbPath)
+	logger.Info("Connected to SQLite DB", "path", dbPath)
 	return &Repository{db: db, logger: logger}, nil
 }
 
+// log returns the logger of the request ctx belongs to, or the repository's own
+func (r *Repository) log(ctx context.Context) *slog.Logger {
+	return logger.FromContext(ctx, r.logger)
+}
+
+// Open connects to the SQLite3 database at dbPath.
+func Open(dbPath string) (*sqlx.DB, error) {
+	db, err := sqlx.Connect("sqlite3", dbPath)
+	if err != nil {
+		return nil, errors.StorageError("connecting to database", err)
+	}
+
+	// Every connection to ":memory:" opens its own, empty database, so the
+	// pool must never hold more than one
+	if dbPath == ":memory:" {
+		db.SetMaxOpenConns(1)
+	}
+
+	return db, nil
+}
+
+// insertQuery inserts one appointment at version 1 and returns it as stored
+const insertQuery = `
+	INSERT INTO appointments (trainer_id, user_id, start_time, end_time, status, version)
+	VALUES (:trainer_id, :user_id, :start_time, :end_time, :status, 1)
+	RETURNING id, trainer_id, user_id, start_time, end_time, status, version`
+
 // Create inserts a new appointment into the database.
 // Returns the created appointment with generated ID or error if insert fails.
 func (r *Repository) Create(ctx context.Context, apt model.Appointment) (*model.Appointment, error) {
-	const query = `
-		INSERT INTO appointments (trainer_id, user_id, start_time, end_time)
-		VALUES (:trainer_id, :user_id, :start_time, :end_time)
-		RETURNING id, trainer_id, user_id, start_time, end_time`
+	r.log(ctx).DebugContext(ctx, "Creating appointment",
+		"trainer_id", apt.TrainerId,
+		"user_id", apt.UserId,
+		"start_time", apt.StartTime,
+		"end_time", apt.EndTime)
+
+	result, err := insert(ctx, r.db, apt)
+	if err != nil {
+		return nil, err
+	}
+
+	r.log(ctx).DebugContext(ctx, "Created appointment", "appointment_id", result.Id)
+	return result, nil
+}
+
+// CreateBatch inserts the appointments in a single transaction.
+// Returns the created appointments in order, or error and nothing stored.
+func (r *Repository) CreateBatch(ctx context.Context, appointments []model.Appointment) ([]model.Appointment, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.StorageError("starting batch", err)
+	}
+	defer tx.Rollback()
+
+	created := make([]model.Appointment, len(appointments))
+	for i, apt := range appointments {
+		result, err := insert(ctx, tx, apt)
+		if err != nil {
+			return nil, err
+		}
+		created[i] = *result
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.StorageError("committing batch", err)
+	}
 
-	log.Printf("Creating appointment: %+v", apt)
+	r.log(ctx).DebugContext(ctx, "Created batch of appointments", "appointments", len(created))
+	return created, nil
+}
 
+// insert runs insertQuery on the database or within a transaction
+func insert(ctx context.Context, db sqlx.ExtContext, apt model.Appointment) (*model.Appointment, error) {
 	// Convert domain model to DB model
 	dbApt := toDBModel(apt)
-	rows, err := r.db.NamedQueryContext(ctx, query, dbApt)
+	rows, err := sqlx.NamedQueryContext(ctx, db, insertQuery, dbApt)
 	if err != nil {
-		return nil, fmt.Errorf("creating appointment: %w", err)
+		return nil, errors.StorageError("creating appointment", err)
 	}
 	defer rows.Close()
 
 	if !rows.Next() {
-		return nil, fmt.Errorf("no rows returned after insert")
+		return nil, errors.StorageError("no rows returned after insert", rows.Err())
 	}
 
 	// Scan the returned row into DB model
 	var created dbAppointment
 	if err := rows.StructScan(&created); err != nil {
-		return nil, fmt.Errorf("scanning created appointment: %w", err)
+		return nil, errors.StorageError("scanning created appointment", err)
 	}
 
 	result := toDomainModel(created)
-	log.Printf("Created appointment: %+v", result)
 	return &result, nil
 }
 
-// List retrieves all appointments for a given trainer ID.
-// Returns empty slice if no appointments found.
-func (r *Repository) List(ctx context.Context, trainerID int64) ([]model.Appointment, error) {
+// List retrieves one page of appointments matching the query.
+// Filtering, ordering and paging are all done in SQL. Paging is keyset based on
+// (start_time, id), which the (trainer_id, start_time) index serves directly
+// since SQLite indexes carry the rowid (id) as their last column.
+// Returns empty page if no appointments found.
+func (r *Repository) List(ctx context.Context, q model.AppointmentQuery) (*model.AppointmentPage, error) {
+	query, args := listQuery(q)
+
+	// Fetch one extra row to find out whether there is another page
+	if q.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, q.Limit+1)
+	}
+
+	var dbAppts []dbAppointment
+	if err := r.db.SelectContext(ctx, &dbAppts, query, args...); err != nil {
+		return nil, errors.StorageError("listing appointments", err)
+	}
+
+	page := &model.AppointmentPage{Appointments: toDomainModels(dbAppts)}
+	if q.Limit > 0 && len(page.Appointments) > q.Limit {
+		page.Appointments = page.Appointments[:q.Limit]
+		page.NextCursor = model.CursorAfter(page.Appointments[q.Limit-1]).Encode()
+	}
+
+	r.log(ctx).DebugContext(ctx, "Listed appointments",
+		"trainer_id", q.TrainerId,
+		"user_id", q.UserId,
+		"appointments", len(page.Appointments))
+	return page, nil
+}
+
+// streamPageSize is how many appointments Stream reads per query
+const streamPageSize = 500
+
+// Stream calls fn for every appointment matching the query's filters, reading
+// them in keyset pages of streamPageSize. Each page's query ends before fn
+// sees its rows, so a slow consumer never holds a connection, which for an
+// in-memory database is the only one, or a read lock that stalls writers.
+func (r *Repository) Stream(ctx context.Context, q model.AppointmentQuery, fn func(model.Appointment) error) error {
+	q.Limit, q.After = streamPageSize, nil
+	for {
+		query, args := listQuery(q)
+		query += " LIMIT ?"
+		args = append(args, q.Limit)
+
+		var dbAppts []dbAppointment
+		if err := r.db.SelectContext(ctx, &dbAppts, query, args...); err != nil {
+			return errors.StorageError("streaming appointments", err)
+		}
+
+		for _, dbApt := range dbAppts {
+			if ctx.Err() != nil {
+				return errors.RequestCancelledError(ctx.Err())
+			}
+			if err := fn(toDomainModel(dbApt)); err != nil {
+				return err
+			}
+		}
+		if len(dbAppts) < q.Limit {
+			return nil
+		}
+		q.After = model.CursorAfter(toDomainModel(dbAppts[len(dbAppts)-1]))
+	}
+}
+
+// listQuery builds the filtered, ordered query behind List and Stream
+func listQuery(q model.AppointmentQuery) (string, []interface{}) {
+	where := []string{"1 = 1"}
+	var args []interface{}
+
+	if q.TrainerId != 0 {
+		where = append(where, "trainer_id = ?")
+		args = append(args, q.TrainerId)
+	}
+	if q.UserId != 0 {
+		where = append(where, "user_id = ?")
+		args = append(args, q.UserId)
+	}
+	if !q.StartsAt.IsZero() {
+		where = append(where, "start_time >= ?")
+		args = append(args, q.StartsAt.UTC())
+	}
+	if !q.EndsAt.IsZero() {
+		where = append(where, "start_time < ?")
+		args = append(args, q.EndsAt.UTC())
+	}
+	if len(q.Statuses) > 0 {
+		where = append(where, "status IN (?"+strings.Repeat(", ?", len(q.Statuses)-1)+")")
+		for _, status := range q.Statuses {
+			args = append(args, string(status))
+		}
+	}
+
+	direction := "ASC"
+	comparison := ">"
+	if q.Sort == model.SortDescending {
+		direction = "DESC"
+		comparison = "<"
+	}
+	if q.After != nil {
+		where = append(where, fmt.Sprintf("(start_time, id) %s (?, ?)", comparison))
+		args = append(args, q.After.StartTime.UTC(), q.After.Id)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, trainer_id, user_id, start_time, end_time, status, version
+		FROM appointments
+		WHERE %s
+		ORDER BY start_time %s, id %s`, strings.Join(where, " AND "), direction, direction)
+	return query, args
+}
+
+// ListClientAppointments returns one page of the appointments of the client
+// userID, across trainers. Filtering on user_id lets SQLite walk the
+// (user_id, start_time, id) index in order, so pages come from the index
+// rather than a sort of the user's appointments.
+func (r *Repository) ListClientAppointments(ctx context.Context, userID int64, q model.AppointmentQuery) (*model.AppointmentPage, error) {
+	q.UserId = userID
+	return r.List(ctx, q)
+}
+
+// BookingStats aggregates the matching appointments in SQL, grouping by
+// trainer, the quarter hour of the start time and status. Times are stored as
+// UTC text, so strftime buckets them without any zone conversion.
+func (r *Repository) BookingStats(ctx context.Context, q model.BookingStatsQuery) ([]model.BookingStats, error) {
+	where := "start_time >= ? AND start_time < ?"
+	args := []interface{}{q.StartsAt.UTC(), q.EndsAt.UTC()}
+	if q.TrainerId != 0 {
+		where += " AND trainer_id = ?"
+		args = append(args, q.TrainerId)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			trainer_id,
+			strftime('%%Y-%%m-%%dT%%H:', start_time)
+				|| printf('%%02d', CAST(strftime('%%M', start_time) AS INTEGER) / 15 * 15)
+				|| ':00Z' AS bucket_start,
+			status,
+			COUNT(*) AS appointments,
+			SUM(strftime('%%s', end_time) - strftime('%%s', start_time)) / 60 AS booked_minutes
+		FROM appointments
+		WHERE %s
+		GROUP BY trainer_id, bucket_start, status
+		ORDER BY trainer_id, bucket_start, status`, where)
+
+	var rows []struct {
+		TrainerId     int64  `db:"trainer_id"`
+		BucketStart   string `db:"bucket_start"`
+		Status        string `db:"status"`
+		Appointments  int    `db:"appointments"`
+		BookedMinutes int    `db:"booked_minutes"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, errors.StorageError("aggregating bookings", err)
+	}
+
+	stats := make([]model.BookingStats, len(rows))
+	for i, row := range rows {
+		start, err := time.Parse(time.RFC3339, row.BucketStart)
+		if err != nil {
+			return nil, errors.StorageError("aggregating bookings", err)
+		}
+		stats[i] = model.BookingStats{
+			TrainerId:     row.TrainerId,
+			Start:         start,
+			Status:        model.AppointmentStatus(row.Status),
+			Appointments:  row.Appointments,
+			BookedMinutes: row.BookedMinutes,
+		}
+	}
+	return stats, nil
+}
+
+// Get retrieves a single appointment by ID.
+// Returns NotFoundError if appointment doesn't exist.
+func (r *Repository) Get(ctx context.Context, id int64) (*model.Appointment, error) {
 	const query = `
-		SELECT id, trainer_id, user_id, start_time, end_time
+		SELECT id, trainer_id, user_id, start_time, end_time, status, version
 		FROM appointments
-		WHERE trainer_id = ?`
+		WHERE id = ?`
 
-	var dbAppts []dbAppointment
-	if err := r.db.SelectContext(ctx, &dbAppts, query, trainerID); err != nil {
-		return nil, fmt.Errorf("listing appointments: %w", err)
+	var dbApt dbAppointment
+	if err := r.db.GetContext(ctx, &dbApt, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.AppointmentNotFoundError(id)
+		}
+		return nil, errors.StorageError("getting appointment", err)
 	}
 
-	appointments := toDomainModels(dbAppts)
-	log.Printf("Listed %d appointments for trainer %d", len(appointments), trainerID)
-	return appointments, nil
+	result := toDomainModel(dbApt)
+	return &result, nil
+}
+
+// Update replaces an appointment if the stored version matches apt.Version.
+// The version check and bump are done in a single UPDATE statement, so two
+// writers holding the same version cannot both succeed.
+// Returns PreconditionFailedError on a version mismatch and NotFoundError if
+// the appointment doesn't exist.
+func (r *Repository) Update(ctx context.Context, apt model.Appointment) (*model.Appointment, error) {
+	const query = `
+		UPDATE appointments
+		SET trainer_id = :trainer_id,
+			user_id = :user_id,
+			start_time = :start_time,
+			end_time = :end_time,
+			status = :status,
+			version = version + 1
+		WHERE id = :id AND version = :version
+		RETURNING id, trainer_id, user_id, start_time, end_time, status, version`
+
+	dbApt := toDBModel(apt)
+	rows, err := r.db.NamedQueryContext(ctx, query, dbApt)
+	if err != nil {
+		return nil, errors.StorageError("updating appointment", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, errors.StorageError("updating appointment", err)
+		}
+		// Release the connection before querying again, the pool may only
+		// have one
+		rows.Close()
+
+		// Nothing matched, figure out whether it is missing or stale
+		current, err := r.Get(ctx, apt.Id)
+		if err != nil {
+			return nil, err
+		}
+		return nil, errors.VersionMismatchError(apt.Id, apt.Version, current.Version)
+	}
+
+	var updated dbAppointment
+	if err := rows.StructScan(&updated); err != nil {
+		return nil, errors.StorageError("scanning updated appointment", err)
+	}
+
+	result := toDomainModel(updated)
+	r.log(ctx).DebugContext(ctx, "Updated appointment",
+		"appointment_id", result.Id,
+		"status", result.Status,
+		"appointment_version", result.Version)
+	return &result, nil
 }
 
 // Delete removes an appointment by ID.
@@ -86,35 +380,37 @@ func (r *Repository) List(ctx context.Context, trainerID int64) ([]model.Appoint
 func (r *Repository) Delete(ctx context.Context, id int64) error {
 	result, err := r.db.ExecContext(ctx, "DELETE FROM appointments WHERE id = ?", id)
 	if err != nil {
-		return fmt.Errorf("deleting appointment: %w", err)
+		return errors.StorageError("deleting appointment", err)
 	}
 
 	rows, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("checking affected rows: %w", err)
+		return errors.StorageError("checking affected rows", err)
 	}
 
 	if rows == 0 {
-		return errors.NotFoundError(fmt.Sprintf("appointment %d not found", id))
+		return errors.AppointmentNotFoundError(id)
 	}
 
-	log.Printf("Deleted appointment %d", id)
+	r.log(ctx).DebugContext(ctx, "Deleted appointment", "appointment_id", id)
 	return nil
 }
 
 // GetTrainerBookings retrieves all appointments for a trainer within the given time range.
-// Time range is inclusive of start and end times.
+// The range is half-open, [start, end), so back-to-back appointments do not
+// overlap. Cancelled appointments are ignored.
 func (r *Repository) GetTrainerBookings(ctx context.Context, trainerID int64, start, end time.Time) ([]model.Appointment, error) {
 	const query = `
-		SELECT id, trainer_id, user_id, start_time, end_time
+		SELECT id, trainer_id, user_id, start_time, end_time, status, version
 		FROM appointments
 		WHERE trainer_id = ?
-		AND end_time >= ?
-		AND start_time <= ?`
+		AND status != 'cancelled'
+		AND end_time > ?
+		AND start_time < ?`
 
 	var dbAppts []dbAppointment
-	if err := r.db.SelectContext(ctx, &dbAppts, query, trainerID, start, end); err != nil {
-		return nil, fmt.Errorf("getting booked appointments: %w", err)
+	if err := r.db.SelectContext(ctx, &dbAppts, query, trainerID, start.UTC(), end.UTC()); err != nil {
+		return nil, errors.StorageError("getting booked appointments", err)
 	}
 
 	appointments := toDomainModels(dbAppts)
@@ -122,24 +418,39 @@ func (r *Repository) GetTrainerBookings(ctx context.Context, trainerID int64, st
 }
 
 // GetClientBookings retrieves all appointments for a user within the given time range.
-// Time range is inclusive of start and end times.
+// The range is half-open, [start, end), so back-to-back appointments do not
+// overlap. Cancelled appointments are ignored.
 func (r *Repository) GetClientBookings(ctx context.Context, userId int64, start, end time.Time) ([]model.Appointment, error) {
 	const query = `
-		SELECT id, trainer_id, user_id, start_time, end_time
+		SELECT id, trainer_id, user_id, start_time, end_time, status, version
 		FROM appointments
 		WHERE user_id = ?
-		AND end_time >= ?
-		AND start_time <= ?`
+		AND status != 'cancelled'
+		AND end_time > ?
+		AND start_time < ?`
 
 	var dbAppts []dbAppointment
-	if err := r.db.SelectContext(ctx, &dbAppts, query, userId, start, end); err != nil {
-		return nil, fmt.Errorf("getting booked appointments: %w", err)
+	if err := r.db.SelectContext(ctx, &dbAppts, query, userId, start.UTC(), end.UTC()); err != nil {
+		return nil, errors.StorageError("getting booked appointments", err)
 	}
 
 	appointments := toDomainModels(dbAppts)
 	return appointments, nil
 }
 
+// DB returns the underlying connection pool, e.g. to migrate its schema.
+func (r *Repository) DB() *sql.DB {
+	return r.db.DB
+}
+
+// Ping checks the database answers.
+func (r *Repository) Ping(ctx context.Context) error {
+	if err := r.db.PingContext(ctx); err != nil {
+		return errors.StorageError("pinging database", err)
+	}
+	return nil
+}
+
 // Close closes the database connection.
 func (r *Repository) Close() error {
 	return r.db.Close()
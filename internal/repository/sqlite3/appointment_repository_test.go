@@ -0,0 +1,151 @@
+package sqlite3
+
+import (
+	"appointment-service/internal/errors"
+	"appointment-service/internal/migrate"
+	"appointment-service/internal/model"
+	"appointment-service/internal/repository"
+	"appointment-service/internal/repository/repositorytest"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConformance runs the shared repository conformance suite against a
+// fresh, migrated database per test, both as a file and in memory
+func TestConformance(t *testing.T) {
+	t.Run("file", func(t *testing.T) {
+		repositorytest.Run(t, func(t *testing.T) repository.AppointmentRepository {
+			return newMigratedRepository(t, filepath.Join(t.TempDir(), "appointments.db"))
+		})
+	})
+
+	t.Run("memory", func(t *testing.T) {
+		repositorytest.Run(t, func(t *testing.T) repository.AppointmentRepository {
+			return newMigratedRepository(t, ":memory:")
+		})
+	})
+}
+
+// TestConcurrentUpdate tests that two writers holding the same version of an
+// appointment cannot both update it, against a database file so the updates
+// run on separate connections.
+//
+// It includes the following test cases:
+//
+// * Of two updates racing with the same version, exactly one succeeds
+// * The other gets VERSION_MISMATCH with the version it lost to
+func TestConcurrentUpdate(t *testing.T) {
+	ctx := context.Background()
+	repo := newMigratedRepository(t, filepath.Join(t.TempDir(), "appointments.db"))
+	defer repo.Close()
+
+	start := time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+	for round := range 20 {
+		created, err := repo.Create(ctx, model.Appointment{
+			TrainerId: 1,
+			UserId:    int64(round + 1),
+			StartTime: start.Add(time.Duration(round) * time.Hour),
+			EndTime:   start.Add(time.Duration(round)*time.Hour + 30*time.Minute),
+			Status:    model.StatusScheduled,
+		})
+		require.NoError(t, err)
+		require.Equal(t, int64(1), created.Version)
+
+		var wg sync.WaitGroup
+		ready := make(chan struct{})
+		results := make([]error, 2)
+		for i, status := range []model.AppointmentStatus{model.StatusCancelled, model.StatusCompleted} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				update := *created
+				update.Status = status
+				<-ready
+				_, results[i] = repo.Update(ctx, update)
+			}()
+		}
+		close(ready)
+		wg.Wait()
+
+		var mismatches []*errors.AppError
+		for _, err := range results {
+			if err != nil {
+				appErr, ok := errors.IsAppError(err)
+				require.True(t, ok, err)
+				mismatches = append(mismatches, appErr)
+			}
+		}
+		require.Len(t, mismatches, 1, "round %d: exactly one update succeeds", round)
+		assert.Equal(t, http.StatusPreconditionFailed, mismatches[0].Code)
+		assert.Equal(t, errors.CodeVersionMismatch, mismatches[0].ErrorCode)
+		assert.EqualValues(t, 2, mismatches[0].Details["current_version"])
+
+		stored, err := repo.Get(ctx, created.Id)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), stored.Version)
+	}
+}
+
+// TestStreamPages tests that Stream reads past its first page.
+//
+// It includes the following test cases:
+//
+// * More appointments than a page, many sharing a start time, in both orders
+func TestStreamPages(t *testing.T) {
+	ctx := context.Background()
+	repo := newMigratedRepository(t, ":memory:")
+	defer repo.Close()
+
+	start := time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+	appointments := make([]model.Appointment, 2*streamPageSize+3)
+	for i := range appointments {
+		at := start.Add(time.Duration(i/4) * 30 * time.Minute)
+		appointments[i] = model.Appointment{TrainerId: int64(i%4 + 1), UserId: int64(i + 1), StartTime: at, EndTime: at.Add(30 * time.Minute), Status: model.StatusScheduled}
+	}
+	_, err := repo.CreateBatch(ctx, appointments)
+	require.NoError(t, err)
+
+	for _, sort := range []model.SortOrder{model.SortAscending, model.SortDescending} {
+		page, err := repo.List(ctx, model.AppointmentQuery{Sort: sort})
+		require.NoError(t, err)
+
+		var seen []int64
+		err = repo.Stream(ctx, model.AppointmentQuery{Sort: sort}, func(apt model.Appointment) error {
+			seen = append(seen, apt.Id)
+			return nil
+		})
+		require.NoError(t, err)
+
+		want := make([]int64, len(page.Appointments))
+		for i, apt := range page.Appointments {
+			want[i] = apt.Id
+		}
+		assert.Len(t, want, len(appointments))
+		assert.Equal(t, want, seen)
+	}
+}
+
+// newMigratedRepository opens a repository at dbPath and applies the
+// embedded migrations to it
+func newMigratedRepository(t *testing.T, dbPath string) *Repository {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo, err := New(dbPath, logger)
+	require.NoError(t, err)
+
+	migrator, err := migrate.New(repo.DB(), migrate.SQLite3, logger)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Up(context.Background()))
+
+	return repo
+}
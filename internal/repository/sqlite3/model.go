@@ -11,6 +11,8 @@ type dbAppointment struct {
 	UserId    int64     `db:"user_id"`
 	StartTime time.Time `db:"start_time"`
 	EndTime   time.Time `db:"end_time"`
+	Status    string    `db:"status"`
+	Version   int64     `db:"version"`
 }
 
 func toDBModel(a model.Appointment) dbAppointment {
@@ -18,8 +20,10 @@ func toDBModel(a model.Appointment) dbAppointment {
 		ID:        a.Id,
 		TrainerId: a.TrainerId,
 		UserId:    a.UserId,
-		StartTime: a.StartTime,
-		EndTime:   a.EndTime,
+		StartTime: a.StartTime.UTC(),
+		EndTime:   a.EndTime.UTC(),
+		Status:    string(a.Status),
+		Version:   a.Version,
 	}
 }
 
@@ -30,6 +34,8 @@ func toDomainModel(a dbAppointment) model.Appointment {
 		UserId:    a.UserId,
 		StartTime: a.StartTime,
 		EndTime:   a.EndTime,
+		Status:    model.AppointmentStatus(a.Status),
+		Version:   a.Version,
 	}
 }
 
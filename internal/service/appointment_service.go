@@ -1,140 +1,345 @@
 package service
 
 import (
+	"appointment-service/internal/availability"
 	"appointment-service/internal/errors"
+	"appointment-service/internal/logger"
 	"appointment-service/internal/model"
 	"appointment-service/internal/repository"
 	"context"
 	"fmt"
 	"log/slog"
+	"sync/atomic"
 	"time"
 )
 
-const appointmentDuration = 30 * time.Minute
-
 type AppointmentService struct {
 	repo   repository.AppointmentRepository
+	policy atomic.Pointer[policy]
 	logger *slog.Logger
 }
 
-func NewAppointmentService(repo repository.AppointmentRepository, logger *slog.Logger) AppointmentServicer {
-	return &AppointmentService{
+// policy is what the service books by, replaced as a whole so a call never
+// mixes old hours with new rules
+type policy struct {
+	hours WorkingHoursProvider
+	rules Rules
+}
+
+func NewAppointmentService(repo repository.AppointmentRepository, hours WorkingHoursProvider, rules Rules, logger *slog.Logger) *AppointmentService {
+	s := &AppointmentService{
 		repo:   repo,
 		logger: logger,
 	}
+	s.SetPolicy(hours, rules)
+	return s
+}
+
+// SetPolicy replaces the working hours and booking rules, e.g. when the
+// configuration is reloaded. Calls in flight finish with the old ones.
+func (s *AppointmentService) SetPolicy(hours WorkingHoursProvider, rules Rules) {
+	s.policy.Store(&policy{hours: hours, rules: rules})
+}
+
+// log returns the logger of the request ctx belongs to, or the service's own
+func (s *AppointmentService) log(ctx context.Context) *slog.Logger {
+	return logger.FromContext(ctx, s.logger)
 }
 
-func (s *AppointmentService) List(ctx context.Context, trainerId int64) ([]model.Appointment, error) {
-	return s.repo.List(ctx, trainerId)
+// List returns one page of appointments matching the query, e.g. a trainer's
+// schedule.
+// Sort order defaults to ascending and the page size to model.DefaultPageSize.
+func (s *AppointmentService) List(ctx context.Context, query model.AppointmentQuery) (*model.AppointmentPage, error) {
+	query, err := checkPage(query)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.List(ctx, query)
+}
+
+// ListClientAppointments returns one page of the appointments the client
+// userID booked, across trainers, with the same filters and defaults as List
+func (s *AppointmentService) ListClientAppointments(ctx context.Context, userID int64, query model.AppointmentQuery) (*model.AppointmentPage, error) {
+	if userID <= 0 {
+		return nil, errors.ValidationError("user_id must be positive").
+			WithCode(errors.CodeFieldInvalid).
+			WithDetail("field", "user_id")
+	}
+	query, err := checkPage(query)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListClientAppointments(ctx, userID, query)
+}
+
+// checkPage checks a listing query like checkQuery, and its page size
+func checkPage(query model.AppointmentQuery) (model.AppointmentQuery, error) {
+	query, err := checkQuery(query)
+	if err != nil {
+		return query, err
+	}
+
+	if query.Limit == 0 {
+		query.Limit = model.DefaultPageSize
+	}
+	if query.Limit < 0 || query.Limit > model.MaxPageSize {
+		return query, errors.ValidationError(fmt.Sprintf("limit must be between 1 and %d", model.MaxPageSize)).
+			WithCode(errors.CodeInvalidPageSize).
+			WithDetail("field", "limit")
+	}
+	return query, nil
+}
+
+// Export calls fn for every appointment matching the query's filters, e.g. a
+// month of bookings for finance. Appointments are streamed from storage, so
+// any number can be exported; Limit and After are ignored.
+func (s *AppointmentService) Export(ctx context.Context, query model.AppointmentQuery, fn func(model.Appointment) error) error {
+	query, err := checkQuery(query)
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	err = s.repo.Stream(ctx, query, func(apt model.Appointment) error {
+		count++
+		return fn(apt)
+	})
+	s.log(ctx).InfoContext(ctx, "Export", "trainer_id", query.TrainerId, "user_id", query.UserId, "appointments", count)
+	return err
+}
+
+// checkQuery defaults the sort order and rejects unknown orders and empty
+// time ranges
+func checkQuery(query model.AppointmentQuery) (model.AppointmentQuery, error) {
+	switch query.Sort {
+	case "":
+		query.Sort = model.SortAscending
+	case model.SortAscending, model.SortDescending:
+	default:
+		return query, errors.ValidationError(fmt.Sprintf("sort must be %q or %q", model.SortAscending, model.SortDescending)).
+			WithCode(errors.CodeInvalidSortOrder).
+			WithDetail("field", "sort")
+	}
+
+	if !query.StartsAt.IsZero() && !query.EndsAt.IsZero() && !query.EndsAt.After(query.StartsAt) {
+		return query, errors.ValidationError("ends_at must be after starts_at").
+			WithCode(errors.CodeInvalidTimeRange).
+			WithDetail("field", "ends_at")
+	}
+
+	return query, nil
+}
+
+// Get returns a single appointment by ID
+func (s *AppointmentService) Get(ctx context.Context, id int64) (*model.Appointment, error) {
+	return s.repo.Get(ctx, id)
+}
+
+// Validate runs every business rule for the appointment's trainer and reports
+// all failures at once. It does not check for conflicting bookings.
+func (s *AppointmentService) Validate(ctx context.Context, apt model.Appointment) error {
+	policy := s.policy.Load()
+	hours, err := policy.hours.WorkingHours(ctx, apt.TrainerId)
+	if err != nil {
+		return err
+	}
+
+	return apt.ValidateAll([]model.ValidationRule{
+		model.MustLast(policy.rules.Duration),
+		model.MustBeWithinWorkingHours(hours),
+	})
 }
 
 func (s *AppointmentService) Create(ctx context.Context, apt model.Appointment) (*model.Appointment, error) {
-	// Run all default validation rules
-	if err := apt.Validate(model.DefaultValidationRules); err != nil {
+	// Run all validation rules, reporting every failure at once
+	if err := s.Validate(ctx, apt); err != nil {
+		return nil, err
+	}
+
+	// Check trainer and client availability
+	if err := s.checkAvailability(ctx, apt); err != nil {
+		return nil, err
+	}
+
+	// VALID!  Create the appointment!
+	apt.Status = model.StatusScheduled
+	return s.repo.Create(ctx, apt)
+}
+
+// Reschedule moves an appointment to a new time. The caller's version must
+// match the stored version, otherwise the write is rejected as stale.
+func (s *AppointmentService) Reschedule(ctx context.Context, id int64, startTime, endTime time.Time, version int64) (*model.Appointment, error) {
+	current, err := s.getForUpdate(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if current.Status != model.StatusScheduled {
+		return nil, errors.ConflictError(fmt.Sprintf("appointment %d is %s and cannot be rescheduled", id, current.Status)).
+			WithCode(errors.CodeInvalidStatusTransition).
+			WithDetail("appointment_id", id).
+			WithDetail("status", current.Status)
+	}
+
+	updated := *current
+	updated.StartTime = startTime
+	updated.EndTime = endTime
+
+	if err := s.Validate(ctx, updated); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkAvailability(ctx, updated); err != nil {
 		return nil, err
 	}
 
-	// Check trainer availability
-	trainerBookings, err := s.repo.GetTrainerBookings(ctx, apt.TrainerId, apt.StartTime, apt.EndTime)
+	return s.repo.Update(ctx, updated)
+}
+
+// Cancel marks an appointment as cancelled, freeing up its time slot.
+func (s *AppointmentService) Cancel(ctx context.Context, id int64, version int64) (*model.Appointment, error) {
+	return s.UpdateStatus(ctx, id, model.StatusCancelled, version)
+}
+
+// UpdateStatus moves an appointment to a new status, e.g. completed or no_show.
+// Only transitions allowed by model.AppointmentStatus.CanTransitionTo are accepted.
+func (s *AppointmentService) UpdateStatus(ctx context.Context, id int64, status model.AppointmentStatus, version int64) (*model.Appointment, error) {
+	if !status.IsValid() {
+		return nil, errors.ValidationError(fmt.Sprintf("unknown appointment status %q", status)).
+			WithCode(errors.CodeUnknownStatus).
+			WithDetail("field", "status")
+	}
+
+	current, err := s.getForUpdate(ctx, id, version)
 	if err != nil {
-		return nil, errors.InternalError("checking trainer availability: %w", err)
+		return nil, err
+	}
+
+	if !current.Status.CanTransitionTo(status) {
+		return nil, errors.ConflictError(fmt.Sprintf("appointment %d cannot move from %s to %s", id, current.Status, status)).
+			WithCode(errors.CodeInvalidStatusTransition).
+			WithDetail("appointment_id", id).
+			WithDetail("status", current.Status).
+			WithDetail("requested_status", status)
 	}
-	if len(trainerBookings) > 0 {
+
+	updated := *current
+	updated.Status = status
+	return s.repo.Update(ctx, updated)
+}
+
+// getForUpdate loads an appointment and checks the caller's version up front,
+// so stale writes fail fast before any validation or conflict checks run.
+// The repository re-checks the version atomically when the update is written.
+func (s *AppointmentService) getForUpdate(ctx context.Context, id int64, version int64) (*model.Appointment, error) {
+	current, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if current.Version != version {
+		return nil, errors.VersionMismatchError(id, version, current.Version)
+	}
+
+	return current, nil
+}
+
+// checkAvailability ensures neither the trainer nor the client has another
+// booking overlapping the appointment. The appointment itself is ignored so
+// that rescheduling within its own slot is allowed.
+func (s *AppointmentService) checkAvailability(ctx context.Context, apt model.Appointment) error {
+	// Check trainer availability, keeping the buffer to their other bookings
+	buffer := s.policy.Load().rules.Buffer
+	trainerBookings, err := s.repo.GetTrainerBookings(ctx, apt.TrainerId, apt.StartTime.Add(-buffer), apt.EndTime.Add(buffer))
+	if err != nil {
+		return err
+	}
+	if conflicts := excludeAppointment(trainerBookings, apt.Id); len(conflicts) > 0 {
 		errMsg := fmt.Sprintf("trainer %d is not available between %v and %v", apt.TrainerId, apt.StartTime, apt.EndTime)
-		return nil, errors.ConflictError(errMsg)
+		return errors.ConflictError(errMsg).
+			WithCode(errors.CodeTrainerUnavailable).
+			WithDetail("trainer_id", apt.TrainerId).
+			WithDetail("conflicting_appointment_ids", appointmentIds(conflicts))
 	}
 
 	// Check client availability
 	clientBookings, err := s.repo.GetClientBookings(ctx, apt.UserId, apt.StartTime, apt.EndTime)
 	if err != nil {
-		return nil, errors.InternalError("checking user availability: %w", err)
+		return err
 	}
-	if len(clientBookings) > 0 {
+	if conflicts := excludeAppointment(clientBookings, apt.Id); len(conflicts) > 0 {
 		errMsg := fmt.Sprintf("user %d is not available between %v and %v", apt.UserId, apt.StartTime, apt.EndTime)
-		return nil, errors.ConflictError(errMsg)
+		return errors.ConflictError(errMsg).
+			WithCode(errors.CodeUserUnavailable).
+			WithDetail("user_id", apt.UserId).
+			WithDetail("conflicting_appointment_ids", appointmentIds(conflicts))
 	}
 
-	// VALID!  Create the appointment!
-	return s.repo.Create(ctx, apt)
+	return nil
 }
 
+// excludeAppointment filters the appointment with the given ID out of the bookings
+func excludeAppointment(bookings []model.Appointment, id int64) []model.Appointment {
+	var others []model.Appointment
+	for _, b := range bookings {
+		if b.Id != id {
+			others = append(others, b)
+		}
+	}
+	return others
+}
+
+// appointmentIds returns the IDs of the given appointments
+func appointmentIds(appointments []model.Appointment) []int64 {
+	ids := make([]int64, len(appointments))
+	for i, apt := range appointments {
+		ids[i] = apt.Id
+	}
+	return ids
+}
+
+// GetAvailability returns the trainer's open slots within the window. Slots are
+// aligned to the trainer's local wall clock and follow their working hours,
+// including on DST transition days.
 func (s *AppointmentService) GetAvailability(ctx context.Context, trainerID int64, windowStartsAtUTC time.Time, windowEndsAtUTC time.Time) ([]model.TimeSlot, error) {
 	// Ensure input times are UTC
 	windowStartsAtUTC = windowStartsAtUTC.UTC()
 	windowEndsAtUTC = windowEndsAtUTC.UTC()
 
-	// Get all booked appointments in the time range
-	booked, err := s.repo.GetTrainerBookings(ctx, trainerID, windowStartsAtUTC, windowEndsAtUTC)
+	policy := s.policy.Load()
+	if width := windowEndsAtUTC.Sub(windowStartsAtUTC); policy.rules.MaxWindow > 0 && width > policy.rules.MaxWindow {
+		return nil, errors.ValidationError(fmt.Sprintf(
+			"availability window covers %s, at most %s is allowed", width, policy.rules.MaxWindow)).
+			WithCode(errors.CodeInvalidTimeRange).
+			WithDetail("field", "ends_at").
+			WithDetail("max_window", policy.rules.MaxWindow.String())
+	}
+
+	// Get all booked appointments in the time range, or whose buffer reaches into it
+	booked, err := s.repo.GetTrainerBookings(ctx, trainerID, windowStartsAtUTC.Add(-policy.rules.Buffer), windowEndsAtUTC.Add(policy.rules.Buffer))
 	if err != nil {
 		return nil, err
 	}
 
-	// Load Pacific timezone
-	loc, err := time.LoadLocation("America/Los_Angeles")
+	// Look up when, and in which time zone, the trainer works
+	hours, err := policy.hours.WorkingHours(ctx, trainerID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load Pacific timezone: %w", err)
+		return nil, err
 	}
 
-	// Round start time up to next :00 or :30
-	currentSlotStart := roundUpToNextSlot(windowStartsAtUTC)
-	s.logger.Info("Slot calculation",
-		"original_start", windowStartsAtUTC.Format(time.RFC3339),
-		"rounded_start", currentSlotStart.Format(time.RFC3339))
-
-	var available []model.TimeSlot
-	for currentSlotStart.Add(appointmentDuration).Before(windowEndsAtUTC) ||
-		currentSlotStart.Add(appointmentDuration).Equal(windowEndsAtUTC) {
-
-		currentSlotEnd := currentSlotStart.Add(appointmentDuration)
-
-		// Convert UTC slot time to Pacific to check business hours
-		slotStartPacific := currentSlotStart.In(loc)
-
-		// Extract hour in Pacific time
-		hour := slotStartPacific.Hour()
-
-		// Check if slot starts during business hours (8 AM - 5 PM Pacific)
-		if hour >= 8 && hour < 17 {
-			// Check if this slot overlaps with any booked appointments
-			isAvailable := true
-			for _, bookedApt := range booked {
-				// A slot overlaps if it starts before the booked appointment ends
-				// AND ends after the booked appointment starts
-				if currentSlotStart.Before(bookedApt.EndTime) &&
-					currentSlotEnd.After(bookedApt.StartTime) {
-					isAvailable = false
-					break
-				}
-			}
-
-			if isAvailable {
-				available = append(available, model.TimeSlot{
-					StartTime: currentSlotStart.UTC(),
-					EndTime:   currentSlotEnd.UTC(),
-				})
-			}
-		}
-
-		currentSlotStart = currentSlotEnd
+	// Slice the working time that is not booked into slots, aligned in local time
+	schedule := availability.Schedule{
+		Hours:    hours,
+		Bookings: policy.rules.blocked(booked),
 	}
+	window := availability.Interval{Start: windowStartsAtUTC, End: windowEndsAtUTC}
+	available := schedule.OpenSlots(window, policy.rules.Duration)
 
-	return available, nil
-}
-
-// roundUpToNextSlot rounds up a time to the next :00 or :30 minute mark
-func roundUpToNextSlot(t time.Time) time.Time {
-	t = t.UTC()
-	minute := t.Minute()
-
-	// If we're already on a slot boundary (:00 or :30), don't round up
-	if minute == 0 || minute == 30 {
-		return t
-	}
+	s.log(ctx).InfoContext(ctx, "Slot calculation",
+		"original_start", windowStartsAtUTC.Format(time.RFC3339),
+		"time_zone", hours.Location.String(),
+		"bookings", len(booked),
+		"open_slots", len(available))
 
-	if minute < 30 {
-		// Round up to next :30
-		return t.Truncate(time.Hour).Add(30 * time.Minute)
-	}
-	// Round up to next :00
-	return t.Truncate(time.Hour).Add(time.Hour)
+	return available, nil
 }
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"appointment-service/internal/availability"
+	"appointment-service/internal/errors"
+	"appointment-service/internal/model"
+	"context"
+	"fmt"
+	"time"
+)
+
+// GetCalendar summarises the trainer's availability for every local date from
+// the date of `from` through the date of `to`, both inclusive, in loc. A nil
+// loc means the trainer's own time zone.
+//
+// Slots are counted as they are walked, so no slot list is built, whatever
+// the range.
+func (s *AppointmentService) GetCalendar(ctx context.Context, trainerID int64, from, to time.Time, loc *time.Location) (*model.Calendar, error) {
+	policy := s.policy.Load()
+	hours, err := policy.hours.WorkingHours(ctx, trainerID)
+	if err != nil {
+		return nil, err
+	}
+	if loc == nil {
+		loc = hours.Location
+	}
+
+	// Dates are taken as given, whatever zone they were parsed in
+	firstYear, firstMonth, firstDay := from.Date()
+	numDays := daysBetween(from, to) + 1
+	if numDays < 1 {
+		return nil, errors.ValidationError("to must not be before from").
+			WithCode(errors.CodeInvalidTimeRange).
+			WithDetail("field", "to")
+	}
+	if numDays > model.MaxCalendarDays {
+		return nil, errors.ValidationError(fmt.Sprintf(
+			"calendar covers %d days, at most %d are allowed", numDays, model.MaxCalendarDays)).
+			WithCode(errors.CodeInvalidTimeRange).
+			WithDetail("field", "to").
+			WithDetail("max_days", model.MaxCalendarDays)
+	}
+
+	calendar := &model.Calendar{
+		Location: loc,
+		Days:     make([]model.CalendarDay, numDays),
+	}
+	for i := range calendar.Days {
+		calendar.Days[i] = model.CalendarDay{
+			Date:   time.Date(firstYear, firstMonth, firstDay+i, 0, 0, 0, 0, loc),
+			Closed: true,
+		}
+	}
+
+	windowStart := calendar.Days[0].Date.UTC()
+	windowEnd := time.Date(firstYear, firstMonth, firstDay+numDays, 0, 0, 0, 0, loc).UTC()
+
+	booked, err := s.repo.GetTrainerBookings(ctx, trainerID, windowStart.Add(-policy.rules.Buffer), windowEnd.Add(policy.rules.Buffer))
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := availability.Schedule{
+		Hours:    hours,
+		Bookings: policy.rules.blocked(booked),
+	}
+	window := availability.Interval{Start: windowStart, End: windowEnd}
+	schedule.WalkSlots(window, policy.rules.Duration, func(slot availability.Interval, open bool) {
+		i := daysBetween(calendar.Days[0].Date, slot.Start.In(loc))
+		if i < 0 || i >= numDays {
+			return
+		}
+		day := &calendar.Days[i]
+		day.Closed = false
+		if !open {
+			return
+		}
+
+		day.OpenSlots++
+		if day.FirstOpen.IsZero() {
+			day.FirstOpen = slot.Start
+		}
+		day.LastOpen = slot.Start
+	})
+
+	for i := range calendar.Days {
+		day := &calendar.Days[i]
+		day.FullyBooked = !day.Closed && day.OpenSlots == 0
+	}
+
+	s.log(ctx).InfoContext(ctx, "Calendar calculation",
+		"trainer_id", trainerID,
+		"time_zone", loc.String(),
+		"days", numDays,
+		"bookings", len(booked))
+
+	return calendar, nil
+}
+
+// daysBetween counts calendar days from the date of a to the date of b, each
+// taken in its own location
+func daysBetween(a, b time.Time) int {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	first := time.Date(ay, am, ad, 0, 0, 0, 0, time.UTC)
+	last := time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC)
+	return int(last.Sub(first).Hours() / 24)
+}
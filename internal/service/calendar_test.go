@@ -0,0 +1,149 @@
+package service
+
+import (
+	"appointment-service/internal/errors"
+	"appointment-service/internal/model"
+	"appointment-service/internal/repository/memory"
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetCalendar tests the per-day availability summary.
+//
+// It includes the following test cases:
+//
+// * Open, partially booked, fully booked and closed days
+// * Days in the caller's time zone rather than the trainer's
+// * A spring forward day, which has fewer slots
+// * Agreement with GetAvailability over a two month range
+// * Ranges that are reversed or longer than model.MaxCalendarDays
+func TestGetCalendar(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	la, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+
+	// Trainer 1 works 8am to 10am Los Angeles time, Monday to Friday
+	hours := &StaticWorkingHours{Default: model.WorkingHours{
+		Location: la,
+		Start:    8 * time.Hour,
+		End:      10 * time.Hour,
+		Weekdays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	}}
+
+	repo := memory.New(logger)
+	svc := NewAppointmentService(repo, hours, DefaultRules(), logger)
+
+	// Monday 2025-06-02: 8:30 is booked
+	// Tuesday 2025-06-03: every slot is booked, back to back
+	book := func(local string) {
+		start, err := time.ParseInLocation("2006-01-02 15:04", local, la)
+		require.NoError(t, err)
+		_, err = svc.Create(ctx, model.Appointment{
+			TrainerId: 1,
+			UserId:    2,
+			StartTime: start.UTC(),
+			EndTime:   start.Add(30 * time.Minute).UTC(),
+		})
+		require.NoError(t, err)
+	}
+	book("2025-06-02 08:30")
+	for _, slot := range []string{"08:00", "08:30", "09:00", "09:30"} {
+		book("2025-06-03 " + slot)
+	}
+
+	t.Run("open, booked and closed days", func(t *testing.T) {
+		calendar, err := svc.GetCalendar(ctx, 1, date(2025, 6, 1), date(2025, 6, 4), nil)
+		require.NoError(t, err)
+		assert.Equal(t, "America/Los_Angeles", calendar.Location.String())
+		require.Len(t, calendar.Days, 4)
+
+		sunday, monday, tuesday, wednesday := calendar.Days[0], calendar.Days[1], calendar.Days[2], calendar.Days[3]
+
+		assert.True(t, sunday.Closed)
+		assert.False(t, sunday.FullyBooked)
+		assert.Zero(t, sunday.OpenSlots)
+
+		assert.Equal(t, 3, monday.OpenSlots)
+		assert.Equal(t, "2025-06-02T08:00:00-07:00", monday.FirstOpen.In(la).Format(time.RFC3339))
+		assert.Equal(t, "2025-06-02T09:30:00-07:00", monday.LastOpen.In(la).Format(time.RFC3339))
+
+		assert.True(t, tuesday.FullyBooked)
+		assert.False(t, tuesday.Closed)
+		assert.True(t, tuesday.FirstOpen.IsZero())
+
+		assert.Equal(t, 4, wednesday.OpenSlots)
+		assert.False(t, wednesday.Closed || wednesday.FullyBooked)
+	})
+
+	t.Run("caller time zone", func(t *testing.T) {
+		// 8am to 10am Los Angeles is 3am to 5am the next day in Auckland
+		auckland, err := time.LoadLocation("Pacific/Auckland")
+		require.NoError(t, err)
+
+		calendar, err := svc.GetCalendar(ctx, 1, date(2025, 6, 2), date(2025, 6, 4), auckland)
+		require.NoError(t, err)
+		require.Len(t, calendar.Days, 3)
+
+		// Monday in Auckland is Sunday in Los Angeles
+		assert.True(t, calendar.Days[0].Closed)
+		assert.Equal(t, 3, calendar.Days[1].OpenSlots)
+		assert.Equal(t, "2025-06-03T03:00:00+12:00", calendar.Days[1].FirstOpen.In(auckland).Format(time.RFC3339))
+		assert.True(t, calendar.Days[2].FullyBooked)
+	})
+
+	t.Run("spring forward day", func(t *testing.T) {
+		night := &StaticWorkingHours{Default: model.WorkingHours{Location: la, Start: 0, End: 6 * time.Hour}}
+		svc := NewAppointmentService(memory.New(logger), night, DefaultRules(), logger)
+
+		calendar, err := svc.GetCalendar(ctx, 1, date(2024, 3, 9), date(2024, 3, 10), nil)
+		require.NoError(t, err)
+		assert.Equal(t, 12, calendar.Days[0].OpenSlots)
+		assert.Equal(t, 10, calendar.Days[1].OpenSlots)
+	})
+
+	t.Run("agrees with availability", func(t *testing.T) {
+		calendar, err := svc.GetCalendar(ctx, 1, date(2025, 5, 1), date(2025, 6, 30), nil)
+		require.NoError(t, err)
+
+		from := time.Date(2025, 5, 1, 0, 0, 0, 0, la)
+		to := time.Date(2025, 7, 1, 0, 0, 0, 0, la)
+		slots, err := svc.GetAvailability(ctx, 1, from, to)
+		require.NoError(t, err)
+
+		perDay := make(map[string]int)
+		for _, slot := range slots {
+			perDay[slot.StartTime.In(la).Format(time.DateOnly)]++
+		}
+		for _, day := range calendar.Days {
+			assert.Equal(t, perDay[day.Date.Format(time.DateOnly)], day.OpenSlots, day.Date.Format(time.DateOnly))
+		}
+	})
+
+	t.Run("invalid ranges", func(t *testing.T) {
+		_, err := svc.GetCalendar(ctx, 1, date(2025, 6, 2), date(2025, 6, 1), nil)
+		appErr, ok := errors.IsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, errors.CodeInvalidTimeRange, appErr.ErrorCode)
+
+		_, err = svc.GetCalendar(ctx, 1, date(2025, 1, 1), date(2025, 1, 1).AddDate(0, 0, model.MaxCalendarDays), nil)
+		appErr, ok = errors.IsAppError(err)
+		require.True(t, ok)
+		assert.Equal(t, errors.CodeInvalidTimeRange, appErr.ErrorCode)
+		assert.Equal(t, model.MaxCalendarDays, appErr.Details["max_days"])
+
+		_, err = svc.GetCalendar(ctx, 1, date(2025, 1, 1), date(2025, 1, 1).AddDate(0, 0, model.MaxCalendarDays-1), nil)
+		assert.NoError(t, err)
+	})
+}
+
+// date returns a calendar date as parsed from a query string
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
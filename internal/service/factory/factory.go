@@ -1,14 +1,55 @@
 package factory
 
 import (
+	"appointment-service/internal/config"
+	"appointment-service/internal/model"
 	"appointment-service/internal/repository"
 	"appointment-service/internal/service"
+	"fmt"
 	"log/slog"
+	"time"
 )
 
 // NewAppointmentService creates a new appointment service with all its dependencies
 // Dont really need a factory for this, as there is only one
 // but it's here for consistency
-func NewAppointmentService(repo repository.AppointmentRepository, logger *slog.Logger) service.AppointmentServicer {
-	return service.NewAppointmentService(repo, logger.With("service", "AppointmentService"))
+func NewAppointmentService(cfg *config.Config, repo repository.AppointmentRepository, logger *slog.Logger) (*service.AppointmentService, error) {
+	hours, err := WorkingHours(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return service.NewAppointmentService(repo, hours, Rules(cfg), logger.With("service", "AppointmentService")), nil
+}
+
+// WorkingHours returns the configured business hours, in the trainers' own
+// time zones where they have one
+func WorkingHours(cfg *config.Config) (*service.StaticWorkingHours, error) {
+	loc, err := time.LoadLocation(cfg.Business.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid BUSINESS_TIMEZONE: %w", err)
+	}
+
+	timezones, err := model.ParseTrainerTimezones(cfg.TrainerTimezones)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TRAINER_TIMEZONES: %w", err)
+	}
+
+	return &service.StaticWorkingHours{
+		Default: model.WorkingHours{
+			Location: loc,
+			Start:    cfg.Business.OpensAt,
+			End:      cfg.Business.ClosesAt,
+			Weekdays: cfg.Business.Weekdays,
+		},
+		Timezones: timezones,
+	}, nil
+}
+
+// Rules returns the configured booking rules
+func Rules(cfg *config.Config) service.Rules {
+	return service.Rules{
+		Duration:  cfg.Business.AppointmentDuration,
+		Buffer:    cfg.Business.Buffer,
+		MaxWindow: cfg.Business.MaxAvailabilityWindow,
+	}
 }
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"appointment-service/internal/errors"
+	"appointment-service/internal/model"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Import validates and books many appointments at once, e.g. a new studio's
+// existing bookings. Every row gets the same rule and conflict checks as
+// Create, and is also checked against the accepted rows before it in the same
+// file. Rows keep their status, so completed history can be imported too;
+// cancelled rows never conflict with anything.
+//
+// Rows that fail a check are rejected with the reason, storage failures abort
+// the whole import. In all-or-nothing mode nothing is committed unless every
+// row is accepted, and then every row is committed in one CreateBatch, so
+// other readers see all of the import or none of it. In partial mode accepted
+// rows are committed in batches of opts.BatchSize, each batch atomically. A
+// dry run stops before committing anything.
+func (s *AppointmentService) Import(ctx context.Context, rows []model.ImportRow, opts model.ImportOptions) (*model.ImportReport, error) {
+	opts, err := importOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > model.MaxImportRows {
+		return nil, errors.ValidationError(fmt.Sprintf("import has %d rows, at most %d are allowed", len(rows), model.MaxImportRows)).
+			WithCode(errors.CodeInvalidImportFile).
+			WithDetail("max_rows", model.MaxImportRows)
+	}
+
+	report := &model.ImportReport{
+		Mode:   opts.Mode,
+		DryRun: opts.DryRun,
+		Rows:   make([]model.ImportRowResult, len(rows)),
+	}
+
+	// Check every row, against storage and against the file so far
+	// ---------------------------------------------------------------
+	var accepted []int
+	var appointments []model.Appointment
+	inFile := importedBookings{trainers: map[int64][]importedBooking{}, users: map[int64][]importedBooking{}, buffer: s.policy.Load().rules.Buffer}
+	for i, row := range rows {
+		result := &report.Rows[i]
+		result.Line = row.Line
+
+		if err := s.checkImportRow(ctx, &row, inFile); err != nil {
+			if !isRowError(err) {
+				return nil, err
+			}
+			result.Err = err
+			report.Rejected++
+			continue
+		}
+
+		inFile.add(row)
+		result.Accepted = true
+		report.Accepted++
+		accepted = append(accepted, i)
+		appointments = append(appointments, row.Appointment)
+	}
+
+	if opts.DryRun || (opts.Mode == model.ImportAllOrNothing && report.Rejected > 0) {
+		s.logImport(ctx, report)
+		return report, nil
+	}
+
+	// Commit the accepted rows batch by batch, or all-or-nothing in one
+	// ---------------------------------------------------------------------
+	batchSize := opts.BatchSize
+	if opts.Mode == model.ImportAllOrNothing {
+		batchSize = max(len(accepted), 1)
+	}
+	for start := 0; start < len(accepted); start += batchSize {
+		end := min(start+batchSize, len(accepted))
+		batch := accepted[start:end]
+
+		created, err := s.repo.CreateBatch(ctx, appointments[start:end])
+		if err != nil {
+			if opts.Mode == model.ImportAllOrNothing {
+				return nil, err
+			}
+			for _, i := range batch {
+				report.Rows[i].Accepted = false
+				report.Rows[i].Err = err
+			}
+			report.Accepted -= len(batch)
+			report.Rejected += len(batch)
+			continue
+		}
+
+		for j, i := range batch {
+			report.Rows[i].AppointmentId = created[j].Id
+		}
+		report.Imported += len(created)
+	}
+
+	s.logImport(ctx, report)
+	return report, nil
+}
+
+// importOptions applies the defaults and rejects options out of range
+func importOptions(opts model.ImportOptions) (model.ImportOptions, error) {
+	switch opts.Mode {
+	case "":
+		opts.Mode = model.ImportAllOrNothing
+	case model.ImportAllOrNothing, model.ImportPartial:
+	default:
+		return opts, errors.ValidationError(fmt.Sprintf("mode must be %q or %q", model.ImportAllOrNothing, model.ImportPartial)).
+			WithCode(errors.CodeFieldInvalid).
+			WithDetail("field", "mode")
+	}
+
+	if opts.BatchSize == 0 {
+		opts.BatchSize = model.DefaultImportBatchSize
+	}
+	if opts.BatchSize < 0 || opts.BatchSize > model.MaxImportBatchSize {
+		return opts, errors.ValidationError(fmt.Sprintf("batch_size must be between 1 and %d", model.MaxImportBatchSize)).
+			WithCode(errors.CodeFieldInvalid).
+			WithDetail("field", "batch_size")
+	}
+
+	return opts, nil
+}
+
+// checkImportRow runs the parse, rule and conflict checks of one row,
+// defaulting its status to scheduled
+func (s *AppointmentService) checkImportRow(ctx context.Context, row *model.ImportRow, inFile importedBookings) error {
+	if row.Err != nil {
+		return row.Err
+	}
+
+	apt := &row.Appointment
+	if apt.Status == "" {
+		apt.Status = model.StatusScheduled
+	}
+
+	if err := s.Validate(ctx, *apt); err != nil {
+		return err
+	}
+	if apt.Status == model.StatusCancelled {
+		return nil
+	}
+	if err := inFile.check(*apt); err != nil {
+		return err
+	}
+	return s.checkAvailability(ctx, *apt)
+}
+
+// isRowError reports whether err rejects a single row, rather than failing
+// the whole import
+func isRowError(err error) bool {
+	appErr, ok := errors.IsAppError(err)
+	return ok && appErr.Code < http.StatusInternalServerError
+}
+
+func (s *AppointmentService) logImport(ctx context.Context, report *model.ImportReport) {
+	s.log(ctx).InfoContext(ctx, "Import",
+		"mode", report.Mode,
+		"dry_run", report.DryRun,
+		"rows", len(report.Rows),
+		"accepted", report.Accepted,
+		"rejected", report.Rejected,
+		"imported", report.Imported)
+}
+
+// importedBooking is an accepted row holding a trainer's or user's time
+type importedBooking struct {
+	line int
+	apt  model.Appointment
+}
+
+// importedBookings indexes the accepted rows of an import by trainer and by
+// user, so each row is only compared with rows that could conflict with it
+type importedBookings struct {
+	trainers map[int64][]importedBooking
+	users    map[int64][]importedBooking
+
+	// buffer is the gap kept between two appointments of a trainer
+	buffer time.Duration
+}
+
+func (b importedBookings) add(row model.ImportRow) {
+	if row.Appointment.Status == model.StatusCancelled {
+		return
+	}
+	booking := importedBooking{line: row.Line, apt: row.Appointment}
+	b.trainers[row.Appointment.TrainerId] = append(b.trainers[row.Appointment.TrainerId], booking)
+	b.users[row.Appointment.UserId] = append(b.users[row.Appointment.UserId], booking)
+}
+
+// check reports a conflict with an earlier accepted row, naming its lines
+func (b importedBookings) check(apt model.Appointment) error {
+	if lines := overlappingLines(b.trainers[apt.TrainerId], apt, b.buffer); len(lines) > 0 {
+		errMsg := fmt.Sprintf("trainer %d is already booked between %v and %v earlier in the import", apt.TrainerId, apt.StartTime, apt.EndTime)
+		return errors.ConflictError(errMsg).
+			WithCode(errors.CodeTrainerUnavailable).
+			WithDetail("trainer_id", apt.TrainerId).
+			WithDetail("conflicting_lines", lines)
+	}
+	if lines := overlappingLines(b.users[apt.UserId], apt, 0); len(lines) > 0 {
+		errMsg := fmt.Sprintf("user %d is already booked between %v and %v earlier in the import", apt.UserId, apt.StartTime, apt.EndTime)
+		return errors.ConflictError(errMsg).
+			WithCode(errors.CodeUserUnavailable).
+			WithDetail("user_id", apt.UserId).
+			WithDetail("conflicting_lines", lines)
+	}
+	return nil
+}
+
+// overlappingLines returns the lines of the bookings within buffer of apt
+func overlappingLines(bookings []importedBooking, apt model.Appointment, buffer time.Duration) []int {
+	var lines []int
+	for _, b := range bookings {
+		if b.apt.Overlaps(apt.StartTime.Add(-buffer), apt.EndTime.Add(buffer)) {
+			lines = append(lines, b.line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"appointment-service/internal/errors"
+	"appointment-service/internal/model"
+	"appointment-service/internal/repository/memory"
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingBatchRepository fails every CreateBatch after the first failAfter
+type failingBatchRepository struct {
+	*memory.MemoryAppointmentRepository
+	failAfter int
+	batches   int
+}
+
+func (r *failingBatchRepository) CreateBatch(ctx context.Context, appointments []model.Appointment) ([]model.Appointment, error) {
+	r.batches++
+	if r.batches > r.failAfter {
+		return nil, errors.StorageError("creating appointments", io.ErrUnexpectedEOF)
+	}
+	return r.MemoryAppointmentRepository.CreateBatch(ctx, appointments)
+}
+
+// TestImport tests the bulk import of appointments.
+//
+// It includes the following test cases:
+//
+// * Rows conflicting with storage or with earlier rows of the same file are rejected
+// * Parse errors and rule violations are reported per row
+// * Cancelled rows keep their status and never conflict
+// * A dry run and an all-or-nothing import with rejected rows store nothing
+// * A partial import stores every accepted row, in batches
+// * A failing batch is rejected in partial mode, and fails an all-or-nothing import
+// * An all-or-nothing import is committed in one batch, whatever the batch size
+// * Unknown modes and out of range batch sizes are rejected
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	hours := &StaticWorkingHours{Default: model.WorkingHours{Location: time.UTC, Start: 8 * time.Hour, End: 18 * time.Hour}}
+	at := func(hour, minute int) time.Time { return time.Date(2025, 6, 2, hour, minute, 0, 0, time.UTC) }
+	row := func(line int, trainer, user int64, hour, minute int) model.ImportRow {
+		return model.ImportRow{Line: line, Appointment: model.Appointment{
+			TrainerId: trainer,
+			UserId:    user,
+			StartTime: at(hour, minute),
+			EndTime:   at(hour, minute).Add(30 * time.Minute),
+		}}
+	}
+
+	// Storage already holds trainer 1 at 9:00
+	newService := func(t *testing.T) (*memory.MemoryAppointmentRepository, AppointmentServicer) {
+		repo := memory.New(logger)
+		svc := NewAppointmentService(repo, hours, DefaultRules(), logger)
+		_, err := svc.Create(ctx, row(0, 1, 100, 9, 0).Appointment)
+		require.NoError(t, err)
+		return repo, svc
+	}
+
+	cancelled := row(7, 1, 9, 9, 0)
+	cancelled.Appointment.Status = model.StatusCancelled
+	rows := []model.ImportRow{
+		row(2, 1, 2, 10, 0), // accepted
+		row(3, 1, 3, 9, 0),  // trainer booked in storage
+		row(4, 1, 4, 10, 0), // trainer booked on line 2
+		row(5, 2, 2, 10, 0), // user booked on line 2
+		row(6, 2, 5, 10, 0), // accepted
+		cancelled,           // accepted, despite trainer 1 at 9:00
+		row(8, 3, 6, 7, 0),  // outside working hours
+		{Line: 9, Err: errors.ValidationError("row is malformed")},
+	}
+
+	rejection := func(t *testing.T, report *model.ImportReport, line int) *errors.AppError {
+		for _, result := range report.Rows {
+			if result.Line == line {
+				require.False(t, result.Accepted)
+				appErr, ok := errors.IsAppError(result.Err)
+				require.True(t, ok)
+				return appErr
+			}
+		}
+		t.Fatalf("no result for line %d", line)
+		return nil
+	}
+
+	t.Run("checks rows against storage and the file", func(t *testing.T) {
+		_, svc := newService(t)
+		report, err := svc.Import(ctx, rows, model.ImportOptions{DryRun: true})
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, report.Accepted)
+		assert.Equal(t, 5, report.Rejected)
+		assert.Equal(t, errors.CodeTrainerUnavailable, rejection(t, report, 3).ErrorCode)
+
+		inFile := rejection(t, report, 4)
+		assert.Equal(t, errors.CodeTrainerUnavailable, inFile.ErrorCode)
+		assert.Equal(t, []int{2}, inFile.Details["conflicting_lines"])
+		assert.Equal(t, errors.CodeUserUnavailable, rejection(t, report, 5).ErrorCode)
+
+		assert.NotEmpty(t, errors.ViolationsOf(rejection(t, report, 8)))
+		assert.Equal(t, "row is malformed", rejection(t, report, 9).Message)
+	})
+
+	t.Run("dry run and all-or-nothing store nothing", func(t *testing.T) {
+		for _, opts := range []model.ImportOptions{
+			{DryRun: true, Mode: model.ImportPartial},
+			{Mode: model.ImportAllOrNothing},
+		} {
+			repo, svc := newService(t)
+			report, err := svc.Import(ctx, rows, opts)
+			require.NoError(t, err)
+			assert.Zero(t, report.Imported)
+
+			page, err := repo.List(ctx, model.AppointmentQuery{})
+			require.NoError(t, err)
+			assert.Len(t, page.Appointments, 1)
+		}
+	})
+
+	t.Run("partial import stores accepted rows in batches", func(t *testing.T) {
+		repo, svc := newService(t)
+		report, err := svc.Import(ctx, rows, model.ImportOptions{Mode: model.ImportPartial, BatchSize: 2})
+		require.NoError(t, err)
+		assert.Equal(t, 3, report.Imported)
+
+		for _, result := range report.Rows {
+			if !result.Accepted {
+				assert.Zero(t, result.AppointmentId)
+				continue
+			}
+			stored, err := repo.Get(ctx, result.AppointmentId)
+			require.NoError(t, err)
+			if result.Line == 7 {
+				assert.Equal(t, model.StatusCancelled, stored.Status)
+			} else {
+				assert.Equal(t, model.StatusScheduled, stored.Status)
+			}
+		}
+	})
+
+	t.Run("failing batch", func(t *testing.T) {
+		valid := []model.ImportRow{row(2, 1, 2, 10, 0), row(3, 1, 2, 11, 0), row(4, 1, 2, 12, 0)}
+
+		repo := &failingBatchRepository{MemoryAppointmentRepository: memory.New(logger), failAfter: 1}
+		svc := NewAppointmentService(repo, hours, DefaultRules(), logger)
+		report, err := svc.Import(ctx, valid, model.ImportOptions{Mode: model.ImportPartial, BatchSize: 2})
+		require.NoError(t, err)
+		assert.Equal(t, 2, report.Imported)
+		assert.Equal(t, 1, report.Rejected)
+		assert.False(t, report.Rows[2].Accepted)
+
+		repo = &failingBatchRepository{MemoryAppointmentRepository: memory.New(logger), failAfter: 0}
+		svc = NewAppointmentService(repo, hours, DefaultRules(), logger)
+		_, err = svc.Import(ctx, valid, model.ImportOptions{BatchSize: 2})
+		require.Error(t, err)
+		page, err := repo.List(ctx, model.AppointmentQuery{})
+		require.NoError(t, err)
+		assert.Empty(t, page.Appointments)
+	})
+
+	t.Run("all-or-nothing commits one batch", func(t *testing.T) {
+		valid := []model.ImportRow{row(2, 1, 2, 10, 0), row(3, 1, 2, 11, 0), row(4, 1, 2, 12, 0)}
+
+		repo := &failingBatchRepository{MemoryAppointmentRepository: memory.New(logger), failAfter: 1}
+		svc := NewAppointmentService(repo, hours, DefaultRules(), logger)
+		report, err := svc.Import(ctx, valid, model.ImportOptions{BatchSize: 2})
+		require.NoError(t, err)
+		assert.Equal(t, 3, report.Imported)
+		assert.Equal(t, 1, repo.batches)
+	})
+
+	t.Run("bad options", func(t *testing.T) {
+		_, svc := newService(t)
+		for _, opts := range []model.ImportOptions{
+			{Mode: "some"},
+			{BatchSize: -1},
+			{BatchSize: model.MaxImportBatchSize + 1},
+		} {
+			_, err := svc.Import(ctx, rows, opts)
+			appErr, ok := errors.IsAppError(err)
+			require.True(t, ok)
+			assert.Equal(t, errors.CodeFieldInvalid, appErr.ErrorCode)
+		}
+	})
+}
@@ -7,7 +7,22 @@ import (
 )
 
 type AppointmentServicer interface {
-	List(ctx context.Context, trainerID int64) ([]model.Appointment, error)
+	List(ctx context.Context, query model.AppointmentQuery) (*model.AppointmentPage, error)
+	ListClientAppointments(ctx context.Context, userID int64, query model.AppointmentQuery) (*model.AppointmentPage, error)
+	Export(ctx context.Context, query model.AppointmentQuery, fn func(model.Appointment) error) error
+	Get(ctx context.Context, id int64) (*model.Appointment, error)
+	Validate(ctx context.Context, appointment model.Appointment) error
 	Create(ctx context.Context, appointment model.Appointment) (*model.Appointment, error)
+	Import(ctx context.Context, rows []model.ImportRow, opts model.ImportOptions) (*model.ImportReport, error)
+	Reschedule(ctx context.Context, id int64, startTime, endTime time.Time, version int64) (*model.Appointment, error)
+	Cancel(ctx context.Context, id int64, version int64) (*model.Appointment, error)
+	UpdateStatus(ctx context.Context, id int64, status model.AppointmentStatus, version int64) (*model.Appointment, error)
 	GetAvailability(ctx context.Context, trainerID int64, windowStartsAt time.Time, windowEndsAt time.Time) ([]model.TimeSlot, error)
+	GetCalendar(ctx context.Context, trainerID int64, from, to time.Time, loc *time.Location) (*model.Calendar, error)
+	Utilization(ctx context.Context, query model.UtilizationQuery) (*model.UtilizationReport, error)
+}
+
+// WorkingHoursProvider looks up when, and in which time zone, a trainer works
+type WorkingHoursProvider interface {
+	WorkingHours(ctx context.Context, trainerID int64) (model.WorkingHours, error)
 }
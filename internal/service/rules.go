@@ -0,0 +1,38 @@
+package service
+
+import (
+	"appointment-service/internal/availability"
+	"appointment-service/internal/model"
+	"time"
+)
+
+// Rules are the booking rules besides working hours
+type Rules struct {
+	// Duration is the length of every appointment, and so of every
+	// availability slot
+	Duration time.Duration
+
+	// Buffer is the minimum gap kept between two appointments of a trainer.
+	// A booking blocks the trainer for Buffer before and after it.
+	Buffer time.Duration
+
+	// MaxWindow caps how wide a window availability may be asked for, so a
+	// single request cannot walk years of slots. Zero is no cap.
+	MaxWindow time.Duration
+}
+
+// DefaultRules returns the service's standard rules, 30 minute appointments
+// that may follow each other directly
+func DefaultRules() Rules {
+	return Rules{Duration: 30 * time.Minute}
+}
+
+// blocked returns the time the bookings take from their trainer, buffers
+// included
+func (r Rules) blocked(bookings []model.Appointment) []availability.Interval {
+	intervals := availability.FromAppointments(bookings)
+	for i := range intervals {
+		intervals[i] = intervals[i].Grow(r.Buffer)
+	}
+	return intervals
+}
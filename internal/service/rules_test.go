@@ -0,0 +1,102 @@
+package service
+
+import (
+	"appointment-service/internal/errors"
+	"appointment-service/internal/model"
+	"appointment-service/internal/repository/memory"
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRules tests configured appointment durations, buffers and window caps.
+//
+// It includes the following test cases:
+//
+// * Appointments must last the configured duration
+// * Availability is sliced into slots of that duration
+// * The buffer takes slots out of availability and the calendar
+// * The buffer keeps other bookings of the trainer away, on both sides
+// * The buffer does not apply between a user's appointments with different trainers
+// * Availability windows wider than the cap are rejected
+func TestRules(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// 8am to noon UTC, hour long appointments with 15 minutes in between
+	hours := &StaticWorkingHours{Default: model.WorkingHours{Location: time.UTC, Start: 8 * time.Hour, End: 12 * time.Hour}}
+	svc := NewAppointmentService(memory.New(logger), hours, Rules{Duration: time.Hour, Buffer: 15 * time.Minute, MaxWindow: 24 * time.Hour}, logger)
+
+	at := func(clock string) time.Time {
+		parsed, err := time.Parse("2006-01-02 15:04", "2030-01-07 "+clock)
+		require.NoError(t, err)
+		return parsed
+	}
+	book := func(trainerId, userId int64, clock string) error {
+		_, err := svc.Create(ctx, model.Appointment{
+			TrainerId: trainerId,
+			UserId:    userId,
+			StartTime: at(clock),
+			EndTime:   at(clock).Add(time.Hour),
+		})
+		return err
+	}
+	code := func(err error) errors.ErrorCode {
+		appErr, ok := errors.IsAppError(err)
+		require.True(t, ok, "not an AppError: %v", err)
+		return appErr.ErrorCode
+	}
+	openSlots := func() []time.Time {
+		slots, err := svc.GetAvailability(ctx, 1, at("08:00"), at("12:00"))
+		require.NoError(t, err)
+		var starts []time.Time
+		for _, slot := range slots {
+			assert.Equal(t, time.Hour, slot.EndTime.Sub(slot.StartTime))
+			starts = append(starts, slot.StartTime)
+		}
+		return starts
+	}
+
+	t.Run("duration", func(t *testing.T) {
+		_, err := svc.Create(ctx, model.Appointment{TrainerId: 1, UserId: 2, StartTime: at("08:00"), EndTime: at("08:30")})
+		assert.Equal(t, errors.CodeInvalidDuration, code(err))
+		assert.ErrorContains(t, err, "appointment must be exactly 60 minutes")
+
+		assert.Equal(t, []time.Time{at("08:00"), at("09:00"), at("10:00"), at("11:00")}, openSlots())
+	})
+
+	// Trainer 1 is booked 9am to 10am, and so blocked 8:45 to 10:15
+	require.NoError(t, book(1, 2, "09:00"))
+
+	t.Run("availability", func(t *testing.T) {
+		assert.Equal(t, []time.Time{at("11:00")}, openSlots())
+
+		calendar, err := svc.GetCalendar(ctx, 1, at("00:00"), at("00:00"), nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, calendar.Days[0].OpenSlots)
+	})
+
+	t.Run("buffer", func(t *testing.T) {
+		assert.Equal(t, errors.CodeTrainerUnavailable, code(book(1, 3, "08:00")), "ends right before")
+		assert.Equal(t, errors.CodeTrainerUnavailable, code(book(1, 3, "10:00")), "starts right after")
+		assert.NoError(t, book(1, 3, "10:15"))
+	})
+
+	t.Run("other trainers", func(t *testing.T) {
+		assert.NoError(t, book(4, 2, "10:00"))
+	})
+
+	t.Run("window cap", func(t *testing.T) {
+		_, err := svc.GetAvailability(ctx, 1, at("08:00"), at("08:00").Add(24*time.Hour))
+		assert.NoError(t, err)
+
+		_, err = svc.GetAvailability(ctx, 1, at("08:00"), at("08:00").Add(25*time.Hour))
+		assert.Equal(t, errors.CodeInvalidTimeRange, code(err))
+		assert.ErrorContains(t, err, "availability window covers 25h0m0s, at most 24h0m0s is allowed")
+	})
+}
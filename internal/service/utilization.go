@@ -0,0 +1,198 @@
+package service
+
+import (
+	"appointment-service/internal/availability"
+	"appointment-service/internal/errors"
+	"appointment-service/internal/model"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// maxPeakHours is how many of the busiest hours each period reports
+const maxPeakHours = 3
+
+// Utilization reports how booked trainers are, per period of the local dates
+// From through To in each trainer's own zone. Booked time is aggregated by
+// the repository, so no appointment is loaded; available time is the
+// trainer's working time, sliced into slots as for availability.
+//
+// With no TrainerId the report covers every trainer with appointments in the
+// range, since only they are known to the service.
+func (s *AppointmentService) Utilization(ctx context.Context, query model.UtilizationQuery) (*model.UtilizationReport, error) {
+	switch {
+	case query.Period == "":
+		query.Period = model.PeriodWeek
+	case !query.Period.IsValid():
+		return nil, errors.ValidationError(fmt.Sprintf("period must be %q, %q or %q", model.PeriodDay, model.PeriodWeek, model.PeriodMonth)).
+			WithCode(errors.CodeFieldInvalid).
+			WithDetail("field", "period")
+	}
+
+	numDays := daysBetween(query.From, query.To) + 1
+	if numDays < 1 {
+		return nil, errors.ValidationError("to must not be before from").
+			WithCode(errors.CodeInvalidTimeRange).
+			WithDetail("field", "to")
+	}
+	if numDays > model.MaxReportDays {
+		return nil, errors.ValidationError(fmt.Sprintf(
+			"report covers %d days, at most %d are allowed", numDays, model.MaxReportDays)).
+			WithCode(errors.CodeInvalidTimeRange).
+			WithDetail("field", "to").
+			WithDetail("max_days", model.MaxReportDays)
+	}
+
+	// Aggregate the bookings. Each trainer's dates are local to them, so the
+	// range is widened by a day on each side to cover every zone.
+	year, month, day := query.From.Date()
+	stats, err := s.repo.BookingStats(ctx, model.BookingStatsQuery{
+		TrainerId: query.TrainerId,
+		StartsAt:  time.Date(year, month, day-1, 0, 0, 0, 0, time.UTC),
+		EndsAt:    time.Date(year, month, day+numDays+1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	trainerIds := []int64{query.TrainerId}
+	if query.TrainerId == 0 {
+		trainerIds = nil
+		for _, st := range stats {
+			if len(trainerIds) == 0 || trainerIds[len(trainerIds)-1] != st.TrainerId {
+				trainerIds = append(trainerIds, st.TrainerId)
+			}
+		}
+	}
+
+	report := &model.UtilizationReport{Period: query.Period}
+	policy := s.policy.Load()
+	for _, trainerId := range trainerIds {
+		// Stats are ordered by trainer, so each trainer's are a run of them
+		first := sort.Search(len(stats), func(i int) bool { return stats[i].TrainerId >= trainerId })
+		last := sort.Search(len(stats), func(i int) bool { return stats[i].TrainerId > trainerId })
+
+		hours, err := policy.hours.WorkingHours(ctx, trainerId)
+		if err != nil {
+			return nil, err
+		}
+		report.Trainers = append(report.Trainers, trainerUtilization(trainerId, hours, policy.rules.Duration, query, numDays, stats[first:last]))
+	}
+
+	s.log(ctx).InfoContext(ctx, "Utilization report",
+		"trainer_id", query.TrainerId,
+		"period", query.Period,
+		"days", numDays,
+		"trainers", len(report.Trainers),
+		"buckets", len(stats))
+
+	return report, nil
+}
+
+// trainerUtilization breaks one trainer's working time and booking stats
+// down into the report's periods
+func trainerUtilization(trainerId int64, hours model.WorkingHours, slotDuration time.Duration, query model.UtilizationQuery, numDays int, stats []model.BookingStats) model.TrainerUtilization {
+	loc := hours.Location
+	year, month, day := query.From.Date()
+	periods := reportPeriods(
+		time.Date(year, month, day, 0, 0, 0, 0, loc),
+		time.Date(year, month, day+numDays, 0, 0, 0, 0, loc),
+		query.Period)
+
+	// periodOf finds the period holding t, or -1 when t is outside the report
+	periodOf := func(t time.Time) int {
+		i := sort.Search(len(periods), func(i int) bool { return periods[i].End.After(t) })
+		if i == len(periods) || t.Before(periods[i].Start) {
+			return -1
+		}
+		return i
+	}
+
+	window := availability.Interval{Start: periods[0].Start, End: periods[len(periods)-1].End}
+	availability.WalkWorkingSlots(hours, window, slotDuration, func(slot availability.Interval) {
+		if i := periodOf(slot.Start); i >= 0 {
+			periods[i].AvailableMinutes += int(slot.End.Sub(slot.Start) / time.Minute)
+		}
+	})
+
+	peaks := make([]map[int]int, len(periods))
+	for _, st := range stats {
+		local := st.Start.In(loc)
+		i := periodOf(local)
+		if i < 0 {
+			continue
+		}
+
+		p := &periods[i]
+		switch st.Status {
+		case model.StatusCancelled:
+			p.Cancellations += st.Appointments
+			continue
+		case model.StatusNoShow:
+			p.NoShows += st.Appointments
+		}
+		p.Appointments += st.Appointments
+		p.BookedMinutes += st.BookedMinutes
+
+		if peaks[i] == nil {
+			peaks[i] = make(map[int]int)
+		}
+		peaks[i][local.Hour()] += st.Appointments
+	}
+
+	for i := range periods {
+		p := &periods[i]
+		if p.AvailableMinutes > 0 {
+			p.Utilization = math.Round(float64(p.BookedMinutes)/float64(p.AvailableMinutes)*1000) / 10
+		}
+		p.PeakHours = peakHours(peaks[i])
+	}
+
+	return model.TrainerUtilization{TrainerId: trainerId, Location: loc, Periods: periods}
+}
+
+// reportPeriods cuts [start, end) at local day, Monday or first of month
+// boundaries
+func reportPeriods(start, end time.Time, period model.ReportPeriod) []model.PeriodUtilization {
+	var periods []model.PeriodUtilization
+	for t := start; t.Before(end); {
+		year, month, day := t.Date()
+		var next time.Time
+		switch period {
+		case model.PeriodDay:
+			next = time.Date(year, month, day+1, 0, 0, 0, 0, t.Location())
+		case model.PeriodWeek:
+			sinceMonday := (int(t.Weekday()) + 6) % 7
+			next = time.Date(year, month, day-sinceMonday+7, 0, 0, 0, 0, t.Location())
+		case model.PeriodMonth:
+			next = time.Date(year, month+1, 1, 0, 0, 0, 0, t.Location())
+		}
+		if next.After(end) {
+			next = end
+		}
+
+		periods = append(periods, model.PeriodUtilization{Start: t, End: next})
+		t = next
+	}
+	return periods
+}
+
+// peakHours returns the busiest hours, most appointments first, then earliest
+func peakHours(counts map[int]int) []model.HourCount {
+	hours := make([]model.HourCount, 0, len(counts))
+	for hour, n := range counts {
+		hours = append(hours, model.HourCount{Hour: hour, Appointments: n})
+	}
+	sort.Slice(hours, func(i, j int) bool {
+		if hours[i].Appointments != hours[j].Appointments {
+			return hours[i].Appointments > hours[j].Appointments
+		}
+		return hours[i].Hour < hours[j].Hour
+	})
+	if len(hours) > maxPeakHours {
+		hours = hours[:maxPeakHours]
+	}
+	return hours
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"appointment-service/internal/errors"
+	"appointment-service/internal/model"
+	"appointment-service/internal/repository/memory"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUtilization tests the trainer utilization report.
+//
+// It includes the following test cases:
+//
+// * Daily available and booked minutes, cancellations, no-shows and peak hours
+// * Bookings counted on the trainer's local date, not the UTC one
+// * Weeks and months cut to the report's dates
+// * Every trainer with appointments when no trainer is given
+// * Unknown periods and reversed or too long ranges
+func TestUtilization(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	la, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+
+	// Trainers work 8am to 10am Los Angeles time, Monday to Friday
+	hours := &StaticWorkingHours{Default: model.WorkingHours{
+		Location: la,
+		Start:    8 * time.Hour,
+		End:      10 * time.Hour,
+		Weekdays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	}}
+	repo := memory.New(logger)
+	svc := NewAppointmentService(repo, hours, DefaultRules(), logger)
+
+	book := func(trainerId int64, local string, status model.AppointmentStatus) {
+		start, err := time.ParseInLocation("2006-01-02 15:04", local, la)
+		require.NoError(t, err)
+		_, err = repo.Create(ctx, model.Appointment{
+			TrainerId: trainerId,
+			UserId:    2,
+			StartTime: start.UTC(),
+			EndTime:   start.Add(30 * time.Minute).UTC(),
+			Status:    status,
+		})
+		require.NoError(t, err)
+	}
+	// Monday 2025-06-02
+	book(1, "2025-06-02 08:00", model.StatusCompleted)
+	book(1, "2025-06-02 08:30", model.StatusScheduled)
+	book(1, "2025-06-02 09:00", model.StatusCancelled)
+	book(1, "2025-06-02 09:00", model.StatusScheduled)
+	// Tuesday 2025-06-03, the evening booking is Wednesday in UTC
+	book(1, "2025-06-03 09:30", model.StatusNoShow)
+	book(1, "2025-06-03 23:30", model.StatusScheduled)
+	book(2, "2025-06-03 08:00", model.StatusScheduled)
+
+	date := func(s string) time.Time {
+		d, err := time.Parse(time.DateOnly, s)
+		require.NoError(t, err)
+		return d
+	}
+	report := func(t *testing.T, query model.UtilizationQuery) *model.UtilizationReport {
+		r, err := svc.Utilization(ctx, query)
+		require.NoError(t, err)
+		return r
+	}
+
+	t.Run("days", func(t *testing.T) {
+		r := report(t, model.UtilizationQuery{TrainerId: 1, Period: model.PeriodDay, From: date("2025-06-02"), To: date("2025-06-04")})
+		require.Len(t, r.Trainers, 1)
+		assert.Equal(t, la, r.Trainers[0].Location)
+		periods := r.Trainers[0].Periods
+		require.Len(t, periods, 3)
+
+		monday := periods[0]
+		assert.Equal(t, time.Date(2025, 6, 2, 0, 0, 0, 0, la), monday.Start)
+		assert.Equal(t, 120, monday.AvailableMinutes)
+		assert.Equal(t, 90, monday.BookedMinutes)
+		assert.Equal(t, 75.0, monday.Utilization)
+		assert.Equal(t, 3, monday.Appointments)
+		assert.Equal(t, 1, monday.Cancellations)
+		assert.Equal(t, []model.HourCount{{Hour: 8, Appointments: 2}, {Hour: 9, Appointments: 1}}, monday.PeakHours)
+
+		tuesday := periods[1]
+		assert.Equal(t, 60, tuesday.BookedMinutes)
+		assert.Equal(t, 50.0, tuesday.Utilization)
+		assert.Equal(t, 1, tuesday.NoShows)
+		assert.Equal(t, []model.HourCount{{Hour: 9, Appointments: 1}, {Hour: 23, Appointments: 1}}, tuesday.PeakHours)
+
+		assert.Zero(t, periods[2].BookedMinutes)
+		assert.Empty(t, periods[2].PeakHours)
+	})
+
+	t.Run("weeks and months", func(t *testing.T) {
+		r := report(t, model.UtilizationQuery{TrainerId: 1, Period: model.PeriodWeek, From: date("2025-06-01"), To: date("2025-06-10")})
+		periods := r.Trainers[0].Periods
+		require.Len(t, periods, 3)
+		assert.Equal(t, []int{0, 600, 240}, []int{periods[0].AvailableMinutes, periods[1].AvailableMinutes, periods[2].AvailableMinutes})
+		assert.Equal(t, time.Date(2025, 6, 2, 0, 0, 0, 0, la), periods[1].Start)
+		assert.Equal(t, time.Date(2025, 6, 9, 0, 0, 0, 0, la), periods[1].End)
+		assert.Equal(t, 5, periods[1].Appointments)
+
+		r = report(t, model.UtilizationQuery{TrainerId: 1, Period: model.PeriodMonth, From: date("2025-05-30"), To: date("2025-06-02")})
+		periods = r.Trainers[0].Periods
+		require.Len(t, periods, 2)
+		assert.Equal(t, time.Date(2025, 6, 1, 0, 0, 0, 0, la), periods[1].Start)
+		assert.Equal(t, 3, periods[1].Appointments)
+	})
+
+	t.Run("every trainer", func(t *testing.T) {
+		r := report(t, model.UtilizationQuery{From: date("2025-06-02"), To: date("2025-06-06")})
+		assert.Equal(t, model.PeriodWeek, r.Period)
+		require.Len(t, r.Trainers, 2)
+		assert.Equal(t, int64(2), r.Trainers[1].TrainerId)
+		assert.Equal(t, 30, r.Trainers[1].Periods[0].BookedMinutes)
+
+		r = report(t, model.UtilizationQuery{From: date("2025-07-01"), To: date("2025-07-31")})
+		assert.Empty(t, r.Trainers)
+	})
+
+	t.Run("bad queries", func(t *testing.T) {
+		for _, query := range []model.UtilizationQuery{
+			{Period: "year", From: date("2025-06-01"), To: date("2025-06-30")},
+			{From: date("2025-06-30"), To: date("2025-06-01")},
+			{From: date("2025-01-01"), To: date("2026-01-02")},
+		} {
+			_, err := svc.Utilization(ctx, query)
+			appErr, ok := errors.IsAppError(err)
+			require.True(t, ok)
+			assert.Equal(t, http.StatusBadRequest, appErr.Code)
+		}
+	})
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"appointment-service/internal/model"
+	"context"
+	"time"
+)
+
+// StaticWorkingHours serves the same working hours to every trainer, except
+// that trainers listed in Timezones work those hours in their own time zone.
+// A Default without a Location is taken to be in UTC.
+type StaticWorkingHours struct {
+	Default   model.WorkingHours
+	Timezones map[int64]*time.Location
+}
+
+// WorkingHours returns the working hours of the given trainer
+func (s *StaticWorkingHours) WorkingHours(ctx context.Context, trainerID int64) (model.WorkingHours, error) {
+	hours := s.Default
+	if hours.Location == nil {
+		hours.Location = time.UTC
+	}
+	if loc, ok := s.Timezones[trainerID]; ok {
+		hours.Location = loc
+	}
+	return hours, nil
+}
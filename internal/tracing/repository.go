@@ -0,0 +1,136 @@
+package tracing
+
+import (
+	"appointment-service/internal/model"
+	"appointment-service/internal/repository"
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentRepository wraps repo so every call is a client span, labelled
+// with the backend name and the number of rows it returned
+func InstrumentRepository(repo repository.AppointmentRepository, backend string, tp trace.TracerProvider) repository.AppointmentRepository {
+	return &tracedRepository{repo: repo, backend: backend, tracer: tp.Tracer(ScopeName)}
+}
+
+type tracedRepository struct {
+	repo    repository.AppointmentRepository
+	backend string
+	tracer  trace.Tracer
+}
+
+func (r *tracedRepository) start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return r.tracer.Start(ctx, "AppointmentRepository."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrDBSystem.String(r.backend)),
+		trace.WithAttributes(attrs...))
+}
+
+func (r *tracedRepository) List(ctx context.Context, query model.AppointmentQuery) (page *model.AppointmentPage, err error) {
+	ctx, span := r.start(ctx, "List", filterAttrs(query.TrainerId, query.UserId)...)
+	defer finish(span, &err)
+
+	page, err = r.repo.List(ctx, query)
+	if err == nil {
+		span.SetAttributes(attrRows.Int(len(page.Appointments)))
+	}
+	return page, err
+}
+
+func (r *tracedRepository) ListClientAppointments(ctx context.Context, userID int64, query model.AppointmentQuery) (page *model.AppointmentPage, err error) {
+	ctx, span := r.start(ctx, "ListClientAppointments", filterAttrs(query.TrainerId, userID)...)
+	defer finish(span, &err)
+
+	page, err = r.repo.ListClientAppointments(ctx, userID, query)
+	if err == nil {
+		span.SetAttributes(attrRows.Int(len(page.Appointments)))
+	}
+	return page, err
+}
+
+func (r *tracedRepository) Stream(ctx context.Context, query model.AppointmentQuery, fn func(model.Appointment) error) (err error) {
+	ctx, span := r.start(ctx, "Stream", filterAttrs(query.TrainerId, query.UserId)...)
+	defer finish(span, &err)
+
+	rows := 0
+	defer func() { span.SetAttributes(attrRows.Int(rows)) }()
+	return r.repo.Stream(ctx, query, func(apt model.Appointment) error {
+		rows++
+		return fn(apt)
+	})
+}
+
+func (r *tracedRepository) Get(ctx context.Context, id int64) (apt *model.Appointment, err error) {
+	ctx, span := r.start(ctx, "Get", attrAppointmentId.Int64(id))
+	defer finish(span, &err)
+	return r.repo.Get(ctx, id)
+}
+
+func (r *tracedRepository) Create(ctx context.Context, appointment model.Appointment) (apt *model.Appointment, err error) {
+	ctx, span := r.start(ctx, "Create", attrTrainerId.Int64(appointment.TrainerId), attrUserId.Int64(appointment.UserId))
+	defer finish(span, &err)
+
+	apt, err = r.repo.Create(ctx, appointment)
+	if err == nil {
+		span.SetAttributes(attrAppointmentId.Int64(apt.Id))
+	}
+	return apt, err
+}
+
+func (r *tracedRepository) CreateBatch(ctx context.Context, appointments []model.Appointment) (created []model.Appointment, err error) {
+	ctx, span := r.start(ctx, "CreateBatch", attrRows.Int(len(appointments)))
+	defer finish(span, &err)
+	return r.repo.CreateBatch(ctx, appointments)
+}
+
+func (r *tracedRepository) Update(ctx context.Context, appointment model.Appointment) (apt *model.Appointment, err error) {
+	ctx, span := r.start(ctx, "Update", attrAppointmentId.Int64(appointment.Id), attrTrainerId.Int64(appointment.TrainerId))
+	defer finish(span, &err)
+	return r.repo.Update(ctx, appointment)
+}
+
+func (r *tracedRepository) Delete(ctx context.Context, id int64) (err error) {
+	ctx, span := r.start(ctx, "Delete", attrAppointmentId.Int64(id))
+	defer finish(span, &err)
+	return r.repo.Delete(ctx, id)
+}
+
+func (r *tracedRepository) BookingStats(ctx context.Context, query model.BookingStatsQuery) (stats []model.BookingStats, err error) {
+	ctx, span := r.start(ctx, "BookingStats", filterAttrs(query.TrainerId, 0)...)
+	defer finish(span, &err)
+
+	stats, err = r.repo.BookingStats(ctx, query)
+	span.SetAttributes(attrRows.Int(len(stats)))
+	return stats, err
+}
+
+func (r *tracedRepository) GetTrainerBookings(ctx context.Context, trainerID int64, startsAt, endsAt time.Time) (bookings []model.Appointment, err error) {
+	ctx, span := r.start(ctx, "GetTrainerBookings", attrTrainerId.Int64(trainerID))
+	defer finish(span, &err)
+
+	bookings, err = r.repo.GetTrainerBookings(ctx, trainerID, startsAt, endsAt)
+	span.SetAttributes(attrRows.Int(len(bookings)))
+	return bookings, err
+}
+
+func (r *tracedRepository) GetClientBookings(ctx context.Context, clientID int64, startsAt, endsAt time.Time) (bookings []model.Appointment, err error) {
+	ctx, span := r.start(ctx, "GetClientBookings", attrUserId.Int64(clientID))
+	defer finish(span, &err)
+
+	bookings, err = r.repo.GetClientBookings(ctx, clientID, startsAt, endsAt)
+	span.SetAttributes(attrRows.Int(len(bookings)))
+	return bookings, err
+}
+
+func (r *tracedRepository) Ping(ctx context.Context) (err error) {
+	ctx, span := r.start(ctx, "Ping")
+	defer finish(span, &err)
+	return r.repo.Ping(ctx)
+}
+
+func (r *tracedRepository) Close() error {
+	return r.repo.Close()
+}
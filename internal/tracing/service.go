@@ -0,0 +1,127 @@
+package tracing
+
+import (
+	"appointment-service/internal/model"
+	"appointment-service/internal/service"
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentService wraps svc so every call is a span, parenting the spans of
+// the repository calls it makes
+func InstrumentService(svc service.AppointmentServicer, tp trace.TracerProvider) service.AppointmentServicer {
+	return &tracedService{svc: svc, tracer: tp.Tracer(ScopeName)}
+}
+
+type tracedService struct {
+	svc    service.AppointmentServicer
+	tracer trace.Tracer
+}
+
+func (s *tracedService) start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return s.tracer.Start(ctx, "AppointmentService."+method, trace.WithAttributes(attrs...))
+}
+
+func (s *tracedService) List(ctx context.Context, query model.AppointmentQuery) (page *model.AppointmentPage, err error) {
+	ctx, span := s.start(ctx, "List", filterAttrs(query.TrainerId, query.UserId)...)
+	defer finish(span, &err)
+
+	page, err = s.svc.List(ctx, query)
+	if err == nil {
+		span.SetAttributes(attrRows.Int(len(page.Appointments)))
+	}
+	return page, err
+}
+
+func (s *tracedService) ListClientAppointments(ctx context.Context, userID int64, query model.AppointmentQuery) (page *model.AppointmentPage, err error) {
+	ctx, span := s.start(ctx, "ListClientAppointments", filterAttrs(query.TrainerId, userID)...)
+	defer finish(span, &err)
+
+	page, err = s.svc.ListClientAppointments(ctx, userID, query)
+	if err == nil {
+		span.SetAttributes(attrRows.Int(len(page.Appointments)))
+	}
+	return page, err
+}
+
+func (s *tracedService) Export(ctx context.Context, query model.AppointmentQuery, fn func(model.Appointment) error) (err error) {
+	ctx, span := s.start(ctx, "Export", filterAttrs(query.TrainerId, query.UserId)...)
+	defer finish(span, &err)
+	return s.svc.Export(ctx, query, fn)
+}
+
+func (s *tracedService) Get(ctx context.Context, id int64) (apt *model.Appointment, err error) {
+	ctx, span := s.start(ctx, "Get", attrAppointmentId.Int64(id))
+	defer finish(span, &err)
+	return s.svc.Get(ctx, id)
+}
+
+func (s *tracedService) Validate(ctx context.Context, appointment model.Appointment) (err error) {
+	ctx, span := s.start(ctx, "Validate", attrTrainerId.Int64(appointment.TrainerId), attrUserId.Int64(appointment.UserId))
+	defer finish(span, &err)
+	return s.svc.Validate(ctx, appointment)
+}
+
+func (s *tracedService) Create(ctx context.Context, appointment model.Appointment) (apt *model.Appointment, err error) {
+	ctx, span := s.start(ctx, "Create", attrTrainerId.Int64(appointment.TrainerId), attrUserId.Int64(appointment.UserId))
+	defer finish(span, &err)
+
+	apt, err = s.svc.Create(ctx, appointment)
+	if err == nil {
+		span.SetAttributes(attrAppointmentId.Int64(apt.Id))
+	}
+	return apt, err
+}
+
+func (s *tracedService) Import(ctx context.Context, rows []model.ImportRow, opts model.ImportOptions) (report *model.ImportReport, err error) {
+	ctx, span := s.start(ctx, "Import", attrImportRows.Int(len(rows)))
+	defer finish(span, &err)
+
+	report, err = s.svc.Import(ctx, rows, opts)
+	if err == nil {
+		span.SetAttributes(attrImported.Int(report.Imported), attrRejected.Int(report.Rejected))
+	}
+	return report, err
+}
+
+func (s *tracedService) Reschedule(ctx context.Context, id int64, startTime, endTime time.Time, version int64) (apt *model.Appointment, err error) {
+	ctx, span := s.start(ctx, "Reschedule", attrAppointmentId.Int64(id))
+	defer finish(span, &err)
+	return s.svc.Reschedule(ctx, id, startTime, endTime, version)
+}
+
+func (s *tracedService) Cancel(ctx context.Context, id int64, version int64) (apt *model.Appointment, err error) {
+	ctx, span := s.start(ctx, "Cancel", attrAppointmentId.Int64(id))
+	defer finish(span, &err)
+	return s.svc.Cancel(ctx, id, version)
+}
+
+func (s *tracedService) UpdateStatus(ctx context.Context, id int64, status model.AppointmentStatus, version int64) (apt *model.Appointment, err error) {
+	ctx, span := s.start(ctx, "UpdateStatus", attrAppointmentId.Int64(id), attrStatus.String(string(status)))
+	defer finish(span, &err)
+	return s.svc.UpdateStatus(ctx, id, status, version)
+}
+
+func (s *tracedService) GetAvailability(ctx context.Context, trainerID int64, windowStartsAt time.Time, windowEndsAt time.Time) (slots []model.TimeSlot, err error) {
+	ctx, span := s.start(ctx, "GetAvailability", attrTrainerId.Int64(trainerID))
+	defer finish(span, &err)
+
+	slots, err = s.svc.GetAvailability(ctx, trainerID, windowStartsAt, windowEndsAt)
+	span.SetAttributes(attrSlots.Int(len(slots)))
+	return slots, err
+}
+
+func (s *tracedService) GetCalendar(ctx context.Context, trainerID int64, from, to time.Time, loc *time.Location) (calendar *model.Calendar, err error) {
+	ctx, span := s.start(ctx, "GetCalendar", attrTrainerId.Int64(trainerID))
+	defer finish(span, &err)
+	return s.svc.GetCalendar(ctx, trainerID, from, to, loc)
+}
+
+func (s *tracedService) Utilization(ctx context.Context, query model.UtilizationQuery) (report *model.UtilizationReport, err error) {
+	ctx, span := s.start(ctx, "Utilization", filterAttrs(query.TrainerId, 0)...)
+	defer finish(span, &err)
+	return s.svc.Utilization(ctx, query)
+}
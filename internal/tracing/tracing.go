@@ -0,0 +1,130 @@
+// Package tracing sets up OpenTelemetry tracing: the tracer provider and
+// exporter, W3C trace context propagation, and spans for every repository
+// and service call.
+//
+// As with metrics, the repository and service are traced by decorators,
+// leaving their implementations free of tracing code. HTTP spans come from
+// the otelgin middleware, so one trace covers a request from its route down
+// to each repository call.
+package tracing
+
+import (
+	"appointment-service/internal/errors"
+	"appointment-service/internal/version"
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of the service's own spans
+const ScopeName = "appointment-service"
+
+// Exporters, as configured by OTEL_TRACES_EXPORTER
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// Span attributes set by the decorators
+const (
+	attrDBSystem      = attribute.Key("db.system")
+	attrAppointmentId = attribute.Key("appointment.id")
+	attrTrainerId     = attribute.Key("trainer.id")
+	attrUserId        = attribute.Key("user.id")
+	attrStatus        = attribute.Key("appointment.status")
+	attrRows          = attribute.Key("db.rows")
+	attrSlots         = attribute.Key("availability.slots")
+	attrImportRows    = attribute.Key("import.rows")
+	attrImported      = attribute.Key("import.imported")
+	attrRejected      = attribute.Key("import.rejected")
+	attrErrorCode     = attribute.Key("error.code")
+)
+
+// Setup installs the global tracer provider exporting to exporter, and the
+// W3C trace context and baggage propagators. OTLP export is configured by the
+// standard OTEL_EXPORTER_OTLP_* variables.
+//
+// With ExporterNone no spans are recorded, but incoming trace context is
+// still propagated, so log records carry the caller's trace ID. The returned
+// function flushes pending spans and must be called on shutdown.
+func Setup(ctx context.Context, exporter string, serviceName string, info version.Info) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var spanExporter sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		spanExporter, err = otlptracehttp.New(ctx)
+	case ExporterStdout:
+		spanExporter, err = stdouttrace.New()
+	default:
+		return nil, fmt.Errorf("unknown traces exporter %q, want %q, %q or %q", exporter, ExporterOTLP, ExporterStdout, ExporterNone)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", serviceName),
+		attribute.String("service.version", info.Version),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// filterAttrs describes a query's trainer and user filters, leaving out the
+// ones not set
+func filterAttrs(trainerId, userId int64) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if trainerId != 0 {
+		attrs = append(attrs, attrTrainerId.Int64(trainerId))
+	}
+	if userId != 0 {
+		attrs = append(attrs, attrUserId.Int64(userId))
+	}
+	return attrs
+}
+
+// finish ends span with the error *err holds by then. Every error is
+// recorded with its code, but only server side failures mark the span as
+// failed; a conflict or missing appointment is a normal outcome. Use as
+// defer finish(span, &err).
+func finish(span trace.Span, err *error) {
+	defer span.End()
+	if *err == nil {
+		return
+	}
+
+	span.RecordError(*err)
+	if appErr, ok := errors.IsAppError(*err); ok {
+		span.SetAttributes(attrErrorCode.String(string(appErr.ErrorCode)))
+		if appErr.Code < http.StatusInternalServerError {
+			return
+		}
+	}
+	span.SetStatus(codes.Error, (*err).Error())
+}
@@ -0,0 +1,145 @@
+package tracing
+
+import (
+	"appointment-service/internal/logger"
+	"appointment-service/internal/model"
+	"appointment-service/internal/repository/memory"
+	"appointment-service/internal/service"
+	"appointment-service/internal/version"
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TestInstrumentation tests the repository and service decorators.
+//
+// It includes the following test cases:
+//
+// * Service spans parent the spans of their repository calls
+// * Trainer, user and appointment IDs, backend and row counts as attributes
+// * Slot count of availability and row counts of imports
+// * Conflicts recorded with their code without failing the span
+func TestInstrumentation(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	hours := &service.StaticWorkingHours{Default: model.WorkingHours{Location: time.UTC, Start: 8 * time.Hour, End: 18 * time.Hour}}
+
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	repo := InstrumentRepository(memory.New(log), "memory", tp)
+	svc := InstrumentService(service.NewAppointmentService(repo, hours, service.DefaultRules(), log), tp)
+
+	at := func(hour, minute int) time.Time { return time.Date(2025, 6, 2, hour, minute, 0, 0, time.UTC) }
+	booking := func(user int64, hour, minute int) model.Appointment {
+		return model.Appointment{TrainerId: 1, UserId: user, StartTime: at(hour, minute), EndTime: at(hour, minute).Add(30 * time.Minute)}
+	}
+
+	// A booking and its repository calls
+	// ----------------------------------
+	_, err := svc.Create(ctx, booking(2, 10, 0))
+	require.NoError(t, err)
+
+	spans := rec.Ended()
+	create := spans[len(spans)-1]
+	assert.Equal(t, "AppointmentService.Create", create.Name())
+	assert.Contains(t, create.Attributes(), attrTrainerId.Int64(1))
+	assert.Contains(t, create.Attributes(), attrUserId.Int64(2))
+	assert.Contains(t, create.Attributes(), attrAppointmentId.Int64(1))
+
+	var repoCalls []string
+	for _, span := range spans[:len(spans)-1] {
+		assert.Equal(t, create.SpanContext().SpanID(), span.Parent().SpanID(), span.Name())
+		assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+		assert.Contains(t, span.Attributes(), attrDBSystem.String("memory"))
+		repoCalls = append(repoCalls, span.Name())
+	}
+	assert.Contains(t, repoCalls, "AppointmentRepository.GetTrainerBookings")
+	assert.Contains(t, repoCalls, "AppointmentRepository.Create")
+
+	// A conflicting booking
+	// ---------------------
+	_, err = svc.Create(ctx, booking(3, 10, 0))
+	require.Error(t, err)
+
+	spans = rec.Ended()
+	conflict := spans[len(spans)-1]
+	assert.Equal(t, codes.Unset, conflict.Status().Code)
+	assert.Contains(t, conflict.Attributes(), attrErrorCode.String("TRAINER_UNAVAILABLE"))
+	require.Len(t, conflict.Events(), 1)
+	assert.Equal(t, "exception", conflict.Events()[0].Name)
+
+	// Availability and import counts
+	// ------------------------------
+	slots, err := svc.GetAvailability(ctx, 1, at(0, 0), at(23, 0))
+	require.NoError(t, err)
+	spans = rec.Ended()
+	assert.Contains(t, spans[len(spans)-1].Attributes(), attrSlots.Int(len(slots)))
+
+	_, err = svc.Import(ctx, []model.ImportRow{
+		{Line: 2, Appointment: booking(4, 13, 0)},
+		{Line: 3, Appointment: booking(5, 13, 0)},
+	}, model.ImportOptions{Mode: model.ImportPartial})
+	require.NoError(t, err)
+
+	spans = rec.Ended()
+	imported := spans[len(spans)-1]
+	assert.Equal(t, "AppointmentService.Import", imported.Name())
+	assert.Contains(t, imported.Attributes(), attrImportRows.Int(2))
+	assert.Contains(t, imported.Attributes(), attrImported.Int(1))
+	assert.Contains(t, imported.Attributes(), attrRejected.Int(1))
+	assert.Contains(t, attributesOf(spans, "AppointmentRepository.CreateBatch"), attrRows.Int(1))
+}
+
+// TestPropagation tests trace context propagation and log correlation.
+//
+// It includes the following test cases:
+//
+// * A W3C traceparent header is extracted, even with tracing switched off
+// * Records logged in a span's context carry its trace and span ID
+// * Records logged without a span carry no IDs
+// * An unknown exporter is rejected
+func TestPropagation(t *testing.T) {
+	shutdown, err := Setup(context.Background(), ExporterNone, "test", version.GetInfo())
+	require.NoError(t, err)
+	defer shutdown(context.Background())
+
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.HeaderCarrier(header))
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", trace.SpanContextFromContext(ctx).TraceID().String())
+
+	var out bytes.Buffer
+	log := logger.NewLogger(logger.Config{Format: logger.FormatText, Output: &out})
+	log.With("component", "test").InfoContext(ctx, "traced")
+	assert.Contains(t, out.String(), "trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7")
+
+	out.Reset()
+	log.InfoContext(context.Background(), "untraced")
+	assert.NotContains(t, out.String(), "trace_id")
+
+	_, err = Setup(context.Background(), "zipkin", "test", version.GetInfo())
+	assert.ErrorContains(t, err, `unknown traces exporter "zipkin"`)
+}
+
+func attributesOf(spans []sdktrace.ReadOnlySpan, name string) []attribute.KeyValue {
+	for _, span := range spans {
+		if span.Name() == name {
+			return span.Attributes()
+		}
+	}
+	return nil
+}
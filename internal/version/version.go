@@ -22,8 +22,3 @@ func GetInfo() Info {
 		BuildTime: BuildTime,
 	}
 }
-func init() {
-	println("Version:", Version)
-	println("Commit:", Commit)
-	println("BuildTime:", BuildTime)
-}
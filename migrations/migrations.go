@@ -0,0 +1,12 @@
+// Package migrations embeds the SQL schema migrations so the binaries can
+// apply them without the files on disk. Each SQL dialect has its own
+// directory of golang-migrate style files, {version}_{name}.{up|down}.sql,
+// and every dialect must carry the same versions.
+package migrations
+
+import "embed"
+
+// FS holds the sqlite3 and postgres migration directories
+//
+//go:embed sqlite3/*.sql postgres/*.sql
+var FS embed.FS
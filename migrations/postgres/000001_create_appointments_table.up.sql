@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS appointments (
+    id BIGSERIAL PRIMARY KEY,
+    trainer_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    start_time TIMESTAMPTZ NOT NULL,
+    end_time TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_appointments_trainer_id ON appointments(trainer_id);
+CREATE INDEX IF NOT EXISTS idx_appointments_time_range ON appointments(trainer_id, start_time, end_time);
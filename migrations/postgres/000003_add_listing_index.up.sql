@@ -0,0 +1,2 @@
+-- Serves keyset pagination ordered by (start_time, id) for a trainer without a sort step
+CREATE INDEX IF NOT EXISTS idx_appointments_trainer_start ON appointments(trainer_id, start_time, id);
@@ -0,0 +1,4 @@
+
+DROP INDEX IF EXISTS idx_appointments_time_range;
+DROP INDEX IF EXISTS idx_appointments_trainer_id;
+DROP TABLE IF EXISTS appointments;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE appointments DROP COLUMN version;
+ALTER TABLE appointments DROP COLUMN status;
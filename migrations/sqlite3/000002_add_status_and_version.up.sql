@@ -0,0 +1,2 @@
+ALTER TABLE appointments ADD COLUMN status TEXT NOT NULL DEFAULT 'scheduled';
+ALTER TABLE appointments ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_appointments_user_start;
@@ -0,0 +1,2 @@
+-- Serves client listings and client booking checks, ordered by (start_time, id)
+CREATE INDEX IF NOT EXISTS idx_appointments_user_start ON appointments(user_id, start_time, id);